@@ -4,36 +4,69 @@ import (
 	"context"
 	"fmt"
 	"github.com/Sleeps17/linker/internal/app"
-	urlShortenerClient "github.com/Sleeps17/linker/internal/clients/url-shortener/url-shortener-client"
 	"github.com/Sleeps17/linker/internal/config"
 	"github.com/Sleeps17/linker/internal/logger"
-	"github.com/Sleeps17/linker/internal/storage/postgresql"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
+const (
+	migrateCmd      = "migrate"
+	exportCmd       = "export"
+	importCmd       = "import"
+	tokenCmd        = "token"
+	telegramCodeCmd = "telegram-code"
+)
+
 func main() {
 	// TODO: Load config
 	cfg := config.MustLoad()
 
-	// TODO: Init logger
-	log := logger.Setup(cfg.Env)
-	log.Info("logger configured successfully", slog.String("env", cfg.Env))
+	if len(os.Args) < 2 {
+		// TODO: Init logger
+		log := logger.Setup(cfg.Env)
+		log.Info("logger configured successfully", slog.String("env", cfg.Env))
+
+		serve(log, cfg)
+		return
+	}
+
+	// Commands print their result to stdout, so their logs go to stderr.
+	log := logger.SetupTo(cfg.Env, os.Stderr)
+
+	var err error
+	switch cmd := os.Args[1]; cmd {
+	case migrateCmd:
+		err = runMigrate(log, cfg, os.Args[2:])
+	case exportCmd:
+		err = runExport(log, cfg, os.Args[2:])
+	case importCmd:
+		err = runImport(log, cfg, os.Args[2:])
+	case tokenCmd:
+		err = runToken(log, cfg, os.Args[2:])
+	case telegramCodeCmd:
+		err = runTelegramCode(log, cfg, os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
 
+	if err != nil {
+		log.Error("command failed", slog.String("cmd", os.Args[1]), slog.String("err", err.Error()))
+		os.Exit(1)
+	}
+}
+
+func serve(log *slog.Logger, cfg *config.Config) {
 	// TODO: Init DB
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
 	defer cancel()
-	storage := postgresql.MustNew(ctx, createPostgresConnString(cfg))
-	log.Info("database configured successfully", slog.String("db_name", cfg.DataBase.Name))
+	storage := app.MustNewStorage(ctx, log, &cfg.DataBase)
+	log.Info("database configured successfully", slog.String("driver", cfg.DataBase.Driver))
 
-	urlShortener := urlShortenerClient.New(
-		cfg.UrlShortenerClient.Host,
-		cfg.UrlShortenerClient.Port,
-		cfg.UrlShortenerClient.Username,
-		cfg.UrlShortenerClient.Password,
-	)
+	urlShortener := app.MustNewUrlShortener(&cfg.UrlShortenerClient, log, storage)
+	log.Info("url shortener configured successfully", slog.String("driver", cfg.UrlShortenerClient.Driver))
 
 	// TODO: Init server
 	application := app.New(log, cfg, storage, urlShortener)
@@ -49,14 +82,3 @@ func main() {
 	<-stop
 	application.Stop()
 }
-
-func createPostgresConnString(cfg *config.Config) string {
-	return fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
-		cfg.DataBase.Host,
-		cfg.DataBase.Port,
-		cfg.DataBase.Username,
-		cfg.DataBase.Name,
-		cfg.DataBase.Password,
-	)
-}
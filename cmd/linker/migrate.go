@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/app"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/storage"
+	"log/slog"
+	"strconv"
+)
+
+const (
+	migrateUpCmd      = "up"
+	migrateDownCmd    = "down"
+	migrateVersionCmd = "version"
+
+	defaultDownSteps = 1
+)
+
+// runMigrate handles `linker migrate up|down [steps]|version`.
+func runMigrate(log *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: linker migrate up|down [steps]|version")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
+	defer cancel()
+
+	dbCfg := cfg.DataBase
+	dbCfg.SkipMigrations = true
+
+	s := app.MustNewStorage(ctx, log, &dbCfg)
+	defer func() { _ = s.Close(ctx) }()
+
+	migratable, ok := s.(storage.Migratable)
+	if !ok {
+		return fmt.Errorf("driver %q has no schema to migrate", dbCfg.Driver)
+	}
+	migrator := migratable.Migrator()
+
+	switch args[0] {
+	case migrateUpCmd:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Info("migrations applied", slog.Int("count", applied))
+	case migrateDownCmd:
+		steps := defaultDownSteps
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+
+		log.Info("migrations reverted", slog.Int("count", reverted))
+	case migrateVersionCmd:
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("schema version %d (latest %d)\n", version, migrator.Latest())
+
+	return nil
+}
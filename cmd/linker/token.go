@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/app"
+	"github.com/Sleeps17/linker/internal/auth"
+	"github.com/Sleeps17/linker/internal/config"
+	"log/slog"
+	"os"
+)
+
+const (
+	tokenUsage        = "usage: linker token -user <username> [-name <name>]"
+	telegramCodeUsage = "usage: linker telegram-code -user <username>"
+)
+
+// runToken handles `linker token`, issuing an API token for a user. The REST
+// API needs a token for every call, so the first one comes from here. Only the
+// token is printed to stdout, e.g. for TOKEN=$(linker token -user bob).
+func runToken(log *slog.Logger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet(tokenCmd, flag.ContinueOnError)
+	username := flags.String("user", "", "username to issue the token for")
+	name := flags.String("name", "cli", "name to tell the token apart")
+
+	if err := flags.Parse(args); err != nil || *username == "" || flags.NArg() != 0 {
+		return errors.New(tokenUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
+	defer cancel()
+
+	s := app.MustNewStorage(ctx, log, &cfg.DataBase)
+	defer func() { _ = s.Close(context.Background()) }()
+
+	token, info, err := auth.New(s).IssueToken(ctx, *username, *name)
+	if err != nil {
+		return err
+	}
+
+	log.Info("api token issued", slog.String("username", *username), slog.Any("id", info.ID))
+
+	fmt.Fprintln(os.Stdout, token)
+
+	return nil
+}
+
+// runTelegramCode handles `linker telegram-code`, issuing a one-time code that
+// binds a Telegram account to a user with /link_account. Only the code is
+// printed to stdout.
+func runTelegramCode(log *slog.Logger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet(telegramCodeCmd, flag.ContinueOnError)
+	username := flags.String("user", "", "username to bind the Telegram account to")
+
+	if err := flags.Parse(args); err != nil || *username == "" || flags.NArg() != 0 {
+		return errors.New(telegramCodeUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
+	defer cancel()
+
+	s := app.MustNewStorage(ctx, log, &cfg.DataBase)
+	defer func() { _ = s.Close(context.Background()) }()
+
+	code, expiresAt, err := auth.New(s).IssueLinkCode(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	log.Info("telegram link code issued", slog.String("username", *username), slog.Time("expires_at", expiresAt))
+
+	fmt.Fprintln(os.Stdout, code)
+
+	return nil
+}
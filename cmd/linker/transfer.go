@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/app"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"io"
+	"log/slog"
+	"os"
+)
+
+const (
+	exportUsage = "usage: linker export -user <username> [-format json|csv] <file>"
+	importUsage = "usage: linker import -user <username> [-format json|csv] [-policy skip|overwrite|rename] <file>"
+)
+
+// runExport handles `linker export`, writing the account of a user to a file.
+// The file "-" is stdout, the logs of commands go to stderr.
+func runExport(log *slog.Logger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet(exportCmd, flag.ContinueOnError)
+	username := flags.String("user", "", "username to export")
+	format := flags.String("format", string(transfer.FormatJSON), "file format, json or csv")
+
+	if err := flags.Parse(args); err != nil || *username == "" || flags.NArg() != 1 {
+		return errors.New(exportUsage)
+	}
+
+	var w io.Writer = os.Stdout
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	// The timeout bounds connecting, a large account takes longer to move.
+	connectCtx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
+	defer cancel()
+
+	s := app.MustNewStorage(connectCtx, log, &cfg.DataBase)
+
+	ctx := context.Background()
+	defer func() { _ = s.Close(ctx) }()
+
+	if err := transfer.New(s).ExportAccount(ctx, *username, transfer.Format(*format), w); err != nil {
+		return err
+	}
+
+	log.Info("account exported", slog.String("username", *username), slog.String("format", *format))
+
+	return nil
+}
+
+// runImport handles `linker import`, merging a file made by `linker export`
+// into the account of a user. The file "-" is stdin.
+func runImport(log *slog.Logger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet(importCmd, flag.ContinueOnError)
+	username := flags.String("user", "", "username to import to")
+	format := flags.String("format", string(transfer.FormatJSON), "file format, json or csv")
+	policy := flags.String("policy", string(transfer.PolicySkip), "what to do with links whose alias is taken: skip, overwrite or rename")
+
+	if err := flags.Parse(args); err != nil || *username == "" || flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	var r io.Reader = os.Stdin
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	// The timeout bounds connecting, a large account takes longer to move.
+	connectCtx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
+	defer cancel()
+
+	s := app.MustNewStorage(connectCtx, log, &cfg.DataBase)
+
+	ctx := context.Background()
+	defer func() { _ = s.Close(ctx) }()
+
+	result, err := transfer.New(s).ImportAccount(ctx, *username, transfer.Format(*format), transfer.Policy(*policy), r)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("topics created %d, links added %d, updated %d, skipped %d\n", result.Topics, result.Links, result.Updated, result.Skipped)
+
+	return nil
+}
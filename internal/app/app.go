@@ -2,10 +2,15 @@ package app
 
 import (
 	botapp "github.com/Sleeps17/linker/internal/app/bot"
+	grpcapp "github.com/Sleeps17/linker/internal/app/grpc"
 	httpapp "github.com/Sleeps17/linker/internal/app/http"
+	outboxapp "github.com/Sleeps17/linker/internal/app/outbox"
+	trashapp "github.com/Sleeps17/linker/internal/app/trash"
+	"github.com/Sleeps17/linker/internal/auth"
 	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
 	"github.com/Sleeps17/linker/internal/config"
 	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
 	"log/slog"
 )
 
@@ -22,7 +27,7 @@ func New(
 	log *slog.Logger,
 	cfg *config.Config,
 	storage storage.Storage,
-	_ urlShortener.UrlShortener,
+	urlShortener urlShortener.UrlShortener,
 ) *Service {
 	var apps []app
 
@@ -30,6 +35,8 @@ func New(
 		apps,
 		httpapp.New(
 			&cfg.Rest,
+			&cfg.Redirect,
+			&cfg.Publish,
 			log,
 			storage,
 		),
@@ -37,13 +44,50 @@ func New(
 
 	apps = append(
 		apps,
-		botapp.MustNew(
-			&cfg.Bot,
+		grpcapp.New(
+			&cfg.Grpc,
 			log,
 			storage,
+			storage,
+			storage,
+			transfer.New(storage),
+			storage,
+			auth.New(storage),
+		),
+	)
+
+	apps = append(
+		apps,
+		outboxapp.New(
+			&cfg.ShortenerSync,
+			log,
+			storage,
+			urlShortener,
 		),
 	)
 
+	apps = append(
+		apps,
+		trashapp.New(
+			&cfg.Trash,
+			log,
+			storage,
+		),
+	)
+
+	if cfg.Bot.Token != "" {
+		apps = append(
+			apps,
+			botapp.MustNew(
+				&cfg.Bot,
+				log,
+				storage,
+			),
+		)
+	} else {
+		log.Warn("bot token is not set, telegram bot disabled")
+	}
+
 	return &Service{
 		apps: apps,
 	}
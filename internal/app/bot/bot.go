@@ -1,9 +1,11 @@
 package botapp
 
 import (
+	"github.com/Sleeps17/linker/internal/auth"
 	linkerbot "github.com/Sleeps17/linker/internal/bot/linker"
 	"github.com/Sleeps17/linker/internal/config"
 	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
 	"log/slog"
 )
 
@@ -13,7 +15,7 @@ type App struct {
 }
 
 func MustNew(cfg *config.BotConfig, log *slog.Logger, storage storage.Storage) *App {
-	bot, err := linkerbot.New(cfg, log, storage, storage)
+	bot, err := linkerbot.New(cfg, log, storage, storage, storage, storage, transfer.New(storage), auth.New(storage), storage)
 	if err != nil {
 		panic(err)
 	}
@@ -2,7 +2,7 @@ package grpcapp
 
 import (
 	"fmt"
-	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"github.com/Sleeps17/linker/internal/config"
 	server "github.com/Sleeps17/linker/internal/grpc/linker"
 	"google.golang.org/grpc"
 	"log/slog"
@@ -12,38 +12,50 @@ import (
 type App struct {
 	log    *slog.Logger
 	server *grpc.Server
-	port   int
+	cfg    *config.ServerConfig
 }
 
 func New(
+	cfg *config.ServerConfig,
 	log *slog.Logger,
-	port int,
 	linkerService server.LinkService,
 	topicService server.TopicService,
-	urlShortener urlShortener.UrlShortener,
+	trashService server.TrashService,
+	transferService server.TransferService,
+	shareService server.ShareService,
+	authenticator server.Authenticator,
 ) *App {
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(server.AuthUnaryInterceptor(authenticator)),
+		grpc.ChainStreamInterceptor(server.AuthStreamInterceptor(authenticator)),
+	)
 
-	server.Register(grpcServer, log, linkerService, topicService, urlShortener)
+	server.Register(grpcServer, log, linkerService, topicService)
+	server.RegisterTrash(grpcServer, log, trashService)
+	server.RegisterTransfer(grpcServer, log, transferService)
+	server.RegisterSharing(grpcServer, log, shareService)
 
 	return &App{
 		log:    log,
 		server: grpcServer,
-		port:   port,
+		cfg:    cfg,
 	}
 }
 
 func (a *App) MustRun() {
-	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+	l, err := net.Listen("tcp", a.cfg.Port)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to listen: %v", err))
 	}
 
+	a.log.Info("grpc server started", slog.String("address", l.Addr().String()))
+
 	if err := a.server.Serve(l); err != nil {
 		panic(fmt.Sprintf("Failed to serve: %v", err))
 	}
 }
 
 func (a *App) Stop() {
+	a.log.Info("grpc server stopped")
 	a.server.GracefulStop()
 }
@@ -3,10 +3,13 @@ package httpapp
 import (
 	"context"
 	"errors"
+	"github.com/Sleeps17/linker/internal/auth"
 	"github.com/Sleeps17/linker/internal/config"
 	httpserver "github.com/Sleeps17/linker/internal/http/linker"
 	handlers2 "github.com/Sleeps17/linker/internal/http/linker/handlers"
+	"github.com/Sleeps17/linker/internal/publish"
 	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
 	"log/slog"
 	"net/http"
 )
@@ -17,11 +20,45 @@ type App struct {
 	cfg *config.ServerConfig
 }
 
-func New(cfg *config.ServerConfig, log *slog.Logger, storage storage.Storage) *App {
+func New(
+	cfg *config.ServerConfig,
+	redirectCfg *config.RedirectConfig,
+	publishCfg *config.PublishConfig,
+	log *slog.Logger,
+	storage storage.Storage,
+) *App {
 	topicHandler := handlers2.NewTopicHandler(log, storage)
 	linkHandler := handlers2.NewLinkHandler(log, storage)
+	tagHandler := handlers2.NewTagHandler(log, storage)
+	trashHandler := handlers2.NewTrashHandler(log, storage)
+	transferService := transfer.New(storage)
+	bookmarksHandler := handlers2.NewBookmarksHandler(log, transferService)
+	accountHandler := handlers2.NewAccountHandler(log, transferService)
+	redirectHandler := handlers2.NewRedirectHandler(log, redirectCfg, storage)
+	shortURLHandler := handlers2.NewShortURLHandler(log, storage)
+	metricsHandler := handlers2.NewMetricsHandler()
+	authService := auth.New(storage)
+	tokenHandler := handlers2.NewTokenHandler(log, authService)
+	telegramHandler := handlers2.NewTelegramHandler(log, authService)
+	shareHandler := handlers2.NewShareHandler(log, storage)
+	publishService := publish.New(storage)
+	publishHandler := handlers2.NewPublishHandler(log, publishCfg, publishService)
+	publicTopicHandler := handlers2.NewPublicTopicHandler(log, publishCfg, publishService)
 
-	srv := httpserver.NewServer(cfg, topicHandler, linkHandler)
+	public := []handlers2.Handler{shortURLHandler, publicTopicHandler}
+	api := []handlers2.Handler{
+		topicHandler, shareHandler, publishHandler, linkHandler, tagHandler, trashHandler,
+		bookmarksHandler, accountHandler, tokenHandler, telegramHandler, metricsHandler,
+	}
+
+	// Redirects to the links of users take API tokens when they require auth.
+	if redirectCfg.RequireAuth {
+		api = append(api, redirectHandler)
+	} else {
+		public = append(public, redirectHandler)
+	}
+
+	srv := httpserver.NewServer(cfg, authService, public, api...)
 
 	return &App{
 		log: log,
@@ -0,0 +1,180 @@
+package outboxapp
+
+import (
+	"context"
+	"errors"
+	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"log/slog"
+	"time"
+)
+
+type Outbox interface {
+	ClaimShortenerTasks(ctx context.Context, limit int, lease time.Duration) (tasks []models.ShortenerTask, err error)
+	CompleteShortenerTask(ctx context.Context, task models.ShortenerTask, shortURL, code string) (err error)
+	RetryShortenerTask(ctx context.Context, taskID uint32, retryAt time.Time, lastErr string) (err error)
+	DiscardShortenerTask(ctx context.Context, taskID uint32) (err error)
+}
+
+// App applies the shortener tasks queued with link changes, so that the URL
+// shortener eventually follows the links even when it is down for a while.
+type App struct {
+	log          *slog.Logger
+	cfg          *config.ShortenerSyncConfig
+	outbox       Outbox
+	urlShortener urlShortener.UrlShortener
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func New(cfg *config.ShortenerSyncConfig, log *slog.Logger, outbox Outbox, urlShortener urlShortener.UrlShortener) *App {
+	return &App{
+		log:          log,
+		cfg:          cfg,
+		outbox:       outbox,
+		urlShortener: urlShortener,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+func (a *App) MustRun() {
+	defer close(a.done)
+
+	a.log.Info(
+		"shortener sync started",
+		slog.Duration("interval", a.cfg.PollInterval),
+		slog.Int("batch_size", a.cfg.BatchSize),
+	)
+
+	ticker := time.NewTicker(a.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.sync()
+
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func (a *App) Stop() {
+	a.log.Info("shortener sync stopped")
+	close(a.stop)
+	<-a.done
+}
+
+func (a *App) sync() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Lease)
+	defer cancel()
+
+	// Stopping abandons the batch, its tasks are claimed again once the lease
+	// is over.
+	go func() {
+		select {
+		case <-a.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	tasks, err := a.outbox.ClaimShortenerTasks(ctx, a.cfg.BatchSize, a.cfg.Lease)
+	if err != nil {
+		a.log.Error("failed to claim shortener tasks", slog.String("err", err.Error()))
+		return
+	}
+
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+
+		a.apply(ctx, task)
+	}
+}
+
+func (a *App) apply(ctx context.Context, task models.ShortenerTask) {
+	log := a.log.With(
+		slog.Any("task_id", task.ID),
+		slog.String("kind", string(task.Kind)),
+		slog.String("alias", task.Alias),
+		slog.Int("attempt", task.Attempts),
+	)
+
+	var shortURL, code string
+	var err error
+
+	switch task.Kind {
+	case models.ShortenerSave:
+		shortURL, code, err = a.urlShortener.SaveURL(ctx, task.URL, task.Alias)
+	case models.ShortenerDelete:
+		// The short URL may be gone already, e.g. after an attempt whose
+		// response was lost.
+		err = a.urlShortener.DeleteURL(ctx, task.Alias)
+		if errors.Is(err, urlShortener.ErrNotFound) {
+			err = nil
+		}
+	default:
+		log.Error("unknown shortener task, dropped")
+		a.discard(ctx, log, task)
+		return
+	}
+
+	if err == nil {
+		if err := a.outbox.CompleteShortenerTask(ctx, task, shortURL, code); err != nil {
+			log.Error("failed to complete shortener task", slog.String("err", err.Error()))
+			return
+		}
+
+		log.Info("shortener task applied")
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	// The shortener has refused the task, repeating it won't help.
+	if !errors.Is(err, urlShortener.ErrUnavailable) {
+		log.Error("shortener task rejected, dropped", slog.String("err", err.Error()))
+		a.discard(ctx, log, task)
+		return
+	}
+
+	if task.Attempts >= a.cfg.MaxAttempts {
+		log.Error("shortener task failed too many times, dropped", slog.String("err", err.Error()))
+		a.discard(ctx, log, task)
+		return
+	}
+
+	retryAt := time.Now().Add(a.backoff(task.Attempts))
+
+	log.Warn("shortener task failed, retry later", slog.String("err", err.Error()), slog.Time("retry_at", retryAt))
+
+	if err := a.outbox.RetryShortenerTask(ctx, task.ID, retryAt, err.Error()); err != nil {
+		log.Error("failed to reschedule shortener task", slog.String("err", err.Error()))
+	}
+}
+
+func (a *App) discard(ctx context.Context, log *slog.Logger, task models.ShortenerTask) {
+	if err := a.outbox.DiscardShortenerTask(ctx, task.ID); err != nil {
+		log.Error("failed to drop shortener task", slog.String("err", err.Error()))
+	}
+}
+
+// backoff doubles the retry delay with every failed attempt.
+func (a *App) backoff(attempts int) time.Duration {
+	delay := a.cfg.RetryBackoff
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= a.cfg.MaxBackoff {
+			return a.cfg.MaxBackoff
+		}
+	}
+
+	return delay
+}
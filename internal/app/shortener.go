@@ -0,0 +1,29 @@
+package app
+
+import (
+	"fmt"
+	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	builtinShortener "github.com/Sleeps17/linker/internal/clients/url-shortener/builtin"
+	urlShortenerClient "github.com/Sleeps17/linker/internal/clients/url-shortener/url-shortener-client"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/storage"
+	"log/slog"
+)
+
+const (
+	externalShortenerDriver = "external"
+	builtinShortenerDriver  = "builtin"
+)
+
+// MustNewUrlShortener returns the URL shortener chosen by cfg.Driver, the
+// built-in one keeps its codes in storage.
+func MustNewUrlShortener(cfg *config.UrlShortenerClientConfig, log *slog.Logger, storage storage.Storage) urlShortener.UrlShortener {
+	switch cfg.Driver {
+	case externalShortenerDriver:
+		return urlShortenerClient.New(cfg, log)
+	case builtinShortenerDriver:
+		return builtinShortener.New(storage, cfg.BaseURL)
+	default:
+		panic(fmt.Sprintf("unknown url shortener driver: %q", cfg.Driver))
+	}
+}
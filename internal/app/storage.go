@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/storage/memory"
+	"github.com/Sleeps17/linker/internal/storage/postgresql"
+	"github.com/Sleeps17/linker/internal/storage/sqlite"
+	"log/slog"
+)
+
+const (
+	postgresDriver = "postgres"
+	sqliteDriver   = "sqlite"
+	memoryDriver   = "memory"
+)
+
+// MustNewStorage opens the storage backend chosen by cfg.Driver and brings
+// its schema up to date unless cfg.SkipMigrations is set.
+func MustNewStorage(ctx context.Context, log *slog.Logger, cfg *config.DataBaseConfig) storage.Storage {
+	var s storage.Storage
+
+	switch cfg.Driver {
+	case postgresDriver:
+		s = postgresql.MustNew(ctx, createPostgresConnString(cfg))
+	case sqliteDriver:
+		s = sqlite.MustNew(ctx, cfg.Path)
+	case memoryDriver:
+		s = memory.New()
+	default:
+		panic(fmt.Sprintf("unknown data base driver: %q", cfg.Driver))
+	}
+
+	migratable, ok := s.(storage.Migratable)
+	if !ok || cfg.SkipMigrations {
+		return s
+	}
+
+	applied, err := migratable.Migrator().Up(ctx)
+	if err != nil {
+		panic("failed to migrate database: " + err.Error())
+	}
+
+	log.Info(
+		"database schema is up to date",
+		slog.Int("applied", applied),
+		slog.Any("version", migratable.Migrator().Latest()),
+	)
+
+	return s
+}
+
+func createPostgresConnString(cfg *config.DataBaseConfig) string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
+		cfg.Host,
+		cfg.Port,
+		cfg.Username,
+		cfg.Name,
+		cfg.Password,
+	)
+}
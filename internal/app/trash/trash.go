@@ -0,0 +1,76 @@
+package trashapp
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/config"
+	"log/slog"
+	"time"
+)
+
+type Purger interface {
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (purged int64, err error)
+}
+
+// App periodically purges topics and links that have been in the trash longer
+// than the retention period.
+type App struct {
+	log    *slog.Logger
+	cfg    *config.TrashConfig
+	purger Purger
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func New(cfg *config.TrashConfig, log *slog.Logger, purger Purger) *App {
+	return &App{
+		log:    log,
+		cfg:    cfg,
+		purger: purger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (a *App) MustRun() {
+	defer close(a.done)
+
+	a.log.Info(
+		"trash purger started",
+		slog.Duration("retention", a.cfg.Retention),
+		slog.Duration("interval", a.cfg.PurgeInterval),
+	)
+
+	ticker := time.NewTicker(a.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		a.purge()
+
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func (a *App) Stop() {
+	a.log.Info("trash purger stopped")
+	close(a.stop)
+	<-a.done
+}
+
+func (a *App) purge() {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.PurgeInterval)
+	defer cancel()
+
+	purged, err := a.purger.PurgeTrash(ctx, time.Now().Add(-a.cfg.Retention))
+	if err != nil {
+		a.log.Error("failed to purge trash", slog.String("err", err.Error()))
+		return
+	}
+
+	if purged > 0 {
+		a.log.Info("trash purged", slog.Int64("purged", purged))
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenPrefix makes tokens easy to recognise, for example by secret
+	// scanners.
+	tokenPrefix = "lnk_"
+	tokenBytes  = 32
+
+	// Link codes are typed by hand, so they are short and skip look-alike
+	// characters. The alphabet has 32 characters, a random byte picks one
+	// without bias.
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	linkCodeLength   = 8
+	LinkCodeTTL      = 10 * time.Minute
+)
+
+var ErrInvalidToken = errors.New("invalid api token")
+
+// Store keeps the hashes of the API tokens.
+type Store interface {
+	CreateToken(ctx context.Context, username, name, tokenHash string) (token models.APIToken, err error)
+	ListTokens(ctx context.Context, username string) (tokens []models.APIToken, err error)
+	RevokeToken(ctx context.Context, username string, tokenID uint32) (err error)
+	TokenUser(ctx context.Context, tokenHash string) (username string, err error)
+	TelegramUser(ctx context.Context, telegramID int64, username string) (boundUsername string, err error)
+	CreateLinkCode(ctx context.Context, username, codeHash string, expiresAt time.Time) (err error)
+	UseLinkCode(ctx context.Context, telegramID int64, codeHash string) (username string, err error)
+}
+
+// Service issues API tokens and tells whom a token belongs to. Tokens are
+// random, so a plain SHA-256 of a token is enough to keep it secret and
+// still find it by the hash.
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// IssueToken creates a new token of username, the user is created if needed.
+// The token is returned only here, only its hash is stored.
+func (s *Service) IssueToken(ctx context.Context, username, name string) (string, models.APIToken, error) {
+	const op = "auth.IssueToken"
+
+	secret := make([]byte, tokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", models.APIToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	info, err := s.store.CreateToken(ctx, username, name, hashToken(token))
+	if err != nil {
+		return "", models.APIToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, info, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context, username string) ([]models.APIToken, error) {
+	return s.store.ListTokens(ctx, username)
+}
+
+func (s *Service) RevokeToken(ctx context.Context, username string, tokenID uint32) error {
+	return s.store.RevokeToken(ctx, username, tokenID)
+}
+
+// Authenticate returns the owner of token.
+func (s *Service) Authenticate(ctx context.Context, token string) (string, error) {
+	const op = "auth.Authenticate"
+
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return "", ErrInvalidToken
+	}
+
+	username, err := s.store.TokenUser(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return "", ErrInvalidToken
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return username, nil
+}
+
+// IssueLinkCode creates a one-time code that binds a Telegram account to
+// username, the code is sent to the bot with /link_account.
+func (s *Service) IssueLinkCode(ctx context.Context, username string) (string, time.Time, error) {
+	const op = "auth.IssueLinkCode"
+
+	random := make([]byte, linkCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	code := make([]byte, linkCodeLength)
+	for i, b := range random {
+		code[i] = linkCodeAlphabet[int(b)%len(linkCodeAlphabet)]
+	}
+
+	expiresAt := time.Now().Add(LinkCodeTTL)
+	if err := s.store.CreateLinkCode(ctx, username, hashToken(string(code)), expiresAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return string(code), expiresAt, nil
+}
+
+// LinkTelegram binds the Telegram account to the user of code and returns
+// the user. It fails with storage.ErrLinkCodeNotFound for unknown, used and
+// expired codes.
+func (s *Service) LinkTelegram(ctx context.Context, telegramID int64, code string) (string, error) {
+	return s.store.UseLinkCode(ctx, telegramID, hashToken(strings.ToUpper(strings.TrimSpace(code))))
+}
+
+// TelegramUser returns the user the Telegram account is bound to, binding the
+// account to username when it is seen for the first time.
+func (s *Service) TelegramUser(ctx context.Context, telegramID int64, username string) (string, error) {
+	return s.store.TelegramUser(ctx, telegramID, username)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
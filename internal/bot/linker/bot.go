@@ -22,6 +22,11 @@ func New(
 	log *slog.Logger,
 	topicService bothandlers.TopicService,
 	linkService bothandlers.LinkService,
+	tagService bothandlers.TagService,
+	trashService bothandlers.TrashService,
+	bookmarksService bothandlers.BookmarksService,
+	accountService bothandlers.AccountService,
+	shareService bothandlers.ShareService,
 ) (*Bot, error) {
 	bot, err := gotgbot.NewBot(cfg.Token, nil)
 	if err != nil {
@@ -42,8 +47,13 @@ func New(
 	var handle []bothandlers.Handler
 	handle = append(
 		handle,
+		bothandlers.NewAccountHandler(cfg, log, accountService),
 		bothandlers.NewTopicsHandler(cfg, log, topicService),
+		bothandlers.NewSharesHandler(cfg, log, shareService),
 		bothandlers.NewLinksHandler(cfg, log, linkService),
+		bothandlers.NewTagsHandler(cfg, log, tagService),
+		bothandlers.NewTrashHandler(cfg, log, trashService),
+		bothandlers.NewBookmarksHandler(cfg, log, bookmarksService),
 	)
 
 	for _, h := range handle {
@@ -66,7 +76,7 @@ func (b *Bot) MustRun() {
 		DropPendingUpdates: true,
 		GetUpdatesOpts: &gotgbot.GetUpdatesOpts{
 			Timeout:        int64(b.cfg.UpdateTimeout.Seconds()),
-			AllowedUpdates: []string{"message"},
+			AllowedUpdates: []string{"message", "callback_query"},
 			RequestOpts: &gotgbot.RequestOpts{
 				Timeout: b.cfg.RequestTimeout,
 			},
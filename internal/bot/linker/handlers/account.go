@@ -0,0 +1,127 @@
+package bothandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/storage"
+	"log/slog"
+)
+
+const (
+	linkAccountCmd = "link_account"
+
+	// usernameKey keeps the linker user of the sender in the update data.
+	usernameKey = "username"
+
+	// resolveGroup runs before the command handlers, which are in group 0.
+	resolveGroup = -1
+)
+
+type AccountService interface {
+	TelegramUser(ctx context.Context, telegramID int64, username string) (boundUsername string, err error)
+	LinkTelegram(ctx context.Context, telegramID int64, code string) (username string, err error)
+}
+
+// AccountHandler finds the linker user of every message by the numeric id of
+// the sender, Telegram usernames may be missing or change. An account seen for
+// the first time keeps the user named after its username only when no other
+// account or API token claims that user, otherwise it gets the user tg<id>.
+// /link_account rebinds it to the user of a one-time code issued through the
+// REST API or the CLI.
+type AccountHandler struct {
+	accountService AccountService
+	log            *slog.Logger
+	cfg            *config.BotConfig
+}
+
+func NewAccountHandler(
+	cfg *config.BotConfig,
+	log *slog.Logger,
+	accountService AccountService,
+) *AccountHandler {
+	return &AccountHandler{
+		cfg:            cfg,
+		log:            log,
+		accountService: accountService,
+	}
+}
+
+func (h *AccountHandler) Register(dispatcher *ext.Dispatcher) {
+	dispatcher.AddHandlerToGroup(handlers.NewMessage(message.All, h.resolveUser), resolveGroup)
+	dispatcher.AddHandler(handlers.NewCommand(linkAccountCmd, h.linkAccount))
+}
+
+// linkerUser returns the linker user of the message sender.
+func linkerUser(extctx *ext.Context) string {
+	username, _ := extctx.Data[usernameKey].(string)
+	return username
+}
+
+func (h *AccountHandler) resolveUser(bot *gotgbot.Bot, extctx *ext.Context) error {
+	from := extctx.Message.From
+	if from == nil {
+		return ext.EndGroups
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	bound, err := h.accountService.TelegramUser(ctx, from.Id, from.Username)
+	if err != nil {
+		h.log.Error("failed to resolve telegram user", slog.Int64("telegram_id", from.Id), slog.String("err", err.Error()))
+		if err := sendMessage(bot, extctx.Message.Chat.Id, "Не удалось определить пользователя, попробуйте позже"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	extctx.Data[usernameKey] = bound
+
+	return nil
+}
+
+func (h *AccountHandler) linkAccount(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Code == "" {
+		if err := sendMessage(bot, chatID, "Аргумент code обязателен, код выдает POST /telegram/code или linker telegram-code"); err != nil {
+			return err
+		}
+
+		return ext.EndGroups
+	}
+
+	username, err := h.accountService.LinkTelegram(ctx, extctx.Message.From.Id, args.Code)
+	if err != nil {
+		text := "Не удалось привязать аккаунт"
+		if errors.Is(err, storage.ErrLinkCodeNotFound) {
+			text = "Код не найден или устарел"
+		} else {
+			h.log.Error("failed to link telegram account", slog.String("err", err.Error()))
+		}
+
+		if err := sendMessage(bot, chatID, text); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessage(bot, chatID, fmt.Sprintf("Аккаунт привязан к пользователю %s", username)); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
@@ -0,0 +1,199 @@
+package bothandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	importBookmarksCmd = "import_bookmarks"
+	exportBookmarksCmd = "export_bookmarks"
+
+	// Downloading and importing a file takes longer than other commands.
+	bookmarksTimeout = 30 * time.Second
+	maxBookmarksSize = 10 << 20
+)
+
+type BookmarksService interface {
+	ImportBookmarks(ctx context.Context, username, parent string, r io.Reader) (result models.ImportResult, err error)
+	ExportBookmarks(ctx context.Context, username string, w io.Writer) (err error)
+}
+
+type BookmarksHandler struct {
+	bookmarksService BookmarksService
+	log              *slog.Logger
+	cfg              *config.BotConfig
+}
+
+func NewBookmarksHandler(
+	cfg *config.BotConfig,
+	log *slog.Logger,
+	bookmarksService BookmarksService,
+) *BookmarksHandler {
+	return &BookmarksHandler{
+		cfg:              cfg,
+		log:              log,
+		bookmarksService: bookmarksService,
+	}
+}
+
+func (h *BookmarksHandler) Register(dispatcher *ext.Dispatcher) {
+	dispatcher.AddHandler(handlers.NewCommand(importBookmarksCmd, h.importBookmarks))
+	dispatcher.AddHandler(handlers.NewCommand(exportBookmarksCmd, h.exportBookmarks))
+
+	// A bookmark file sent without the command is imported to the top level.
+	dispatcher.AddHandler(handlers.NewMessage(isBookmarksFile, h.importBookmarks))
+}
+
+func isBookmarksFile(msg *gotgbot.Message) bool {
+	if msg.Document == nil {
+		return false
+	}
+
+	extension := strings.ToLower(path.Ext(msg.Document.FileName))
+	return extension == ".html" || extension == ".htm"
+}
+
+// importBookmarks imports the bookmark file attached to the message, the
+// caption may hold the command with the topic to import to.
+func (h *BookmarksHandler) importBookmarks(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), bookmarksTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	if !isBookmarksFile(extctx.Message) {
+		text := "Отправьте HTML-файл закладок, экспортированный из браузера, как документ. " +
+			"Чтобы импортировать папки в топик, добавьте подпись /import_bookmarks topic:<topic>"
+		if err := sendMessage(bot, chatID, text); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	args := &models.CmdArgs{}
+	if caption := extctx.Message.Caption; strings.HasPrefix(caption, "/") {
+		var err error
+		if args, err = parseCommandArgs(caption); err != nil {
+			return fmt.Errorf("failed to parse command args: %w", err)
+		}
+	}
+
+	if extctx.Message.Document.FileSize > maxBookmarksSize {
+		if err := sendMessage(bot, chatID, "Файл закладок слишком большой"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	data, err := downloadFile(ctx, bot, extctx.Message.Document.FileId)
+	if err != nil {
+		h.log.Error("failed to download bookmarks", slog.String("err", err.Error()))
+		if err := sendMessage(bot, chatID, "Не удалось загрузить файл закладок"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+
+	result, err := h.bookmarksService.ImportBookmarks(ctx, username, args.Topic, bytes.NewReader(data))
+	if err != nil {
+		text := "Не удалось импортировать закладки"
+
+		switch {
+		case errors.Is(err, storage.ErrInvalidTopicPath):
+			text = "Неверный путь топика"
+		case errors.Is(err, transfer.ErrNoBookmarks):
+			text = "В файле нет закладок"
+		default:
+			h.log.Error("failed to import bookmarks", slog.String("err", err.Error()))
+		}
+
+		if err := sendMessage(bot, chatID, text); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	text := fmt.Sprintf(
+		"Импорт завершен: топиков создано %d, ссылок добавлено %d, пропущено уже сохраненных %d",
+		result.Topics, result.Links, result.Skipped,
+	)
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *BookmarksHandler) exportBookmarks(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), bookmarksTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+	username := linkerUser(extctx)
+
+	var buf bytes.Buffer
+	if err := h.bookmarksService.ExportBookmarks(ctx, username, &buf); err != nil {
+		text := "Не удалось экспортировать закладки"
+
+		if errors.Is(err, storage.ErrUserNotFound) {
+			text = "Пользователь не найден"
+		} else {
+			h.log.Error("failed to export bookmarks", slog.String("err", err.Error()))
+		}
+
+		if err := sendMessage(bot, chatID, text); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	_, err := bot.SendDocument(chatID, gotgbot.InputFileByReader("bookmarks.html", &buf), &gotgbot.SendDocumentOpts{
+		Caption: "Файл можно импортировать в любой браузер",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send document: %w", err)
+	}
+	return ext.EndGroups
+}
+
+// downloadFile fetches a file sent to the bot.
+func downloadFile(ctx context.Context, bot *gotgbot.Bot, fileID string) ([]byte, error) {
+	file, err := bot.GetFile(fileID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.URL(bot, nil), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxBookmarksSize))
+}
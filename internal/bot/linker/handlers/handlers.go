@@ -1,10 +1,13 @@
 package bothandlers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -13,7 +16,10 @@ import (
 const (
 	handlersTimeout = 5 * time.Second
 
-	commandPattern = `^\/(?P<command>\w+)(?:\s+(topic:(?P<topic>[^ ]+)|link:(?P<link>[^ ]+)|alias:(?P<alias>[^ ]+)))*$`
+	// Free text arguments (title, description, note) may be quoted to contain spaces: note:"read before review".
+	commandPattern = `^\/(?P<command>\w+)(?:\s+(topic:(?P<topic>[^ ]+)|link:(?P<link>[^ ]+)|alias:(?P<alias>[^ ]+)|tag:(?P<tag>[^ ]+)|` +
+		`to:(?P<to>[^ ]+)|new_alias:(?P<new_alias>[^ ]+)|recursive:(?P<recursive>true|false)|` +
+		`code:(?P<code>[^ ]+)|user:(?P<user>[^ ]+)|role:(?P<role>[^ ]+)|title:(?P<title>"[^"]*"|[^ ]+)|description:(?P<description>"[^"]*"|[^ ]+)|note:(?P<note>"[^"]*"|[^ ]+)))*$`
 )
 
 type Handler interface {
@@ -29,11 +35,33 @@ func sendMessage(api *gotgbot.Bot, chatID int64, text string) error {
 	return nil
 }
 
-func sendMessageMD(api *gotgbot.Bot, chatID int64, text string) error {
-	escapedText := escapeMarkdownV2(text)
-	escapedText = "```\n" + escapedText + "\n```"
+// sendLinkError reports storage errors of commands addressing a single link.
+func sendLinkError(bot *gotgbot.Bot, log *slog.Logger, chatID int64, err error, fallback string) error {
+	text := fallback
+
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		text = "Пользователь не найден"
+	case errors.Is(err, storage.ErrTopicNotFound):
+		text = "Топик не найден"
+	case errors.Is(err, storage.ErrPermissionDenied):
+		text = "Недостаточно прав для топика"
+	case errors.Is(err, storage.ErrAliasNotFound):
+		text = "Ссылка не найдена"
+	case errors.Is(err, storage.ErrAliasAlreadyExists):
+		text = "Ссылка с таким алиасом уже существует"
+	default:
+		log.Error("failed to handle link command", slog.String("err", err.Error()))
+	}
 
-	_, err := api.SendMessage(chatID, escapedText, &gotgbot.SendMessageOpts{
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func sendMessageMD(api *gotgbot.Bot, chatID int64, text string) error {
+	_, err := api.SendMessage(chatID, formatMD(text), &gotgbot.SendMessageOpts{
 		ParseMode: "MarkdownV2",
 	})
 	if err != nil {
@@ -43,6 +71,11 @@ func sendMessageMD(api *gotgbot.Bot, chatID int64, text string) error {
 	return nil
 }
 
+// formatMD wraps text into a MarkdownV2 code block.
+func formatMD(text string) string {
+	return "```\n" + escapeMarkdownV2(text) + "\n```"
+}
+
 func parseCommandArgs(msg string) (*models.CmdArgs, error) {
 	re, err := regexp.Compile(commandPattern)
 	if err != nil {
@@ -62,12 +95,30 @@ func parseCommandArgs(msg string) (*models.CmdArgs, error) {
 	}
 
 	return &models.CmdArgs{
-		Topic: result["topic"],
-		Link:  result["link"],
-		Alias: result["alias"],
+		Topic:       result["topic"],
+		Link:        result["link"],
+		Alias:       result["alias"],
+		Tag:         result["tag"],
+		To:          result["to"],
+		NewAlias:    result["new_alias"],
+		Code:        result["code"],
+		User:        result["user"],
+		Role:        result["role"],
+		Recursive:   result["recursive"] == "true",
+		Title:       unquote(result["title"]),
+		Description: unquote(result["description"]),
+		Note:        unquote(result["note"]),
 	}, nil
 }
 
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
 func escapeMarkdownV2(text string) string {
 	// Экранирование всех специальных символов для MarkdownV2
 	replacer := strings.NewReplacer(
@@ -9,10 +9,14 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
 	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
 	"github.com/Sleeps17/linker/internal/storage"
 	"github.com/Sleeps17/linker/pkg/random"
 	"github.com/olekukonko/tablewriter"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 )
 
 const (
@@ -20,17 +24,28 @@ const (
 	pickLinkCmd   = "pick_link"
 	deleteLinkCmd = "delete_link"
 	listLinksCmd  = "list_links"
+	searchCmd     = "search"
+	updateLinkCmd = "update_link"
+	moveLinkCmd   = "move_link"
+	copyLinkCmd   = "copy_link"
+
+	searchLimit = 10
 )
 
 type LinkService interface {
-	PostLink(ctx context.Context, username, topic, link, alias string) (err error)
-	PickLink(ctx context.Context, username, topic, alias string) (link string, err error)
-	DeleteLink(ctx context.Context, username, topic, alias string) (err error)
-	ListLinks(ctx context.Context, username, topic string) (links []string, aliases []string, err error)
+	PostShortenedLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	PickLink(ctx context.Context, username, topic, alias string) (link models.Link, err error)
+	DeleteShortenedLink(ctx context.Context, username, topic, alias string) (err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
+	SearchLinks(ctx context.Context, username, query string, limit int) (links []models.Link, err error)
+	UpdateLink(ctx context.Context, username, topic, alias string, update models.LinkUpdate) (link models.Link, err error)
+	MoveLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
+	CopyLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
 }
 
 type LinksHandler struct {
 	linkService LinkService
+	pager       *pager
 	log         *slog.Logger
 	cfg         *config.BotConfig
 }
@@ -44,6 +59,7 @@ func NewLinksHandler(
 		cfg:         cfg,
 		log:         log,
 		linkService: linkService,
+		pager:       newPager(listLinksCmd, log),
 	}
 }
 
@@ -53,6 +69,10 @@ func (h *LinksHandler) Register(dispatcher *ext.Dispatcher) {
 		h.pickLink,
 		h.deleteLink,
 		h.listLinks,
+		h.search,
+		h.updateLink,
+		h.moveLink,
+		h.copyLink,
 	}
 
 	cmdTags := []string{
@@ -60,6 +80,10 @@ func (h *LinksHandler) Register(dispatcher *ext.Dispatcher) {
 		pickLinkCmd,
 		deleteLinkCmd,
 		listLinksCmd,
+		searchCmd,
+		updateLinkCmd,
+		moveLinkCmd,
+		copyLinkCmd,
 	}
 
 	for idx := range cmdHandlers {
@@ -68,6 +92,8 @@ func (h *LinksHandler) Register(dispatcher *ext.Dispatcher) {
 			cmdHandlers[idx],
 		))
 	}
+
+	h.pager.Register(dispatcher)
 }
 
 func (h *LinksHandler) postLink(bot *gotgbot.Bot, extctx *ext.Context) error {
@@ -101,12 +127,14 @@ func (h *LinksHandler) postLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 		args.Alias = random.Alias()
 	}
 
-	username := extctx.Message.From.Username
-	if err := h.linkService.PostLink(
-		ctx, username,
-		args.Topic, args.Link,
-		args.Alias,
-	); err != nil {
+	username := linkerUser(extctx)
+	if err := h.linkService.PostShortenedLink(ctx, username, args.Topic, models.Link{
+		URL:         args.Link,
+		Alias:       args.Alias,
+		Title:       args.Title,
+		Description: args.Description,
+		Note:        args.Note,
+	}); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
 				return err
@@ -121,6 +149,13 @@ func (h *LinksHandler) postLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if errors.Is(err, storage.ErrAliasAlreadyExists) {
 			if err := sendMessage(bot, chatID, "Ссылка с таким алиасом уже существует"); err != nil {
 				return err
@@ -166,7 +201,7 @@ func (h *LinksHandler) pickLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 		return ext.EndGroups
 	}
 
-	username := extctx.Message.From.Username
+	username := linkerUser(extctx)
 	link, err := h.linkService.PickLink(ctx, username, args.Topic, args.Alias)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
@@ -183,6 +218,13 @@ func (h *LinksHandler) pickLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			if err := sendMessage(bot, chatID, "Ссылка не найдена"); err != nil {
 				return err
@@ -196,7 +238,20 @@ func (h *LinksHandler) pickLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 		return ext.EndGroups
 	}
 
-	if err := sendMessage(bot, chatID, link); err != nil {
+	text := link.URL
+	if link.ShortURL != "" {
+		text += "\n" + link.ShortURL
+	}
+
+	if link.Title != "" {
+		text = link.Title + "\n" + text
+	}
+
+	if link.Note != "" {
+		text += "\n\n" + link.Note
+	}
+
+	if err := sendMessage(bot, chatID, text); err != nil {
 		return err
 	}
 	return ext.EndGroups
@@ -228,8 +283,8 @@ func (h *LinksHandler) deleteLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 		return ext.EndGroups
 	}
 
-	username := extctx.Message.From.Username
-	if err := h.linkService.DeleteLink(ctx, username, args.Topic, args.Alias); err != nil {
+	username := linkerUser(extctx)
+	if err := h.linkService.DeleteShortenedLink(ctx, username, args.Topic, args.Alias); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
 				return err
@@ -244,6 +299,13 @@ func (h *LinksHandler) deleteLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			if err := sendMessage(bot, chatID, "Ссылка не найдена"); err != nil {
 				return err
@@ -256,7 +318,7 @@ func (h *LinksHandler) deleteLink(bot *gotgbot.Bot, extctx *ext.Context) error {
 		}
 	}
 
-	if err := sendMessage(bot, chatID, "Ссылка усмпешно удалена"); err != nil {
+	if err := sendMessage(bot, chatID, "Ссылка перемещена в корзину, вернуть ее можно командой /restore"); err != nil {
 		return err
 	}
 	return ext.EndGroups
@@ -281,8 +343,19 @@ func (h *LinksHandler) listLinks(bot *gotgbot.Bot, extctx *ext.Context) error {
 		return ext.EndGroups
 	}
 
-	username := extctx.Message.From.Username
-	links, aliases, err := h.linkService.ListLinks(ctx, username, args.Topic)
+	username := linkerUser(extctx)
+	filter := models.LinksFilter{Tag: args.Tag, Recursive: args.Recursive}
+
+	err = h.pager.send(ctx, bot, chatID, func(ctx context.Context, cursor string) (string, string, error) {
+		page := models.Page{Limit: pageSize, Cursor: cursor}
+
+		links, next, err := h.linkService.ListLinks(ctx, username, args.Topic, page, filter)
+		if err != nil {
+			return "", "", err
+		}
+
+		return renderLinks(links, args.Recursive), next, nil
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
@@ -298,26 +371,207 @@ func (h *LinksHandler) listLinks(bot *gotgbot.Bot, extctx *ext.Context) error {
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if err := sendMessage(bot, chatID, "Не удалось получить ссылки"); err != nil {
 			return err
 		}
 		return ext.EndGroups
 	}
 
+	return ext.EndGroups
+}
+
+func (h *LinksHandler) search(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	// The query is free text: everything after the command itself.
+	var query string
+	if _, rest, found := strings.Cut(extctx.Message.Text, " "); found {
+		query = strings.TrimSpace(rest)
+	}
+
+	if query == "" {
+		if err := sendMessage(bot, chatID, "Укажите поисковый запрос: /search слова для поиска"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	links, err := h.linkService.SearchLinks(ctx, username, query, searchLimit)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if err := sendMessage(bot, chatID, "Не удалось выполнить поиск"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if len(links) == 0 {
+		if err := sendMessage(bot, chatID, "Ничего не найдено"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessageMD(bot, chatID, renderLinks(links, true)); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return ext.EndGroups
+}
+
+func (h *LinksHandler) updateLink(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.Alias == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic и alias обязательны"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	// Arguments left out keep their values.
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+
+	update := models.LinkUpdate{
+		URL:         optional(args.Link),
+		Alias:       optional(args.NewAlias),
+		Title:       optional(args.Title),
+		Description: optional(args.Description),
+		Note:        optional(args.Note),
+	}
+
+	if update == (models.LinkUpdate{}) {
+		if err := sendMessage(bot, chatID, "Укажите хотя бы одно из полей link, new_alias, title, description, note"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	if _, err := h.linkService.UpdateLink(ctx, username, args.Topic, args.Alias, update); err != nil {
+		return sendLinkError(bot, h.log, chatID, err, "Не удалось изменить ссылку")
+	}
+
+	if err := sendMessage(bot, chatID, "Ссылка успешно изменена"); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *LinksHandler) moveLink(bot *gotgbot.Bot, extctx *ext.Context) error {
+	return h.relocateLink(bot, extctx, h.linkService.MoveLink, "перемещена", "Не удалось переместить ссылку")
+}
+
+func (h *LinksHandler) copyLink(bot *gotgbot.Bot, extctx *ext.Context) error {
+	return h.relocateLink(bot, extctx, h.linkService.CopyLink, "скопирована", "Не удалось скопировать ссылку")
+}
+
+// relocateLink handles the commands putting a link into the topic given by
+// the to argument.
+func (h *LinksHandler) relocateLink(
+	bot *gotgbot.Bot,
+	extctx *ext.Context,
+	relocate func(ctx context.Context, username, topic, alias, toTopic string) (string, error),
+	done string,
+	fallback string,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.Alias == "" || args.To == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic, alias и to обязательны"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	alias, err := relocate(ctx, username, args.Topic, args.Alias, args.To)
+	if err != nil {
+		return sendLinkError(bot, h.log, chatID, err, fallback)
+	}
+
+	text := fmt.Sprintf("Ссылка %s в топик %s", done, args.To)
+	if alias != args.Alias {
+		text += fmt.Sprintf(", алиас %s занят, новый алиас: %s", args.Alias, alias)
+	}
+
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+// renderLinks draws links as a text table, withTopic adds a topic column for
+// listings spanning several topics.
+func renderLinks(links []models.Link, withTopic bool) string {
 	var buffer bytes.Buffer
 	table := tablewriter.NewWriter(&buffer)
-	headers := []string{"id", "alias", "link"}
+
+	headers := []string{"id", "alias", "link", "short link", "title", "description", "note", "tags", "created"}
+	if withTopic {
+		headers = slices.Insert(headers, 1, "topic")
+	}
+
 	values := make([][]string, 0)
-	for idx := range links {
-		values = append(values, []string{fmt.Sprint(idx + 1), aliases[idx], links[idx]})
+	for idx, link := range links {
+		row := []string{
+			fmt.Sprint(idx + 1),
+			link.Alias,
+			link.URL,
+			link.ShortURL,
+			link.Title,
+			link.Description,
+			link.Note,
+			strings.Join(link.Tags, ", "),
+			link.CreatedAt.Format(time.DateOnly),
+		}
+		if withTopic {
+			row = slices.Insert(row, 1, link.Topic)
+		}
+
+		values = append(values, row)
 	}
 
 	table.SetHeader(headers)
 	table.AppendBulk(values)
 	table.Render()
 
-	if err := sendMessageMD(bot, chatID, buffer.String()); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
-	return ext.EndGroups
+	return buffer.String()
 }
@@ -0,0 +1,200 @@
+package bothandlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/callbackquery"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const (
+	pageSize = 10
+
+	// Listings are kept in memory, the oldest are forgotten first.
+	maxListings = 1000
+
+	nextPage = "next"
+	prevPage = "prev"
+)
+
+// loadPage renders the page of a listing starting at cursor and returns the
+// cursor of the following page, empty on the last one.
+type loadPage func(ctx context.Context, cursor string) (text string, next string, err error)
+
+// listing is a paginated message, cursors[i] is the cursor page i was loaded
+// from and the last one is the page on screen.
+type listing struct {
+	mu      sync.Mutex
+	load    loadPage
+	cursors []string
+	next    string
+}
+
+type listingKey struct {
+	chatID    int64
+	messageID int64
+}
+
+// pager sends listings with next/prev buttons. Cursors don't fit into the
+// callback data, so buttons only carry the direction and the pager remembers
+// where each message is.
+type pager struct {
+	prefix string
+	log    *slog.Logger
+
+	mu       sync.Mutex
+	listings map[listingKey]*listing
+	order    []listingKey
+}
+
+func newPager(prefix string, log *slog.Logger) *pager {
+	return &pager{
+		prefix:   prefix + ":",
+		log:      log,
+		listings: make(map[listingKey]*listing),
+	}
+}
+
+func (p *pager) Register(dispatcher *ext.Dispatcher) {
+	dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(p.prefix), p.turn))
+}
+
+// send sends the first page of a listing. Errors of load are returned as is,
+// so that callers can report them.
+func (p *pager) send(ctx context.Context, bot *gotgbot.Bot, chatID int64, load loadPage) error {
+	text, next, err := load(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	// A single page needs neither buttons nor state.
+	if next == "" {
+		return sendMessageMD(bot, chatID, text)
+	}
+
+	msg, err := bot.SendMessage(chatID, formatMD(text), &gotgbot.SendMessageOpts{
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: p.keyboard(0, next),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	p.remember(listingKey{chatID: chatID, messageID: msg.MessageId}, &listing{
+		load:    load,
+		cursors: []string{""},
+		next:    next,
+	})
+
+	return nil
+}
+
+func (p *pager) turn(bot *gotgbot.Bot, extctx *ext.Context) error {
+	query := extctx.CallbackQuery
+	if query.Message == nil {
+		return p.answer(bot, query, "")
+	}
+
+	key := listingKey{chatID: query.Message.GetChat().Id, messageID: query.Message.GetMessageId()}
+
+	p.mu.Lock()
+	l := p.listings[key]
+	p.mu.Unlock()
+
+	if l == nil {
+		return p.answer(bot, query, "Список устарел, запросите его заново")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := len(l.cursors) - 1
+
+	var target int
+	switch strings.TrimPrefix(query.Data, p.prefix) {
+	case nextPage:
+		if l.next == "" {
+			return p.answer(bot, query, "")
+		}
+		target = current + 1
+	case prevPage:
+		if current == 0 {
+			return p.answer(bot, query, "")
+		}
+		target = current - 1
+	default:
+		return p.answer(bot, query, "")
+	}
+
+	cursor := l.next
+	if target < current {
+		cursor = l.cursors[target]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	text, next, err := l.load(ctx, cursor)
+	if err != nil {
+		p.log.Error("failed to load page", slog.Any("error", err))
+		return p.answer(bot, query, "Не удалось загрузить страницу")
+	}
+
+	if target > current {
+		l.cursors = append(l.cursors, cursor)
+	} else {
+		l.cursors = l.cursors[:target+1]
+	}
+	l.next = next
+
+	_, _, err = bot.EditMessageText(formatMD(text), &gotgbot.EditMessageTextOpts{
+		ChatId:      key.chatID,
+		MessageId:   key.messageID,
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: p.keyboard(target, next),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	return p.answer(bot, query, "")
+}
+
+func (p *pager) answer(bot *gotgbot.Bot, query *gotgbot.CallbackQuery, text string) error {
+	if _, err := query.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{Text: text}); err != nil {
+		return fmt.Errorf("failed to answer callback query: %w", err)
+	}
+
+	return ext.EndGroups
+}
+
+func (p *pager) keyboard(page int, next string) gotgbot.InlineKeyboardMarkup {
+	buttons := make([]gotgbot.InlineKeyboardButton, 0, 2)
+	if page > 0 {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: "« Назад", CallbackData: p.prefix + prevPage})
+	}
+
+	if next != "" {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: "Далее »", CallbackData: p.prefix + nextPage})
+	}
+
+	return gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{buttons}}
+}
+
+func (p *pager) remember(key listingKey, l *listing) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.order) >= maxListings {
+		delete(p.listings, p.order[0])
+		p.order = p.order[1:]
+	}
+
+	p.listings[key] = l
+	p.order = append(p.order, key)
+}
@@ -0,0 +1,201 @@
+package bothandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/olekukonko/tablewriter"
+	"log/slog"
+)
+
+const (
+	shareTopicCmd   = "share_topic"
+	unshareTopicCmd = "unshare_topic"
+	sharedTopicsCmd = "shared_topics"
+)
+
+type ShareService interface {
+	ShareTopic(ctx context.Context, owner, topic, username string, role models.ShareRole) (err error)
+	UnshareTopic(ctx context.Context, owner, topic, username string) (err error)
+	ListSharedTopics(ctx context.Context, username string) (topics []models.SharedTopic, err error)
+}
+
+type SharesHandler struct {
+	shareService ShareService
+	log          *slog.Logger
+	cfg          *config.BotConfig
+}
+
+func NewSharesHandler(
+	cfg *config.BotConfig,
+	log *slog.Logger,
+	shareService ShareService,
+) *SharesHandler {
+	return &SharesHandler{
+		cfg:          cfg,
+		log:          log,
+		shareService: shareService,
+	}
+}
+
+func (h *SharesHandler) Register(dispatcher *ext.Dispatcher) {
+	cmdHandlers := []handlers.Response{
+		h.shareTopic,
+		h.unshareTopic,
+		h.sharedTopics,
+	}
+
+	cmdTags := []string{
+		shareTopicCmd,
+		unshareTopicCmd,
+		sharedTopicsCmd,
+	}
+
+	for idx := range cmdHandlers {
+		dispatcher.AddHandler(handlers.NewCommand(
+			cmdTags[idx],
+			cmdHandlers[idx],
+		))
+	}
+}
+
+// shareTopic opens a topic of the user to another user, a viewer by default.
+func (h *SharesHandler) shareTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.User == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic и user обязательны, role может быть viewer или editor"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	role := models.ShareRole(args.Role)
+	if role == "" {
+		role = models.ShareViewer
+	}
+
+	text := fmt.Sprintf("Топик %s открыт пользователю %s с ролью %s, он найдет его как %s",
+		args.Topic, args.User, role, storage.SharedTopicPath(linkerUser(extctx), args.Topic))
+	if err := h.shareService.ShareTopic(ctx, linkerUser(extctx), args.Topic, args.User, role); err != nil {
+		text = h.shareError(err, "Не удалось поделиться топиком")
+	}
+
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *SharesHandler) unshareTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.User == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic и user обязательны"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	text := fmt.Sprintf("Доступ пользователя %s к топику %s закрыт", args.User, args.Topic)
+	if err := h.shareService.UnshareTopic(ctx, linkerUser(extctx), args.Topic, args.User); err != nil {
+		text = h.shareError(err, "Не удалось закрыть доступ к топику")
+	}
+
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *SharesHandler) sharedTopics(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	topics, err := h.shareService.ListSharedTopics(ctx, linkerUser(extctx))
+	if err != nil {
+		if err := sendMessage(bot, chatID, h.shareError(err, "Не удалось получить список топиков")); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if len(topics) == 0 {
+		if err := sendMessage(bot, chatID, "С вами пока не поделились ни одним топиком"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessageMD(bot, chatID, renderSharedTopics(topics)); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+// shareError describes storage errors of share commands, fallback describes
+// any other failure.
+func (h *SharesHandler) shareError(err error, fallback string) string {
+	switch {
+	case errors.Is(err, storage.ErrUserNotFound):
+		return "Пользователь не найден"
+	case errors.Is(err, storage.ErrTopicNotFound):
+		return "Топик не найден"
+	case errors.Is(err, storage.ErrShareNotFound):
+		return "Топик не открыт этому пользователю"
+	case errors.Is(err, storage.ErrPermissionDenied):
+		return "Поделиться можно только своим топиком"
+	case errors.Is(err, storage.ErrInvalidShareRole):
+		return "Неверная роль, допустимы viewer и editor"
+	case errors.Is(err, storage.ErrShareWithOwner):
+		return "Нельзя поделиться топиком с самим собой"
+	}
+
+	h.log.Error("failed to handle share command", slog.String("err", err.Error()))
+	return fallback
+}
+
+func renderSharedTopics(topics []models.SharedTopic) string {
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	headers := []string{"topic", "owner", "role"}
+	values := make([][]string, 0)
+	for _, topic := range topics {
+		values = append(values, []string{
+			topic.Path,
+			topic.Owner,
+			string(topic.Role),
+		})
+	}
+
+	table.SetHeader(headers)
+	table.AppendBulk(values)
+	table.Render()
+
+	return buffer.String()
+}
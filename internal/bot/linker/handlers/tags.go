@@ -0,0 +1,177 @@
+package bothandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"log/slog"
+)
+
+const (
+	tagCmd       = "tag"
+	untagCmd     = "untag"
+	listByTagCmd = "list_by_tag"
+)
+
+type TagService interface {
+	TagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	UntagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	ListLinksByTag(ctx context.Context, username, tag string) (links []models.Link, err error)
+}
+
+type TagsHandler struct {
+	tagService TagService
+	log        *slog.Logger
+	cfg        *config.BotConfig
+}
+
+func NewTagsHandler(
+	cfg *config.BotConfig,
+	log *slog.Logger,
+	tagService TagService,
+) *TagsHandler {
+	return &TagsHandler{
+		cfg:        cfg,
+		log:        log,
+		tagService: tagService,
+	}
+}
+
+func (h *TagsHandler) Register(dispatcher *ext.Dispatcher) {
+	cmdHandlers := []handlers.Response{
+		h.tag,
+		h.untag,
+		h.listByTag,
+	}
+
+	cmdTags := []string{
+		tagCmd,
+		untagCmd,
+		listByTagCmd,
+	}
+
+	for idx := range cmdHandlers {
+		dispatcher.AddHandler(handlers.NewCommand(
+			cmdTags[idx],
+			cmdHandlers[idx],
+		))
+	}
+}
+
+func (h *TagsHandler) tag(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.Alias == "" || args.Tag == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic, alias и tag обязательны"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	if err := h.tagService.TagLink(ctx, username, args.Topic, args.Alias, args.Tag); err != nil {
+		return sendLinkError(bot, h.log, chatID, err, "Не удалось добавить тег")
+	}
+
+	if err := sendMessage(bot, chatID, "Тег успешно добавлен"); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *TagsHandler) untag(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.Alias == "" || args.Tag == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic, alias и tag обязательны"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	if err := h.tagService.UntagLink(ctx, username, args.Topic, args.Alias, args.Tag); err != nil {
+		if errors.Is(err, storage.ErrTagNotFound) {
+			if err := sendMessage(bot, chatID, "У ссылки нет такого тега"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		return sendLinkError(bot, h.log, chatID, err, "Не удалось удалить тег")
+	}
+
+	if err := sendMessage(bot, chatID, "Тег успешно удален"); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func (h *TagsHandler) listByTag(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Tag == "" {
+		if err := sendMessage(bot, chatID, "Аргумент tag обязателен"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+	links, err := h.tagService.ListLinksByTag(ctx, username, args.Tag)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if err := sendMessage(bot, chatID, "Не удалось получить ссылки"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if len(links) == 0 {
+		if err := sendMessage(bot, chatID, "Ссылок с таким тегом нет"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessageMD(bot, chatID, renderLinks(links, true)); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return ext.EndGroups
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
 	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
 	"github.com/Sleeps17/linker/internal/storage"
 	"github.com/olekukonko/tablewriter"
 	"log/slog"
@@ -18,16 +19,20 @@ const (
 	postTopicCmd   = "post_topic"
 	deleteTopicCmd = "delete_topic"
 	listTopicsCmd  = "list_topics"
+	renameTopicCmd = "rename_topic"
+	moveTopicCmd   = "move_topic"
 )
 
 type TopicService interface {
 	PostTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
 	DeleteTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
-	ListTopics(ctx context.Context, username string) (topics []string, err error)
+	ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) (topics []string, next string, err error)
+	RenameTopic(ctx context.Context, username, topic, newTopic string) (err error)
 }
 
 type TopicsHandler struct {
 	topicService TopicService
+	pager        *pager
 	log          *slog.Logger
 	cfg          *config.BotConfig
 }
@@ -41,6 +46,7 @@ func NewTopicsHandler(
 		cfg:          cfg,
 		log:          log,
 		topicService: topicService,
+		pager:        newPager(listTopicsCmd, log),
 	}
 }
 
@@ -49,12 +55,16 @@ func (h *TopicsHandler) Register(dispatcher *ext.Dispatcher) {
 		h.postTopic,
 		h.deleteTopic,
 		h.listTopics,
+		h.renameTopic,
+		h.moveTopic,
 	}
 
 	cmdTags := []string{
 		postTopicCmd,
 		deleteTopicCmd,
 		listTopicsCmd,
+		renameTopicCmd,
+		moveTopicCmd,
 	}
 
 	for idx := range cmdHandlers {
@@ -63,6 +73,8 @@ func (h *TopicsHandler) Register(dispatcher *ext.Dispatcher) {
 			cmdHandlers[idx],
 		))
 	}
+
+	h.pager.Register(dispatcher)
 }
 
 func (h *TopicsHandler) postTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
@@ -84,7 +96,7 @@ func (h *TopicsHandler) postTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
 		return ext.EndGroups
 	}
 
-	username := extctx.Message.From.Username
+	username := linkerUser(extctx)
 
 	id, err := h.topicService.PostTopic(ctx, username, args.Topic)
 	if err != nil {
@@ -96,6 +108,14 @@ func (h *TopicsHandler) postTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			if err := sendMessage(bot, chatID, "Неверный путь топика, пример: work/backend/postgres"); err != nil {
+				return err
+			}
+
+			return ext.EndGroups
+		}
+
 		if err := sendMessage(bot, chatID, "Не удалось создать топик"); err != nil {
 			return err
 		}
@@ -127,7 +147,7 @@ func (h *TopicsHandler) deleteTopic(bot *gotgbot.Bot, extctx *ext.Context) error
 		return ext.EndGroups
 	}
 
-	username := extctx.Message.From.Username
+	username := linkerUser(extctx)
 
 	id, err := h.topicService.DeleteTopic(ctx, username, args.Topic)
 	if err != nil {
@@ -145,13 +165,20 @@ func (h *TopicsHandler) deleteTopic(bot *gotgbot.Bot, extctx *ext.Context) error
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if err := sendMessage(bot, chatID, "Не удалось удалить топик"); err != nil {
 			return err
 		}
 		return ext.EndGroups
 	}
 
-	if err := sendMessage(bot, chatID, fmt.Sprintf("Топик усмпешно удален, id = %d", id)); err != nil {
+	if err := sendMessage(bot, chatID, fmt.Sprintf("Топик перемещен в корзину вместе с подтопиками и ссылками, id = %d. Вернуть его можно командой /restore", id)); err != nil {
 		return err
 	}
 	return ext.EndGroups
@@ -162,9 +189,32 @@ func (h *TopicsHandler) listTopics(bot *gotgbot.Bot, extctx *ext.Context) error
 	defer cancel()
 
 	chatID := extctx.Message.Chat.Id
-	username := extctx.Message.From.Username
+	username := linkerUser(extctx)
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	// Topics are listed by path, so subtopics follow their parents.
+	filter := models.TopicsFilter{}
+	if filter.Parent, err = storage.CleanTopicParent(args.Topic); err != nil {
+		if err := sendMessage(bot, chatID, "Неверный путь топика, пример: work/backend/postgres"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	err = h.pager.send(ctx, bot, chatID, func(ctx context.Context, cursor string) (string, string, error) {
+		page := models.Page{Limit: pageSize, Cursor: cursor, SortBy: models.SortByName}
+
+		topics, next, err := h.topicService.ListTopics(ctx, username, page, filter)
+		if err != nil {
+			return "", "", err
+		}
 
-	topics, err := h.topicService.ListTopics(ctx, username)
+		return renderTopics(topics), next, nil
+	})
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
@@ -173,12 +223,134 @@ func (h *TopicsHandler) listTopics(bot *gotgbot.Bot, extctx *ext.Context) error
 			return ext.EndGroups
 		}
 
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			if err := sendMessage(bot, chatID, "Топик не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
 		if err := sendMessage(bot, chatID, "Неудалось получить список топиков"); err != nil {
 			return err
 		}
 		return ext.EndGroups
 	}
 
+	return ext.EndGroups
+}
+
+func (h *TopicsHandler) renameTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.To == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic и to обязательны"); err != nil {
+			return err
+		}
+
+		return ext.EndGroups
+	}
+
+	return h.relocateTopic(bot, extctx, args.Topic, args.To, "Топик переименован в %s")
+}
+
+// moveTopic moves a topic with its subtopics under the topic given by to,
+// to:/ moves it to the top level.
+func (h *TopicsHandler) moveTopic(bot *gotgbot.Bot, extctx *ext.Context) error {
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" || args.To == "" {
+		if err := sendMessage(bot, chatID, "Аргументы topic и to обязательны, to:/ переносит топик на верхний уровень"); err != nil {
+			return err
+		}
+
+		return ext.EndGroups
+	}
+
+	parent, err := storage.CleanTopicParent(args.To)
+	if err != nil {
+		if err := sendMessage(bot, chatID, "Неверный путь топика, пример: work/backend/postgres"); err != nil {
+			return err
+		}
+
+		return ext.EndGroups
+	}
+
+	return h.relocateTopic(bot, extctx, args.Topic, storage.MoveTopicPath(args.Topic, parent), "Топик перенесен в %s")
+}
+
+func (h *TopicsHandler) relocateTopic(bot *gotgbot.Bot, extctx *ext.Context, topic, newTopic, success string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+	username := linkerUser(extctx)
+
+	if err := h.topicService.RenameTopic(ctx, username, topic, newTopic); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			if err := sendMessage(bot, chatID, "Топик не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			if err := sendMessage(bot, chatID, "Недостаточно прав для топика"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if errors.Is(err, storage.ErrTopicAlreadyExists) {
+			if err := sendMessage(bot, chatID, "Топик с таким названием уже существует"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			if err := sendMessage(bot, chatID, "Неверный путь топика, топик нельзя переместить внутрь самого себя"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		if err := sendMessage(bot, chatID, "Не удалось переместить топик"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessage(bot, chatID, fmt.Sprintf(success, newTopic)); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func renderTopics(topics []string) string {
 	var buffer bytes.Buffer
 	table := tablewriter.NewWriter(&buffer)
 	headers := []string{"ID", "Topic"}
@@ -191,8 +363,5 @@ func (h *TopicsHandler) listTopics(bot *gotgbot.Bot, extctx *ext.Context) error
 	table.AppendBulk(values)
 	table.Render()
 
-	if err := sendMessageMD(bot, chatID, buffer.String()); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
-	}
-	return ext.EndGroups
+	return buffer.String()
 }
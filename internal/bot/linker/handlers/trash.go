@@ -0,0 +1,179 @@
+package bothandlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/olekukonko/tablewriter"
+	"log/slog"
+	"time"
+)
+
+const (
+	trashCmd   = "trash"
+	restoreCmd = "restore"
+)
+
+type TrashService interface {
+	ListTrash(ctx context.Context, username string) (items []models.TrashItem, err error)
+	RestoreTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
+	RestoreLink(ctx context.Context, username, topic, alias string) (err error)
+}
+
+type TrashHandler struct {
+	trashService TrashService
+	log          *slog.Logger
+	cfg          *config.BotConfig
+}
+
+func NewTrashHandler(
+	cfg *config.BotConfig,
+	log *slog.Logger,
+	trashService TrashService,
+) *TrashHandler {
+	return &TrashHandler{
+		cfg:          cfg,
+		log:          log,
+		trashService: trashService,
+	}
+}
+
+func (h *TrashHandler) Register(dispatcher *ext.Dispatcher) {
+	cmdHandlers := []handlers.Response{
+		h.trash,
+		h.restore,
+	}
+
+	cmdTags := []string{
+		trashCmd,
+		restoreCmd,
+	}
+
+	for idx := range cmdHandlers {
+		dispatcher.AddHandler(handlers.NewCommand(
+			cmdTags[idx],
+			cmdHandlers[idx],
+		))
+	}
+}
+
+func (h *TrashHandler) trash(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+	username := linkerUser(extctx)
+
+	items, err := h.trashService.ListTrash(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			if err := sendMessage(bot, chatID, "Пользователь не найден"); err != nil {
+				return err
+			}
+			return ext.EndGroups
+		}
+
+		h.log.Error("failed to list trash", slog.String("err", err.Error()))
+		if err := sendMessage(bot, chatID, "Не удалось получить содержимое корзины"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if len(items) == 0 {
+		if err := sendMessage(bot, chatID, "Корзина пуста"); err != nil {
+			return err
+		}
+		return ext.EndGroups
+	}
+
+	if err := sendMessageMD(bot, chatID, renderTrash(items)); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+// restore brings back a deleted link given topic and alias, or a deleted topic
+// given the topic alone.
+func (h *TrashHandler) restore(bot *gotgbot.Bot, extctx *ext.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), handlersTimeout)
+	defer cancel()
+
+	chatID := extctx.Message.Chat.Id
+
+	args, err := parseCommandArgs(extctx.Message.Text)
+	if err != nil {
+		return fmt.Errorf("failed to parse command args: %w", err)
+	}
+
+	if args.Topic == "" {
+		if err := sendMessage(bot, chatID, "Аргумент topic обязателен, alias указывается для восстановления ссылки"); err != nil {
+			return err
+		}
+
+		return ext.EndGroups
+	}
+
+	username := linkerUser(extctx)
+
+	text := fmt.Sprintf("Топик %s восстановлен", args.Topic)
+	if args.Alias == "" {
+		_, err = h.trashService.RestoreTopic(ctx, username, args.Topic)
+	} else {
+		text = fmt.Sprintf("Ссылка %s восстановлена в топик %s", args.Alias, args.Topic)
+		err = h.trashService.RestoreLink(ctx, username, args.Topic, args.Alias)
+	}
+
+	if err != nil {
+		text = "Не удалось восстановить из корзины"
+
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			text = "Пользователь не найден"
+		case errors.Is(err, storage.ErrNotInTrash):
+			text = "В корзине нет такого топика или ссылки, посмотреть ее содержимое можно командой /trash"
+		case errors.Is(err, storage.ErrInvalidTopicPath):
+			text = "Неверный путь топика"
+		case errors.Is(err, storage.ErrTopicAlreadyExists):
+			text = "Топик с таким названием уже существует"
+		case errors.Is(err, storage.ErrAliasAlreadyExists):
+			text = "Ссылка с таким алиасом уже существует"
+		default:
+			h.log.Error("failed to restore from trash", slog.String("err", err.Error()))
+		}
+	}
+
+	if err := sendMessage(bot, chatID, text); err != nil {
+		return err
+	}
+	return ext.EndGroups
+}
+
+func renderTrash(items []models.TrashItem) string {
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	headers := []string{"topic", "alias", "subtopics", "links", "deleted"}
+	values := make([][]string, 0)
+	for _, item := range items {
+		values = append(values, []string{
+			item.Topic,
+			item.Alias,
+			fmt.Sprint(item.Subtopics),
+			fmt.Sprint(item.Links),
+			item.DeletedAt.Format(time.DateTime),
+		})
+	}
+
+	table.SetHeader(headers)
+	table.AppendBulk(values)
+	table.Render()
+
+	return buffer.String()
+}
@@ -0,0 +1,106 @@
+package builtinShortener
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"github.com/Sleeps17/linker/internal/storage"
+	"math/big"
+	"strings"
+)
+
+const (
+	codeLength   = 7
+	codeAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// RedirectPath is the route of the REST server short URLs point to.
+	RedirectPath = "/u/"
+
+	// A clash of random codes is rare, a few of them in a row mean the codes
+	// run out.
+	maxSaveAttempts = 5
+)
+
+var ErrNoFreeCode = errors.New("failed to pick a free short code")
+
+// Store keeps the short codes.
+type Store interface {
+	SaveShortURL(ctx context.Context, code, url string) (err error)
+	DeleteShortURL(ctx context.Context, code string) (err error)
+}
+
+// Shortener shortens links in process, keeping short codes in the linker
+// storage and redirecting from them with the REST server.
+type Shortener struct {
+	store   Store
+	baseURL string
+}
+
+// New returns a shortener whose short URLs start with baseURL, the public
+// address of the REST server.
+func New(store Store, baseURL string) urlShortener.UrlShortener {
+	return &Shortener{
+		store:   store,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// SaveURL saves url under a random code and returns its short URL. The alias
+// is not used as the code: codes are shared by all users, while aliases are
+// only unique within a topic.
+func (s *Shortener) SaveURL(ctx context.Context, url string, alias string) (string, string, error) {
+	const op = "builtinShortener.SaveURL"
+
+	for range maxSaveAttempts {
+		code, err := randomCode()
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = s.store.SaveShortURL(ctx, code, url)
+		if errors.Is(err, storage.ErrShortCodeExists) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w: %w", op, urlShortener.ErrUnavailable, err)
+		}
+
+		return s.baseURL + RedirectPath + code, code, nil
+	}
+
+	return "", "", fmt.Errorf("%s: %w", op, ErrNoFreeCode)
+}
+
+// DeleteURL deletes the short URL with the code. Failures of the store are
+// reported as the shortener being unavailable, they are worth retrying.
+func (s *Shortener) DeleteURL(ctx context.Context, code string) error {
+	const op = "builtinShortener.DeleteURL"
+
+	err := s.store.DeleteShortURL(ctx, code)
+	if errors.Is(err, storage.ErrShortCodeNotFound) {
+		return fmt.Errorf("%s: %w", op, urlShortener.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, urlShortener.ErrUnavailable, err)
+	}
+
+	return nil
+}
+
+func randomCode() (string, error) {
+	code := make([]byte, codeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+
+	for idx := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		code[idx] = codeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
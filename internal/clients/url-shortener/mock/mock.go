@@ -35,26 +35,27 @@ func (m *MockUrlShortener) EXPECT() *MockUrlShortenerMockRecorder {
 }
 
 // DeleteURL mocks base method.
-func (m *MockUrlShortener) DeleteURL(ctx context.Context, alias string) error {
+func (m *MockUrlShortener) DeleteURL(ctx context.Context, code string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteURL", ctx, alias)
+	ret := m.ctrl.Call(m, "DeleteURL", ctx, code)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteURL indicates an expected call of DeleteURL.
-func (mr *MockUrlShortenerMockRecorder) DeleteURL(ctx, alias interface{}) *gomock.Call {
+func (mr *MockUrlShortenerMockRecorder) DeleteURL(ctx, code interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURL", reflect.TypeOf((*MockUrlShortener)(nil).DeleteURL), ctx, alias)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteURL", reflect.TypeOf((*MockUrlShortener)(nil).DeleteURL), ctx, code)
 }
 
 // SaveURL mocks base method.
-func (m *MockUrlShortener) SaveURL(ctx context.Context, url, alias string) (string, error) {
+func (m *MockUrlShortener) SaveURL(ctx context.Context, url, alias string) (string, string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SaveURL", ctx, url, alias)
 	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // SaveURL indicates an expected call of SaveURL.
@@ -0,0 +1,129 @@
+package urlShortenerClient
+
+import (
+	"fmt"
+	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the shortener while it is
+// considered down.
+var ErrCircuitOpen = fmt.Errorf("%w: circuit is open", urlShortener.ErrUnavailable)
+
+type breakerState int
+
+const (
+	// stateClosed passes every call.
+	stateClosed breakerState = iota
+	// stateOpen fails calls fast until the cooldown is over.
+	stateOpen
+	// stateHalfOpen passes one probe call, which closes or reopens the
+	// circuit.
+	stateHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker opens after threshold failed calls in a row and stays open for
+// cooldown, so that a dead shortener doesn't stall its callers.
+type breaker struct {
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	probing  bool
+	openedAt time.Time
+
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from, to breakerState)
+}
+
+func newBreaker(threshold int, cooldown time.Duration, onChange func(from, to breakerState)) *breaker {
+	return &breaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		onChange:  onChange,
+	}
+}
+
+// allow reports whether a call may go through.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+
+		b.setState(stateHalfOpen)
+		b.probing = true
+
+		return nil
+	case stateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+
+		b.probing = true
+
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.setState(stateClosed)
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(stateOpen)
+	}
+}
+
+// abandon ends a call that tells nothing about the shortener, such as one
+// cancelled by its caller.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
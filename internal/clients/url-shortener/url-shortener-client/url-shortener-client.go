@@ -5,27 +5,72 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"github.com/Sleeps17/linker/internal/config"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// ErrUnavailable wraps failures of the shortener itself: network errors,
+// timeouts and server errors. Only they are retried and trip the breaker.
+var ErrUnavailable = urlShortener.ErrUnavailable
+
+// aliasNotFound is the error the shortener answers a delete of an unknown
+// alias with.
+const aliasNotFound = "alias not found"
+
+// metrics are published by expvar as "url_shortener": counters of calls,
+// failures, retries and calls rejected by the open circuit, and the state of
+// the circuit.
+var (
+	metrics      = expvar.NewMap("url_shortener")
+	circuitState = new(expvar.String)
+)
+
+func init() {
+	circuitState.Set(stateClosed.String())
+	metrics.Set("breaker_state", circuitState)
+}
+
 type Client struct {
 	url      string
+	baseURL  string
 	username string
 	password string
+
+	http         *http.Client
+	retries      int
+	retryBackoff time.Duration
+	breaker      *breaker
+	log          *slog.Logger
 }
 
-func New(host, port string, username, password string) urlShortener.UrlShortener {
-	return &Client{
-		url:      fmt.Sprintf("http://%s:%s/", host, port),
-		username: username,
-		password: password,
+func New(cfg *config.UrlShortenerClientConfig, log *slog.Logger) urlShortener.UrlShortener {
+	c := &Client{
+		url:          fmt.Sprintf("http://%s:%s/", cfg.Host, cfg.Port),
+		baseURL:      strings.TrimSuffix(cfg.BaseURL, "/"),
+		username:     cfg.Username,
+		password:     cfg.Password,
+		http:         &http.Client{Timeout: cfg.Timeout},
+		retries:      cfg.Retries,
+		retryBackoff: cfg.RetryBackoff,
+		log:          log,
 	}
+
+	c.breaker = newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown, c.breakerChanged)
+
+	return c
 }
 
-func (c *Client) SaveURL(ctx context.Context, Url, alias string) (string, error) {
+// SaveURL is not retried: the alias may have been saved by an attempt whose
+// response was lost, then a retry fails. The service redirects from the
+// alias it returns, which is the code of the short URL.
+func (c *Client) SaveURL(ctx context.Context, Url, alias string) (string, string, error) {
 	type (
 		Request struct {
 			Url   string `json:"url"`
@@ -39,43 +84,19 @@ func (c *Client) SaveURL(ctx context.Context, Url, alias string) (string, error)
 		}
 	)
 
-	body, err := json.Marshal(Request{Url: Url, Alias: alias})
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewBuffer(body))
-	if err != nil {
-		return "", err
-	}
-
-	req.SetBasicAuth(c.username, c.password)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-
-	defer func() { _ = resp.Body.Close() }()
-
 	var response Response
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", err
+	if err := c.call(ctx, http.MethodPost, Request{Url: Url, Alias: alias}, &response, false); err != nil {
+		return "", "", err
 	}
 
 	if response.Status != "OK" {
-		return "", errors.New(response.Error)
+		return "", "", errors.New(response.Error)
 	}
 
-	return response.Alias, nil
+	return c.baseURL + "/" + response.Alias, response.Alias, nil
 }
 
-func (c *Client) DeleteURL(ctx context.Context, alias string) error {
+func (c *Client) DeleteURL(ctx context.Context, code string) error {
 	type (
 		Request struct {
 			Alias string `json:"alias"`
@@ -87,38 +108,126 @@ func (c *Client) DeleteURL(ctx context.Context, alias string) error {
 		}
 	)
 
-	body, err := json.Marshal(Request{Alias: alias})
+	var response Response
+	if err := c.call(ctx, http.MethodDelete, Request{Alias: code}, &response, true); err != nil {
+		return err
+	}
+
+	if response.Status != "OK" {
+		if response.Error == aliasNotFound {
+			return fmt.Errorf("%w: %s", urlShortener.ErrNotFound, code)
+		}
+
+		return errors.New(response.Error)
+	}
+
+	return nil
+}
+
+// call sends request through the breaker and decodes the response. Calls
+// that are safe to repeat are retried with exponential backoff while the
+// shortener is unavailable.
+func (c *Client) call(ctx context.Context, method string, request, response any, idempotent bool) error {
+	body, err := json.Marshal(request)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, c.url, bytes.NewBuffer(body))
+	attempts := 1
+	if idempotent {
+		attempts += c.retries
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.allow(); err != nil {
+			metrics.Add("rejected", 1)
+			return err
+		}
+
+		metrics.Add("requests", 1)
+
+		err := c.send(ctx, method, body, response)
+		switch {
+		case err == nil:
+			c.breaker.success()
+			return nil
+		case ctx.Err() != nil:
+			c.breaker.abandon()
+			return err
+		case !errors.Is(err, ErrUnavailable):
+			// The shortener has answered, it works.
+			c.breaker.success()
+			return err
+		}
+
+		metrics.Add("failures", 1)
+		c.breaker.failure()
+
+		if attempt >= attempts {
+			return err
+		}
+
+		c.log.Debug(
+			"url shortener call failed, retrying",
+			slog.String("method", method),
+			slog.Int("attempt", attempt),
+			slog.String("err", err.Error()),
+		)
+		metrics.Add("retries", 1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method string, body []byte, response any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.url, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 
 	req.SetBasicAuth(c.username, c.password)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
 	}
 
 	defer func() { _ = resp.Body.Close() }()
 
-	jsonResp, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
 	}
 
-	var response Response
-	if err := json.Unmarshal(jsonResp, &response); err != nil {
-		return err
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
 	}
 
-	if response.Status != "OK" {
-		return errors.New(response.Error)
+	// A working shortener answers with JSON.
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
 	}
 
 	return nil
 }
+
+func (c *Client) breakerChanged(from, to breakerState) {
+	circuitState.Set(to.String())
+
+	switch to {
+	case stateOpen:
+		metrics.Add("breaker_opened", 1)
+		c.log.Warn("url shortener circuit opened, calls fail fast", slog.String("from", from.String()))
+	case stateClosed:
+		c.log.Info("url shortener circuit closed", slog.String("from", from.String()))
+	default:
+		c.log.Info("url shortener circuit half-open, probing", slog.String("from", from.String()))
+	}
+}
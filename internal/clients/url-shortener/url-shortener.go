@@ -1,8 +1,23 @@
 package urlShortener
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
+var (
+	// ErrUnavailable wraps failures of the shortener itself, the calls that
+	// failed with it are worth repeating later. Any other error is the
+	// answer of a working shortener.
+	ErrUnavailable = errors.New("url shortener is unavailable")
+	// ErrNotFound is returned by DeleteURL for a code the shortener does not
+	// know.
+	ErrNotFound = errors.New("short url not found")
+)
+
+// UrlShortener shortens URLs. SaveURL returns the short URL together with
+// the code DeleteURL deletes it by.
 type UrlShortener interface {
-	SaveURL(ctx context.Context, url string, alias string) (_alias string, err error)
-	DeleteURL(ctx context.Context, alias string) (err error)
+	SaveURL(ctx context.Context, url string, alias string) (shortURL, code string, err error)
+	DeleteURL(ctx context.Context, code string) (err error)
 }
@@ -1,9 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
-	"fmt"
 	"os"
 	"time"
 )
@@ -17,8 +17,12 @@ type Config struct {
 	Rest               ServerConfig             `yaml:"rest"`
 	Grpc               ServerConfig             `yaml:"grpc"`
 	Bot                BotConfig                `yaml:"bot"`
-	DataBase           PostgresDBConfig         `yaml:"data_base"`
+	DataBase           DataBaseConfig           `yaml:"data_base"`
+	Trash              TrashConfig              `yaml:"trash"`
+	Redirect           RedirectConfig           `yaml:"redirect"`
+	Publish            PublishConfig            `yaml:"publish"`
 	UrlShortenerClient UrlShortenerClientConfig `yaml:"url_shortener_client"`
+	ShortenerSync      ShortenerSyncConfig      `yaml:"shortener_sync"`
 }
 
 type ServerConfig struct {
@@ -39,20 +43,79 @@ type MongoDBConfig struct {
 	Collection string        `yaml:"collection" env-default:"links"`
 }
 
-type PostgresDBConfig struct {
-	Timeout  time.Duration `yaml:"timeout" env-default:"10s"`
-	Host     string        `yaml:"host" env-default:"db"`
-	Port     string        `yaml:"port" env-default:"5432"`
-	Name     string        `yaml:"name" env-required:"true"`
-	Username string        `yaml:"username" env-required:"true"`
-	Password string        `yaml:"password" env-required:"true"`
+// DataBaseConfig selects the storage backend with Driver ("postgres",
+// "sqlite" or "memory"). Path is the sqlite data file, the remaining
+// connection settings are only used by postgres. Schema migrations are
+// applied on startup unless SkipMigrations is set.
+type DataBaseConfig struct {
+	Driver         string        `yaml:"driver" env-default:"postgres"`
+	Path           string        `yaml:"path" env-default:"linker.db"`
+	Timeout        time.Duration `yaml:"timeout" env-default:"10s"`
+	Host           string        `yaml:"host" env-default:"db"`
+	Port           string        `yaml:"port" env-default:"5432"`
+	Name           string        `yaml:"name"`
+	Username       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+	SkipMigrations bool          `yaml:"skip_migrations"`
+}
+
+// TrashConfig sets how long deleted topics and links are kept in the trash
+// and how often the expired ones are purged.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// ShortenerSyncConfig tunes the worker applying the queued shortener tasks:
+// up to BatchSize tasks are claimed every PollInterval, a failed task is
+// retried after RetryBackoff doubled with every attempt up to MaxBackoff and
+// dropped after MaxAttempts. A batch has Lease to be applied, after that its
+// tasks are claimed again.
+type ShortenerSyncConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env-default:"50"`
+	Lease        time.Duration `yaml:"lease" env-default:"5m"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env-default:"5s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env-default:"1h"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"20"`
+}
+
+// RedirectConfig protects the redirect routes. With RequireAuth set a user
+// follows own links only, authenticated by an API token like the REST API.
+type RedirectConfig struct {
+	RequireAuth bool `yaml:"require_auth"`
+}
+
+// PublishConfig sets BaseURL, the public address of the REST server that the
+// pages and feeds of published topics are linked from. Requests can't tell
+// it, their Host and X-Forwarded-Proto headers are up to the client.
+type PublishConfig struct {
+	BaseURL string `yaml:"base_url" env-default:"http://localhost:8080"`
 }
 
+// UrlShortenerClientConfig selects the URL shortener with Driver: "external"
+// calls the url-shortener service at Host and Port, "builtin" keeps short
+// codes in the linker storage and serves them from the REST server. Short
+// URLs start with BaseURL, the public address of the service redirecting
+// from them. It has no default: the linker address is wrong for the external
+// service, so loading a config without it fails.
+//
+// Calls to the external service time out after Timeout, deletes are retried
+// Retries times starting with RetryBackoff and doubling it. After
+// BreakerThreshold failed calls in a row the service is not called for
+// BreakerCooldown.
 type UrlShortenerClientConfig struct {
-	Host     string `yaml:"host" env-default:"localhost"`
-	Port     string `yaml:"port" env-default:"8080"`
-	Username string `yaml:"username" env-default:"pasha"`
-	Password string `yaml:"password" env-default:"1234"`
+	Driver           string        `yaml:"driver" env-default:"external"`
+	BaseURL          string        `yaml:"base_url"`
+	Host             string        `yaml:"host" env-default:"localhost"`
+	Port             string        `yaml:"port" env-default:"8080"`
+	Username         string        `yaml:"username" env-default:"pasha"`
+	Password         string        `yaml:"password" env-default:"1234"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"3s"`
+	Retries          int           `yaml:"retries" env-default:"2"`
+	RetryBackoff     time.Duration `yaml:"retry_backoff" env-default:"200ms"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env-default:"30s"`
 }
 
 func MustLoad() *Config {
@@ -68,7 +131,9 @@ func MustLoad() *Config {
 		panic(fmt.Sprintf("Failed parse config: %v", err))
 	}
 
-	fmt.Println(cfg)
+	if err := cfg.validate(); err != nil {
+		panic(fmt.Sprintf("Invalid config: %v", err))
+	}
 
 	return &cfg
 }
@@ -80,5 +145,17 @@ func MustLoadByPath(configPath string) *Config {
 		panic(fmt.Sprintf("Failed parse config: %v", err))
 	}
 
+	if err := cfg.validate(); err != nil {
+		panic(fmt.Sprintf("Invalid config: %v", err))
+	}
+
 	return &cfg
 }
+
+func (c *Config) validate() error {
+	if c.UrlShortenerClient.BaseURL == "" {
+		return errors.New("url_shortener_client.base_url is not set")
+	}
+
+	return nil
+}
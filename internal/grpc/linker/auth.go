@@ -0,0 +1,178 @@
+package linker
+
+import (
+	"context"
+	"errors"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"strings"
+)
+
+// Callers pass their API token, the one of the REST API, in the authorization
+// metadata as "Bearer <token>".
+const (
+	authorizationKey = "authorization"
+	bearerPrefix     = "Bearer "
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (username string, err error)
+}
+
+type callerKey struct{}
+
+// usernameRequest is a request that names the user it acts for.
+type usernameRequest interface {
+	GetUsername() string
+}
+
+// requestUsername returns the user a request of some method acts for.
+type requestUsername func(ctx context.Context, req any) string
+
+// methodUsernames tells where the request of every served method names its
+// user. Methods missing here are refused, so a new method can't skip the
+// check by accident.
+var methodUsernames = map[string]requestUsername{
+	linkerMethod("PostTopic"):   fieldUsername,
+	linkerMethod("DeleteTopic"): fieldUsername,
+	linkerMethod("ListTopics"):  fieldUsername,
+	linkerMethod("PostLink"):    fieldUsername,
+	linkerMethod("PickLink"):    fieldUsername,
+	linkerMethod("DeleteLink"):  fieldUsername,
+	linkerMethod("ListLinks"):   fieldUsername,
+
+	listTrashMethod:    valueUsername,
+	restoreTopicMethod: fieldUsername,
+	restoreLinkMethod:  fieldUsername,
+
+	exportAccountMethod: valueUsername,
+	importAccountMethod: metadataUsername,
+
+	shareTopicMethod:       structUsername,
+	unshareTopicMethod:     structUsername,
+	listTopicSharesMethod:  structUsername,
+	listSharedTopicsMethod: valueUsername,
+}
+
+func linkerMethod(name string) string {
+	return "/" + linkerV2.Linker_ServiceDesc.ServiceName + "/" + name
+}
+
+func fieldUsername(_ context.Context, req any) string {
+	if r, ok := req.(usernameRequest); ok {
+		return r.GetUsername()
+	}
+
+	return ""
+}
+
+func valueUsername(_ context.Context, req any) string {
+	if r, ok := req.(*wrapperspb.StringValue); ok {
+		return r.GetValue()
+	}
+
+	return ""
+}
+
+func structUsername(_ context.Context, req any) string {
+	if r, ok := req.(*structpb.Struct); ok {
+		return structString(r, shareUsernameField)
+	}
+
+	return ""
+}
+
+func metadataUsername(ctx context.Context, _ any) string {
+	return metadataValue(ctx, usernameKey)
+}
+
+// AuthUnaryInterceptor authenticates the caller, puts the caller into the
+// context and rejects requests made for other users and requests of methods
+// missing from methodUsernames.
+func AuthUnaryInterceptor(authenticator Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+
+		username, ok := methodUsernames[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.PermissionDenied, MsgPermissionDenied)
+		}
+
+		if err := authorize(ctx, username(ctx, req)); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor does for streams what AuthUnaryInterceptor does for
+// unary calls, every received message is checked.
+func AuthStreamInterceptor(authenticator Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+
+		username, ok := methodUsernames[info.FullMethod]
+		if !ok {
+			return status.Error(codes.PermissionDenied, MsgPermissionDenied)
+		}
+
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx, username: username})
+	}
+}
+
+type authStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	username requestUsername
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return authorize(s.ctx, s.username(s.ctx, m))
+}
+
+func authenticate(ctx context.Context, authenticator Authenticator) (context.Context, error) {
+	header := metadataValue(ctx, authorizationKey)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return nil, status.Error(codes.Unauthenticated, MsgUnauthenticated)
+	}
+
+	username, err := authenticator.Authenticate(ctx, strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, MsgUnauthenticated)
+		}
+
+		return nil, status.Error(codes.Internal, MsgInternalError)
+	}
+
+	return context.WithValue(ctx, callerKey{}, username), nil
+}
+
+// authorize checks that the request for username is made by username itself.
+func authorize(ctx context.Context, username string) error {
+	if caller, _ := ctx.Value(callerKey{}).(string); caller != username {
+		return status.Error(codes.PermissionDenied, MsgPermissionDenied)
+	}
+
+	return nil
+}
@@ -3,16 +3,32 @@ package linker
 var (
 	MsgInternalError = "something went wrong"
 
+	MsgUnauthenticated  = "missing or invalid api token"
+	MsgPermissionDenied = "the api token belongs to another user"
+
+	MsgTopicPermissionDenied = "not enough rights for the shared topic"
+
 	MsgRecordNotFound = "link with this alias was not found"
 	MsgAliasNotFound  = "link with this alias was not found"
 	MsgUserNotFound   = "unknown username"
 	MsgTopicNotFound  = "unknown topic"
+	MsgNotInTrash     = "there is no such topic or link in the trash"
+	MsgShareNotFound  = "the topic is not shared with this user"
 
 	MsgAliasAlreadyExists = "link with such an alias already exists"
 	MsgTopicAlreadyExists = "topic with such name already exists"
 
-	MsgInvalidUsername = "you cannot use a username less than 8 characters long"
-	MsgInvalidLink     = "you are trying to post a non-link"
-	MsgEmptyAlias      = "it is impossible to find a link using an empty alias"
-	MsgEmptyTopic      = "it is impossible to post a new topic with empty name"
+	MsgInvalidUsername  = "you cannot use a username less than 8 characters long"
+	MsgInvalidLink      = "you are trying to post a non-link"
+	MsgEmptyAlias       = "it is impossible to find a link using an empty alias"
+	MsgEmptyTopic       = "it is impossible to post a new topic with empty name"
+	MsgInvalidPage      = "invalid page size, cursor or sort order"
+	MsgInvalidTopicPath = "topic path must not contain empty segments"
+
+	MsgInvalidShareRole = "unsupported share role, use viewer or editor"
+	MsgShareWithOwner   = "a topic cannot be shared with its owner"
+
+	MsgUnsupportedFormat = "unsupported export format, use json or csv"
+	MsgUnsupportedPolicy = "unsupported conflict policy, use skip, overwrite or rename"
+	MsgInvalidExport     = "invalid or unsupported export file"
 )
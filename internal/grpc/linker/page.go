@@ -0,0 +1,74 @@
+package linker
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strconv"
+)
+
+// The listing messages have no paging fields, so clients ask for a page with
+// request metadata and receive the cursor of the next one in the response
+// header. Without metadata the whole listing is returned.
+const (
+	pageSizeKey    = "page-size"
+	pageCursorKey  = "page-cursor"
+	sortByKey      = "sort-by"
+	sortOrderKey   = "sort-order"
+	filterQueryKey = "filter-query"
+	filterTagKey   = "filter-tag"
+	nextCursorKey  = "next-cursor"
+
+	// Topic paths nest, these narrow listings to a part of the hierarchy:
+	// topic-parent keeps the subtopics of a topic, topic-direct set to "true"
+	// only its children and recursive set to "true" lists links of subtopics
+	// too.
+	topicParentKey = "topic-parent"
+	topicDirectKey = "topic-direct"
+	recursiveKey   = "recursive"
+
+	descOrder = "desc"
+)
+
+func pageFromMetadata(ctx context.Context) (models.Page, error) {
+	page := models.Page{
+		Cursor: metadataValue(ctx, pageCursorKey),
+		SortBy: models.SortKey(metadataValue(ctx, sortByKey)),
+		Desc:   metadataValue(ctx, sortOrderKey) == descOrder,
+	}
+
+	if size := metadataValue(ctx, pageSizeKey); size != "" {
+		limit, err := strconv.Atoi(size)
+		if err != nil || limit <= 0 {
+			return page, storage.ErrInvalidPage
+		}
+
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func setNextCursor(ctx context.Context, next string) error {
+	if next == "" {
+		return nil
+	}
+
+	return grpc.SetHeader(ctx, metadata.Pairs(nextCursorKey, next))
+}
@@ -4,15 +4,15 @@ import (
 	"context"
 	"errors"
 	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
-	urlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener"
+	"github.com/Sleeps17/linker/internal/models"
 	"github.com/Sleeps17/linker/internal/storage"
 	"github.com/Sleeps17/linker/pkg/random"
 	"github.com/go-playground/validator"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log/slog"
-	"strings"
 )
 
 const (
@@ -21,17 +21,26 @@ const (
 	emptyTopic            = ""
 )
 
+// The link messages carry the URL the user saved, short URLs are returned in
+// the response header: short-url for a picked link and a short-urls value per
+// listed link, in the order of the links. Links that aren't shortened yet have
+// empty values.
+const (
+	shortURLKey  = "short-url"
+	shortURLsKey = "short-urls"
+)
+
 type TopicService interface {
 	PostTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
 	DeleteTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
-	ListTopics(ctx context.Context, username string) (topics []string, err error)
+	ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) (topics []string, next string, err error)
 }
 
 type LinkService interface {
-	PostLink(ctx context.Context, username, topic, link, alias string) (err error)
-	PickLink(ctx context.Context, username, topic, alias string) (link string, err error)
-	DeleteLink(ctx context.Context, username, topic, alias string) (err error)
-	ListLinks(ctx context.Context, username, topic string) (links []string, aliases []string, err error)
+	PostShortenedLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	PickLink(ctx context.Context, username, topic, alias string) (link models.Link, err error)
+	DeleteShortenedLink(ctx context.Context, username, topic, alias string) (err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
 }
 
 type serverAPI struct {
@@ -39,7 +48,6 @@ type serverAPI struct {
 	log           *slog.Logger
 	topicService  TopicService
 	linkerService LinkService
-	urlShortener  urlShortener.UrlShortener
 }
 
 func Register(
@@ -47,14 +55,12 @@ func Register(
 	log *slog.Logger,
 	linkerService LinkService,
 	topicService TopicService,
-	urlShortener urlShortener.UrlShortener,
 ) {
 	linkerV2.RegisterLinkerServer(
 		s, &serverAPI{
 			log:           log,
 			linkerService: linkerService,
 			topicService:  topicService,
-			urlShortener:  urlShortener,
 		},
 	)
 }
@@ -83,6 +89,11 @@ func (s *serverAPI) PostTopic(ctx context.Context, req *linkerV2.PostTopicReques
 			return nil, status.Error(codes.InvalidArgument, MsgTopicAlreadyExists)
 		}
 
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			s.log.Info("request with invalid topic path", slog.String("topic", topic))
+			return nil, status.Error(codes.InvalidArgument, MsgInvalidTopicPath)
+		}
+
 		s.log.Error("failed to handle post topic request", slog.String("err", err.Error()))
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
@@ -119,6 +130,11 @@ func (s *serverAPI) DeleteTopic(ctx context.Context, req *linkerV2.DeleteTopicRe
 			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			s.log.Info("not enough rights for the topic", slog.String("user", username), slog.String("topic", topic))
+			return nil, status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+		}
+
 		s.log.Error("failed to handle delete topic request", slog.String("err", err.Error()))
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
@@ -135,17 +151,48 @@ func (s *serverAPI) ListTopics(ctx context.Context, req *linkerV2.ListTopicsRequ
 		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
 	}
 
-	topics, err := s.topicService.ListTopics(ctx, username)
+	page, err := pageFromMetadata(ctx)
+	if err != nil {
+		s.log.Info("request with invalid page", slog.String("err", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidPage)
+	}
+
+	filter := models.TopicsFilter{
+		Query:  metadataValue(ctx, filterQueryKey),
+		Direct: metadataValue(ctx, topicDirectKey) == "true",
+	}
+
+	if filter.Parent, err = storage.CleanTopicParent(metadataValue(ctx, topicParentKey)); err != nil {
+		s.log.Info("request with invalid topic path", slog.String("err", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidTopicPath)
+	}
+
+	topics, next, err := s.topicService.ListTopics(ctx, username, page, filter)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			s.log.Info("user not found", slog.String("user", username))
 			return nil, status.Error(codes.InvalidArgument, MsgUserNotFound)
 		}
 
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			s.log.Info("parent topic not found", slog.String("user", username), slog.String("topic", filter.Parent))
+			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
+		}
+
+		if errors.Is(err, storage.ErrInvalidPage) {
+			s.log.Info("request with invalid page", slog.String("err", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, MsgInvalidPage)
+		}
+
 		s.log.Error("failed to handle list topics request", slog.String("err", err.Error()))
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
 
+	if err := setNextCursor(ctx, next); err != nil {
+		s.log.Error("failed to set next cursor", slog.String("err", err.Error()))
+		return nil, status.Error(codes.Internal, MsgInternalError)
+	}
+
 	s.log.Info("list topics request handled successfully")
 	return &linkerV2.ListTopicsResponse{Topics: topics}, nil
 }
@@ -178,16 +225,9 @@ func (s *serverAPI) PostLink(ctx context.Context, req *linkerV2.PostLinkRequest)
 		return nil, status.Error(codes.InvalidArgument, MsgInvalidLink)
 	}
 
-	s.log.Info("try to short link", slog.String("link", link))
-	newLink, err := s.urlShortener.SaveURL(ctx, link, alias)
-	if err != nil {
-		s.log.Info("failed to short link", slog.String("err", err.Error()))
-	} else {
-		link = newLink
-		s.log.Info("short link generated", slog.String("link", link))
-	}
-
-	if err := s.linkerService.PostLink(ctx, username, topic, link, alias); err != nil {
+	// The link is shortened in the background, it keeps the long URL until
+	// then.
+	if err := s.linkerService.PostShortenedLink(ctx, username, topic, models.Link{URL: link, Alias: alias}); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			s.log.Info("user not found", slog.String("user", username))
 			return nil, status.Error(codes.InvalidArgument, MsgUserNotFound)
@@ -198,6 +238,11 @@ func (s *serverAPI) PostLink(ctx context.Context, req *linkerV2.PostLinkRequest)
 			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			s.log.Info("not enough rights for the topic", slog.String("user", username), slog.String("topic", topic))
+			return nil, status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+		}
+
 		if errors.Is(err, storage.ErrAliasAlreadyExists) {
 			s.log.Info("alias already exists", slog.String("alias", alias))
 			return nil, status.Error(codes.InvalidArgument, MsgAliasAlreadyExists)
@@ -245,6 +290,11 @@ func (s *serverAPI) PickLink(ctx context.Context, req *linkerV2.PickLinkRequest)
 			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			s.log.Info("not enough rights for the topic", slog.String("user", username), slog.String("topic", topic))
+			return nil, status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			s.log.Info("alias not found", slog.String("alias", alias))
 			return nil, status.Error(codes.InvalidArgument, MsgAliasNotFound)
@@ -254,8 +304,13 @@ func (s *serverAPI) PickLink(ctx context.Context, req *linkerV2.PickLinkRequest)
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
 
+	if err := grpc.SetHeader(ctx, metadata.Pairs(shortURLKey, link.ShortURL)); err != nil {
+		s.log.Error("failed to set short url", slog.String("err", err.Error()))
+		return nil, status.Error(codes.Internal, MsgInternalError)
+	}
+
 	s.log.Info("pick request handled successfully", slog.String("alias", alias))
-	return &linkerV2.PickLinkResponse{Link: link}, nil
+	return &linkerV2.PickLinkResponse{Link: link.URL}, nil
 }
 
 func (s *serverAPI) ListLinks(ctx context.Context, req *linkerV2.ListLinksRequest) (*linkerV2.ListLinksResponse, error) {
@@ -274,7 +329,19 @@ func (s *serverAPI) ListLinks(ctx context.Context, req *linkerV2.ListLinksReques
 		return nil, status.Error(codes.InvalidArgument, MsgEmptyTopic)
 	}
 
-	links, aliases, err := s.linkerService.ListLinks(ctx, username, topic)
+	page, err := pageFromMetadata(ctx)
+	if err != nil {
+		s.log.Info("request with invalid page", slog.String("err", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidPage)
+	}
+
+	filter := models.LinksFilter{
+		Query:     metadataValue(ctx, filterQueryKey),
+		Tag:       metadataValue(ctx, filterTagKey),
+		Recursive: metadataValue(ctx, recursiveKey) == "true",
+	}
+
+	links, next, err := s.linkerService.ListLinks(ctx, username, topic, page, filter)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			s.log.Info("user not found", slog.String("user", username))
@@ -286,12 +353,41 @@ func (s *serverAPI) ListLinks(ctx context.Context, req *linkerV2.ListLinksReques
 			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			s.log.Info("not enough rights for the topic", slog.String("user", username), slog.String("topic", topic))
+			return nil, status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+		}
+
+		if errors.Is(err, storage.ErrInvalidPage) {
+			s.log.Info("request with invalid page", slog.String("err", err.Error()))
+			return nil, status.Error(codes.InvalidArgument, MsgInvalidPage)
+		}
+
 		s.log.Error("filed to handle list request", slog.String("err", err.Error()))
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
 
+	if err := setNextCursor(ctx, next); err != nil {
+		s.log.Error("failed to set next cursor", slog.String("err", err.Error()))
+		return nil, status.Error(codes.Internal, MsgInternalError)
+	}
+
+	urls := make([]string, 0, len(links))
+	aliases := make([]string, 0, len(links))
+	shortURLs := metadata.MD{}
+	for _, link := range links {
+		urls = append(urls, link.URL)
+		aliases = append(aliases, link.Alias)
+		shortURLs.Append(shortURLsKey, link.ShortURL)
+	}
+
+	if err := grpc.SetHeader(ctx, shortURLs); err != nil {
+		s.log.Error("failed to set short urls", slog.String("err", err.Error()))
+		return nil, status.Error(codes.Internal, MsgInternalError)
+	}
+
 	s.log.Info("list request handled successfully")
-	return &linkerV2.ListLinksResponse{Links: links, Aliases: aliases}, nil
+	return &linkerV2.ListLinksResponse{Links: urls, Aliases: aliases}, nil
 }
 
 func (s *serverAPI) DeleteLink(ctx context.Context, req *linkerV2.DeleteLinkRequest) (*linkerV2.DeleteLinkResponse, error) {
@@ -316,7 +412,7 @@ func (s *serverAPI) DeleteLink(ctx context.Context, req *linkerV2.DeleteLinkRequ
 		return nil, status.Error(codes.InvalidArgument, MsgEmptyAlias)
 	}
 
-	if err := s.linkerService.DeleteLink(ctx, username, topic, alias); err != nil {
+	if err := s.linkerService.DeleteShortenedLink(ctx, username, topic, alias); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			s.log.Info("user not found", slog.String("user", username))
 			return nil, status.Error(codes.InvalidArgument, MsgUserNotFound)
@@ -327,6 +423,11 @@ func (s *serverAPI) DeleteLink(ctx context.Context, req *linkerV2.DeleteLinkRequ
 			return nil, status.Error(codes.InvalidArgument, MsgTopicNotFound)
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			s.log.Info("not enough rights for the topic", slog.String("user", username), slog.String("topic", topic))
+			return nil, status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			s.log.Info("alias not found", slog.String("alias", alias))
 			return nil, status.Error(codes.InvalidArgument, MsgAliasNotFound)
@@ -336,18 +437,6 @@ func (s *serverAPI) DeleteLink(ctx context.Context, req *linkerV2.DeleteLinkRequ
 		return nil, status.Error(codes.Internal, MsgInternalError)
 	}
 
-	s.log.Info("try to delete link from shortener", slog.String("alias", alias))
-	err := s.urlShortener.DeleteURL(ctx, getAlias(alias))
-	if err != nil {
-		s.log.Info("failed to delete url", slog.String("err", err.Error()))
-	}
-
 	s.log.Info("delete request handled successfully", slog.String("alias", alias))
 	return &linkerV2.DeleteLinkResponse{Alias: alias}, nil
 }
-
-func getAlias(link string) string {
-	parts := strings.Split(link, "/")
-	lastPart := parts[len(parts)-1]
-	return lastPart
-}
@@ -0,0 +1,34 @@
+package linker
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// unaryHandler adapts a method of a hand-described service to grpc the way
+// generated code does.
+func unaryHandler[Srv, Req, Resp any](
+	method string,
+	call func(Srv, context.Context, *Req) (*Resp, error),
+) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(Req)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+
+		if interceptor == nil {
+			return call(srv.(Srv), ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: method,
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return call(srv.(Srv), ctx, req.(*Req))
+		}
+
+		return interceptor(ctx, in, info, handler)
+	}
+}
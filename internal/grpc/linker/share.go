@@ -0,0 +1,287 @@
+package linker
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"log/slog"
+)
+
+// Topic shares are served by the linker.Sharing service, described by hand
+// with well-known messages. Requests about a topic are structs with the
+// username of the owner, the topic and, when sharing, the user and the role:
+// {"username", "topic", "user", "role"}. ListSharedTopics takes the username.
+const (
+	sharingServiceName     = "linker.Sharing"
+	shareTopicMethod       = "/" + sharingServiceName + "/ShareTopic"
+	unshareTopicMethod     = "/" + sharingServiceName + "/UnshareTopic"
+	listTopicSharesMethod  = "/" + sharingServiceName + "/ListTopicShares"
+	listSharedTopicsMethod = "/" + sharingServiceName + "/ListSharedTopics"
+
+	shareUsernameField = "username"
+	shareTopicField    = "topic"
+	shareUserField     = "user"
+	shareRoleField     = "role"
+)
+
+type ShareService interface {
+	ShareTopic(ctx context.Context, owner, topic, username string, role models.ShareRole) (err error)
+	UnshareTopic(ctx context.Context, owner, topic, username string) (err error)
+	ListTopicShares(ctx context.Context, owner, topic string) (shares []models.TopicShare, err error)
+	ListSharedTopics(ctx context.Context, username string) (topics []models.SharedTopic, err error)
+}
+
+type sharingServer interface {
+	ShareTopic(ctx context.Context, req *structpb.Struct) (*emptypb.Empty, error)
+	UnshareTopic(ctx context.Context, req *structpb.Struct) (*emptypb.Empty, error)
+	ListTopicShares(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	ListSharedTopics(ctx context.Context, req *wrapperspb.StringValue) (*structpb.Struct, error)
+}
+
+var sharingServiceDesc = grpc.ServiceDesc{
+	ServiceName: sharingServiceName,
+	HandlerType: (*sharingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ShareTopic",
+			Handler:    unaryHandler(shareTopicMethod, sharingServer.ShareTopic),
+		},
+		{
+			MethodName: "UnshareTopic",
+			Handler:    unaryHandler(unshareTopicMethod, sharingServer.UnshareTopic),
+		},
+		{
+			MethodName: "ListTopicShares",
+			Handler:    unaryHandler(listTopicSharesMethod, sharingServer.ListTopicShares),
+		},
+		{
+			MethodName: "ListSharedTopics",
+			Handler:    unaryHandler(listSharedTopicsMethod, sharingServer.ListSharedTopics),
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+type sharingServerAPI struct {
+	log          *slog.Logger
+	shareService ShareService
+}
+
+func RegisterSharing(s *grpc.Server, log *slog.Logger, shareService ShareService) {
+	s.RegisterService(&sharingServiceDesc, &sharingServerAPI{
+		log:          log,
+		shareService: shareService,
+	})
+}
+
+func (s *sharingServerAPI) ShareTopic(ctx context.Context, req *structpb.Struct) (*emptypb.Empty, error) {
+	owner, topic, err := s.topicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	username := structString(req, shareUserField)
+	role := models.ShareRole(structString(req, shareRoleField))
+
+	s.log.Info("try to handle share topic request", slog.String("topic", topic), slog.String("user", username), slog.String("role", string(role)))
+
+	if err := s.shareService.ShareTopic(ctx, owner, topic, username, role); err != nil {
+		return nil, s.shareError(err, owner)
+	}
+
+	s.log.Info("share topic request handled successfully", slog.String("topic", topic))
+	return &emptypb.Empty{}, nil
+}
+
+func (s *sharingServerAPI) UnshareTopic(ctx context.Context, req *structpb.Struct) (*emptypb.Empty, error) {
+	owner, topic, err := s.topicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	username := structString(req, shareUserField)
+
+	s.log.Info("try to handle unshare topic request", slog.String("topic", topic), slog.String("user", username))
+
+	if err := s.shareService.UnshareTopic(ctx, owner, topic, username); err != nil {
+		return nil, s.shareError(err, owner)
+	}
+
+	s.log.Info("unshare topic request handled successfully", slog.String("topic", topic))
+	return &emptypb.Empty{}, nil
+}
+
+func (s *sharingServerAPI) ListTopicShares(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	owner, topic, err := s.topicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("try to handle list topic shares request", slog.String("topic", topic))
+
+	shares, err := s.shareService.ListTopicShares(ctx, owner, topic)
+	if err != nil {
+		return nil, s.shareError(err, owner)
+	}
+
+	values := make([]*structpb.Value, 0, len(shares))
+	for _, share := range shares {
+		values = append(values, structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"username": structpb.NewStringValue(share.Username),
+				"role":     structpb.NewStringValue(string(share.Role)),
+			},
+		}))
+	}
+
+	s.log.Info("list topic shares request handled successfully", slog.Int("count", len(shares)))
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"shares": structpb.NewListValue(&structpb.ListValue{Values: values}),
+		},
+	}, nil
+}
+
+func (s *sharingServerAPI) ListSharedTopics(ctx context.Context, req *wrapperspb.StringValue) (*structpb.Struct, error) {
+	username := req.GetValue()
+
+	s.log.Info("try to handle list shared topics request", slog.String("username", username))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	topics, err := s.shareService.ListSharedTopics(ctx, username)
+	if err != nil {
+		return nil, s.shareError(err, username)
+	}
+
+	values := make([]*structpb.Value, 0, len(topics))
+	for _, topic := range topics {
+		values = append(values, structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"owner": structpb.NewStringValue(topic.Owner),
+				"topic": structpb.NewStringValue(topic.Topic),
+				"path":  structpb.NewStringValue(topic.Path),
+				"role":  structpb.NewStringValue(string(topic.Role)),
+			},
+		}))
+	}
+
+	s.log.Info("list shared topics request handled successfully", slog.Int("count", len(topics)))
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"topics": structpb.NewListValue(&structpb.ListValue{Values: values}),
+		},
+	}, nil
+}
+
+// topicRequest validates the owner and the topic of req.
+func (s *sharingServerAPI) topicRequest(req *structpb.Struct) (string, string, error) {
+	owner := structString(req, shareUsernameField)
+	topic := structString(req, shareTopicField)
+
+	if len(owner) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return "", "", status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	if topic == emptyTopic {
+		s.log.Info("request with empty topic")
+		return "", "", status.Error(codes.InvalidArgument, MsgEmptyTopic)
+	}
+
+	return owner, topic, nil
+}
+
+func (s *sharingServerAPI) shareError(err error, username string) error {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		s.log.Info("user not found", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgUserNotFound)
+	}
+
+	if errors.Is(err, storage.ErrTopicNotFound) {
+		s.log.Info("topic not found", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgTopicNotFound)
+	}
+
+	if errors.Is(err, storage.ErrShareNotFound) {
+		s.log.Info("share not found", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgShareNotFound)
+	}
+
+	if errors.Is(err, storage.ErrInvalidShareRole) {
+		s.log.Info("invalid share role", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgInvalidShareRole)
+	}
+
+	if errors.Is(err, storage.ErrShareWithOwner) {
+		s.log.Info("share with the owner", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgShareWithOwner)
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		s.log.Info("share of a shared topic", slog.String("user", username))
+		return status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+	}
+
+	s.log.Error("failed to handle sharing request", slog.String("err", err.Error()))
+	return status.Error(codes.Internal, MsgInternalError)
+}
+
+func structString(req *structpb.Struct, field string) string {
+	return req.GetFields()[field].GetStringValue()
+}
+
+// SharingClient is the client of the linker.Sharing service.
+type SharingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSharingClient(cc grpc.ClientConnInterface) *SharingClient {
+	return &SharingClient{cc: cc}
+}
+
+func (c *SharingClient) ShareTopic(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	if err := c.cc.Invoke(ctx, shareTopicMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *SharingClient) UnshareTopic(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	if err := c.cc.Invoke(ctx, unshareTopicMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *SharingClient) ListTopicShares(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, listTopicSharesMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *SharingClient) ListSharedTopics(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, listSharedTopicsMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
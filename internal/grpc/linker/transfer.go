@@ -0,0 +1,172 @@
+package linker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"io"
+	"log/slog"
+)
+
+// Account exports and imports are served by the linker.Transfer service. It
+// is described by hand with well-known messages: ExportAccount takes the
+// username and returns the file, ImportAccount takes the file and returns the
+// counts of the import result. The rest is passed in request metadata.
+const (
+	transferServiceName = "linker.Transfer"
+	exportAccountMethod = "/" + transferServiceName + "/ExportAccount"
+	importAccountMethod = "/" + transferServiceName + "/ImportAccount"
+
+	// usernameKey is the user an import goes to.
+	usernameKey = "username"
+	// exportFormatKey is json or csv, json by default.
+	exportFormatKey = "export-format"
+	// conflictPolicyKey is skip, overwrite or rename, skip by default.
+	conflictPolicyKey = "conflict-policy"
+)
+
+type TransferService interface {
+	ExportAccount(ctx context.Context, username string, format transfer.Format, w io.Writer) (err error)
+	ImportAccount(ctx context.Context, username string, format transfer.Format, policy transfer.Policy, r io.Reader) (result models.ImportResult, err error)
+}
+
+type transferServer interface {
+	ExportAccount(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.BytesValue, error)
+	ImportAccount(ctx context.Context, req *wrapperspb.BytesValue) (*structpb.Struct, error)
+}
+
+var transferServiceDesc = grpc.ServiceDesc{
+	ServiceName: transferServiceName,
+	HandlerType: (*transferServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ExportAccount",
+			Handler:    unaryHandler(exportAccountMethod, transferServer.ExportAccount),
+		},
+		{
+			MethodName: "ImportAccount",
+			Handler:    unaryHandler(importAccountMethod, transferServer.ImportAccount),
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+type transferServerAPI struct {
+	log             *slog.Logger
+	transferService TransferService
+}
+
+func RegisterTransfer(s *grpc.Server, log *slog.Logger, transferService TransferService) {
+	s.RegisterService(&transferServiceDesc, &transferServerAPI{
+		log:             log,
+		transferService: transferService,
+	})
+}
+
+func (s *transferServerAPI) ExportAccount(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.BytesValue, error) {
+	username := req.GetValue()
+	format := transfer.Format(metadataValue(ctx, exportFormatKey))
+
+	s.log.Info("try to handle export account request", slog.String("username", username), slog.String("format", string(format)))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	var buf bytes.Buffer
+	if err := s.transferService.ExportAccount(ctx, username, format, &buf); err != nil {
+		return nil, s.transferError(err, username)
+	}
+
+	s.log.Info("export account request handled successfully", slog.Int("size", buf.Len()))
+	return wrapperspb.Bytes(buf.Bytes()), nil
+}
+
+func (s *transferServerAPI) ImportAccount(ctx context.Context, req *wrapperspb.BytesValue) (*structpb.Struct, error) {
+	username := metadataValue(ctx, usernameKey)
+	format := transfer.Format(metadataValue(ctx, exportFormatKey))
+	policy := transfer.Policy(metadataValue(ctx, conflictPolicyKey))
+
+	s.log.Info("try to handle import account request", slog.String("username", username), slog.String("policy", string(policy)))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	result, err := s.transferService.ImportAccount(ctx, username, format, policy, bytes.NewReader(req.GetValue()))
+	if err != nil {
+		return nil, s.transferError(err, username)
+	}
+
+	s.log.Info("import account request handled successfully", slog.Any("result", result))
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"topics":  structpb.NewNumberValue(float64(result.Topics)),
+			"links":   structpb.NewNumberValue(float64(result.Links)),
+			"updated": structpb.NewNumberValue(float64(result.Updated)),
+			"skipped": structpb.NewNumberValue(float64(result.Skipped)),
+		},
+	}, nil
+}
+
+func (s *transferServerAPI) transferError(err error, username string) error {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		s.log.Info("user not found", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgUserNotFound)
+	}
+
+	if errors.Is(err, transfer.ErrUnsupportedFormat) {
+		s.log.Info("unsupported export format")
+		return status.Error(codes.InvalidArgument, MsgUnsupportedFormat)
+	}
+
+	if errors.Is(err, transfer.ErrUnsupportedPolicy) {
+		s.log.Info("unsupported conflict policy")
+		return status.Error(codes.InvalidArgument, MsgUnsupportedPolicy)
+	}
+
+	if errors.Is(err, transfer.ErrUnsupportedVersion) || errors.Is(err, transfer.ErrInvalidArchive) {
+		s.log.Info("invalid export file", slog.String("err", err.Error()))
+		return status.Error(codes.InvalidArgument, MsgInvalidExport)
+	}
+
+	s.log.Error("failed to handle transfer request", slog.String("err", err.Error()))
+	return status.Error(codes.Internal, MsgInternalError)
+}
+
+// TransferClient is the client of the linker.Transfer service.
+type TransferClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransferClient(cc grpc.ClientConnInterface) *TransferClient {
+	return &TransferClient{cc: cc}
+}
+
+func (c *TransferClient) ExportAccount(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*wrapperspb.BytesValue, error) {
+	out := new(wrapperspb.BytesValue)
+	if err := c.cc.Invoke(ctx, exportAccountMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *TransferClient) ImportAccount(ctx context.Context, in *wrapperspb.BytesValue, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, importAccountMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,235 @@
+package linker
+
+import (
+	"context"
+	"errors"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"log/slog"
+	"time"
+)
+
+// The linker protos have no trash RPCs, so the trash is served by the
+// linker.Trash service described here by hand. Restores take the messages of
+// the deletes they undo. ListTrash takes the username and returns a struct
+// with the items: {"items": [{"id", "topic", "alias", "subtopics", "links",
+// "deleted_at"}]}, deleted_at is in RFC 3339.
+const (
+	trashServiceName   = "linker.Trash"
+	listTrashMethod    = "/" + trashServiceName + "/ListTrash"
+	restoreTopicMethod = "/" + trashServiceName + "/RestoreTopic"
+	restoreLinkMethod  = "/" + trashServiceName + "/RestoreLink"
+)
+
+type TrashService interface {
+	ListTrash(ctx context.Context, username string) (items []models.TrashItem, err error)
+	RestoreTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
+	RestoreLink(ctx context.Context, username, topic, alias string) (err error)
+}
+
+type trashServer interface {
+	ListTrash(ctx context.Context, req *wrapperspb.StringValue) (*structpb.Struct, error)
+	RestoreTopic(ctx context.Context, req *linkerV2.DeleteTopicRequest) (*linkerV2.DeleteTopicResponse, error)
+	RestoreLink(ctx context.Context, req *linkerV2.DeleteLinkRequest) (*linkerV2.DeleteLinkResponse, error)
+}
+
+var trashServiceDesc = grpc.ServiceDesc{
+	ServiceName: trashServiceName,
+	HandlerType: (*trashServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTrash",
+			Handler:    unaryHandler(listTrashMethod, trashServer.ListTrash),
+		},
+		{
+			MethodName: "RestoreTopic",
+			Handler:    unaryHandler(restoreTopicMethod, trashServer.RestoreTopic),
+		},
+		{
+			MethodName: "RestoreLink",
+			Handler:    unaryHandler(restoreLinkMethod, trashServer.RestoreLink),
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+type trashServerAPI struct {
+	log          *slog.Logger
+	trashService TrashService
+}
+
+func RegisterTrash(s *grpc.Server, log *slog.Logger, trashService TrashService) {
+	s.RegisterService(&trashServiceDesc, &trashServerAPI{
+		log:          log,
+		trashService: trashService,
+	})
+}
+
+func (s *trashServerAPI) ListTrash(ctx context.Context, req *wrapperspb.StringValue) (*structpb.Struct, error) {
+	username := req.GetValue()
+
+	s.log.Info("try to handle list trash request", slog.String("username", username))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	items, err := s.trashService.ListTrash(ctx, username)
+	if err != nil {
+		return nil, s.trashError(err, username)
+	}
+
+	values := make([]*structpb.Value, 0, len(items))
+	for _, item := range items {
+		values = append(values, structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"id":         structpb.NewNumberValue(float64(item.ID)),
+				"topic":      structpb.NewStringValue(item.Topic),
+				"alias":      structpb.NewStringValue(item.Alias),
+				"subtopics":  structpb.NewNumberValue(float64(item.Subtopics)),
+				"links":      structpb.NewNumberValue(float64(item.Links)),
+				"deleted_at": structpb.NewStringValue(item.DeletedAt.UTC().Format(time.RFC3339)),
+			},
+		}))
+	}
+
+	s.log.Info("list trash request handled successfully", slog.Int("count", len(items)))
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"items": structpb.NewListValue(&structpb.ListValue{Values: values}),
+		},
+	}, nil
+}
+
+func (s *trashServerAPI) RestoreTopic(ctx context.Context, req *linkerV2.DeleteTopicRequest) (*linkerV2.DeleteTopicResponse, error) {
+	username := req.GetUsername()
+	topic := req.GetTopic()
+
+	s.log.Info("try to handle restore topic request", slog.String("username", username), slog.String("topic", topic))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	if topic == emptyTopic {
+		s.log.Info("request with empty topic")
+		return nil, status.Error(codes.InvalidArgument, MsgEmptyTopic)
+	}
+
+	topicId, err := s.trashService.RestoreTopic(ctx, username, topic)
+	if err != nil {
+		return nil, s.trashError(err, username)
+	}
+
+	s.log.Info("restore topic request handled successfully", slog.Any("topic_id", topicId))
+	return &linkerV2.DeleteTopicResponse{TopicId: topicId}, nil
+}
+
+func (s *trashServerAPI) RestoreLink(ctx context.Context, req *linkerV2.DeleteLinkRequest) (*linkerV2.DeleteLinkResponse, error) {
+	username := req.GetUsername()
+	topic := req.GetTopic()
+	alias := req.GetAlias()
+
+	s.log.Info("try to handle restore link request", slog.String("username", username), slog.String("alias", alias))
+
+	if len(username) < minimalUsernameLength {
+		s.log.Info("request with invalid username")
+		return nil, status.Error(codes.InvalidArgument, MsgInvalidUsername)
+	}
+
+	if topic == emptyTopic {
+		s.log.Info("request with empty topic")
+		return nil, status.Error(codes.InvalidArgument, MsgEmptyTopic)
+	}
+
+	if alias == emptyAlias {
+		s.log.Info("request with empty alias")
+		return nil, status.Error(codes.InvalidArgument, MsgEmptyAlias)
+	}
+
+	if err := s.trashService.RestoreLink(ctx, username, topic, alias); err != nil {
+		return nil, s.trashError(err, username)
+	}
+
+	s.log.Info("restore link request handled successfully", slog.String("alias", alias))
+	return &linkerV2.DeleteLinkResponse{Alias: alias}, nil
+}
+
+func (s *trashServerAPI) trashError(err error, username string) error {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		s.log.Info("user not found", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgUserNotFound)
+	}
+
+	if errors.Is(err, storage.ErrNotInTrash) {
+		s.log.Info("item not found in trash", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgNotInTrash)
+	}
+
+	if errors.Is(err, storage.ErrTopicAlreadyExists) {
+		s.log.Info("topic already exists", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgTopicAlreadyExists)
+	}
+
+	if errors.Is(err, storage.ErrAliasAlreadyExists) {
+		s.log.Info("alias already exists", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgAliasAlreadyExists)
+	}
+
+	if errors.Is(err, storage.ErrInvalidTopicPath) {
+		s.log.Info("request with invalid topic path", slog.String("user", username))
+		return status.Error(codes.InvalidArgument, MsgInvalidTopicPath)
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		s.log.Info("not enough rights for the topic", slog.String("user", username))
+		return status.Error(codes.PermissionDenied, MsgTopicPermissionDenied)
+	}
+
+	s.log.Error("failed to handle restore request", slog.String("err", err.Error()))
+	return status.Error(codes.Internal, MsgInternalError)
+}
+
+// TrashClient is the client of the linker.Trash service.
+type TrashClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTrashClient(cc grpc.ClientConnInterface) *TrashClient {
+	return &TrashClient{cc: cc}
+}
+
+func (c *TrashClient) ListTrash(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	if err := c.cc.Invoke(ctx, listTrashMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *TrashClient) RestoreTopic(ctx context.Context, in *linkerV2.DeleteTopicRequest, opts ...grpc.CallOption) (*linkerV2.DeleteTopicResponse, error) {
+	out := new(linkerV2.DeleteTopicResponse)
+	if err := c.cc.Invoke(ctx, restoreTopicMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (c *TrashClient) RestoreLink(ctx context.Context, in *linkerV2.DeleteLinkRequest, opts ...grpc.CallOption) (*linkerV2.DeleteLinkResponse, error) {
+	out := new(linkerV2.DeleteLinkResponse)
+	if err := c.cc.Invoke(ctx, restoreLinkMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package linker
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/auth"
+	"github.com/Sleeps17/linker/internal/http/linker/handlers"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (username string, err error)
+}
+
+// bearerAuth lets through requests with a valid "Authorization: Bearer" token
+// and stores the owner of the token under handlers.UsernameKey.
+func bearerAuth(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiError{
+				Message: "Требуется токен доступа",
+				Error:   "missing bearer token",
+			})
+			return
+		}
+
+		username, err := authenticator.Authenticate(c, strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidToken) {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.ApiError{
+					Message: "Неверный токен доступа",
+					Error:   err.Error(),
+				})
+				return
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+				Message: "Не удалось проверить токен доступа",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.Set(handlers.UsernameKey, username)
+		c.Next()
+	}
+}
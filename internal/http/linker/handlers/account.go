@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type AccountService interface {
+	ExportAccount(ctx context.Context, username string, format transfer.Format, w io.Writer) (err error)
+	ImportAccount(ctx context.Context, username string, format transfer.Format, policy transfer.Policy, r io.Reader) (result models.ImportResult, err error)
+}
+
+type AccountHandler struct {
+	accountService AccountService
+}
+
+func NewAccountHandler(log *slog.Logger, accountService AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+func (h *AccountHandler) Register(router gin.IRouter) {
+	router.GET("/account/export", h.exportAccount)
+	router.POST("/account/import", h.importAccount)
+}
+
+func (h *AccountHandler) exportAccount(c *gin.Context) {
+	var req models.ExportAccountRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	format := transfer.Format(req.Format)
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+
+	var buf bytes.Buffer
+	if err := h.accountService.ExportAccount(c, username(c), format, &buf); err != nil {
+		if errors.Is(err, transfer.ErrUnsupportedFormat) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неизвестный формат, поддерживаются json и csv",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось выгрузить данные пользователя",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == transfer.FormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="linker.%s"`, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+func (h *AccountHandler) importAccount(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+
+	var req models.ImportAccountRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	file, ok := uploadedFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	result, err := h.accountService.ImportAccount(c, username(c), transfer.Format(req.Format), transfer.Policy(req.Policy), file)
+	if err != nil {
+		if errors.Is(err, transfer.ErrUnsupportedFormat) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неизвестный формат, поддерживаются json и csv",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, transfer.ErrUnsupportedPolicy) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неизвестная политика конфликтов, поддерживаются skip, overwrite и rename",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, transfer.ErrUnsupportedVersion) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Версия файла не поддерживается",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, transfer.ErrInvalidArchive) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверный формат файла",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось загрузить данные пользователя",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
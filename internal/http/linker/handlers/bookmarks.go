@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+)
+
+// maxUploadSize limits uploaded bookmark files and account exports, browsers
+// export a few megabytes for tens of thousands of bookmarks.
+const maxUploadSize = 10 << 20
+
+type BookmarksService interface {
+	ImportBookmarks(ctx context.Context, username, parent string, r io.Reader) (result models.ImportResult, err error)
+	ExportBookmarks(ctx context.Context, username string, w io.Writer) (err error)
+}
+
+type BookmarksHandler struct {
+	bookmarksService BookmarksService
+}
+
+func NewBookmarksHandler(log *slog.Logger, bookmarksService BookmarksService) *BookmarksHandler {
+	return &BookmarksHandler{
+		bookmarksService: bookmarksService,
+	}
+}
+
+func (h *BookmarksHandler) Register(router gin.IRouter) {
+	router.POST("/bookmarks/import", h.importBookmarks)
+	router.GET("/bookmarks/export", h.exportBookmarks)
+}
+
+func (h *BookmarksHandler) importBookmarks(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+
+	var req models.ImportBookmarksRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	file, ok := uploadedFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	result, err := h.bookmarksService.ImportBookmarks(c, username(c), req.Topic, file)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверный путь топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, transfer.ErrNoBookmarks) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "В файле нет закладок",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось импортировать закладки",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *BookmarksHandler) exportBookmarks(c *gin.Context) {
+	// The file is built in memory, so a failed export still gets a JSON error.
+	var buf bytes.Buffer
+	if err := h.bookmarksService.ExportBookmarks(c, username(c), &buf); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось экспортировать закладки",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="bookmarks.html"`)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// uploadedFile opens the file sent in the "file" field of a multipart form,
+// aborting the request when there is none.
+func uploadedFile(c *gin.Context) (multipart.File, bool) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Файл не передан",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось прочитать файл",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	return file, true
+}
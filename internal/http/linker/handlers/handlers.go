@@ -4,6 +4,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UsernameKey is the context key under which the auth middleware stores the
+// owner of the request token.
+const UsernameKey = "username"
+
 type Handler interface {
-	Register(router *gin.Engine)
+	Register(router gin.IRouter)
+}
+
+// username returns the user the request is made by.
+func username(c *gin.Context) string {
+	return c.GetString(UsernameKey)
 }
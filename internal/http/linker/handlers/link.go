@@ -9,15 +9,25 @@ import (
 	"github.com/gin-gonic/gin"
 	"log/slog"
 	"net/http"
+	"strings"
 )
 
 type LinkService interface {
-	PostLink(ctx context.Context, username, topic, link, alias string) (err error)
-	PickLink(ctx context.Context, username, topic, alias string) (link string, err error)
-	DeleteLink(ctx context.Context, username, topic, alias string) (err error)
-	ListLinks(ctx context.Context, username, topic string) (links []string, aliases []string, err error)
+	PostShortenedLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	PickLink(ctx context.Context, username, topic, alias string) (link models.Link, err error)
+	DeleteShortenedLink(ctx context.Context, username, topic, alias string) (err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
+	SearchLinks(ctx context.Context, username, query string, limit int) (links []models.Link, err error)
+	UpdateLink(ctx context.Context, username, topic, alias string, update models.LinkUpdate) (link models.Link, err error)
+	MoveLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
+	CopyLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
 }
 
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
 type LinkHandler struct {
 	linkService LinkService
 }
@@ -28,11 +38,15 @@ func NewLinkHandler(log *slog.Logger, linkService LinkService) *LinkHandler {
 	}
 }
 
-func (h *LinkHandler) Register(router *gin.Engine) {
+func (h *LinkHandler) Register(router gin.IRouter) {
 	router.POST("/links", h.postLink)
 	router.GET("/links", h.getLink)
 	router.DELETE("/links", h.deleteLink)
 	router.GET("/links/list", h.listLinks)
+	router.GET("/links/search", h.searchLinks)
+	router.PATCH("/links", h.updateLink)
+	router.PATCH("/links/move", h.moveLink)
+	router.POST("/links/copy", h.copyLink)
 }
 
 func (h *LinkHandler) postLink(c *gin.Context) {
@@ -49,7 +63,15 @@ func (h *LinkHandler) postLink(c *gin.Context) {
 		req.Alias = random.Alias()
 	}
 
-	if err := h.linkService.PostLink(c, req.Username, req.Topic, req.Link, req.Alias); err != nil {
+	link := models.Link{
+		URL:         req.Link,
+		Alias:       req.Alias,
+		Title:       req.Title,
+		Description: req.Description,
+		Note:        req.Note,
+	}
+
+	if err := h.linkService.PostShortenedLink(c, username(c), req.Topic, link); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Пользователь не найден",
@@ -66,6 +88,14 @@ func (h *LinkHandler) postLink(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrAliasAlreadyExists) {
 			c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
 				Message: "Алиас уже существует",
@@ -94,7 +124,7 @@ func (h *LinkHandler) getLink(c *gin.Context) {
 		return
 	}
 
-	link, err := h.linkService.PickLink(c, req.Username, req.Topic, req.Alias)
+	link, err := h.linkService.PickLink(c, username(c), req.Topic, req.Alias)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
@@ -112,6 +142,14 @@ func (h *LinkHandler) getLink(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Адиас не найден",
@@ -127,7 +165,11 @@ func (h *LinkHandler) getLink(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.PickLinkResponse{Link: link})
+	c.JSON(http.StatusOK, models.PickLinkResponse{
+		Link:     link.URL,
+		ShortURL: link.ShortURL,
+		Code:     link.Code,
+	})
 }
 
 func (h *LinkHandler) deleteLink(c *gin.Context) {
@@ -140,7 +182,7 @@ func (h *LinkHandler) deleteLink(c *gin.Context) {
 		return
 	}
 
-	if err := h.linkService.DeleteLink(c, req.Username, req.Topic, req.Alias); err != nil {
+	if err := h.linkService.DeleteShortenedLink(c, username(c), req.Topic, req.Alias); err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Пользователь не найден",
@@ -157,6 +199,14 @@ func (h *LinkHandler) deleteLink(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrAliasNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Алиас не найден",
@@ -177,7 +227,7 @@ func (h *LinkHandler) deleteLink(c *gin.Context) {
 
 func (h *LinkHandler) listLinks(c *gin.Context) {
 	var req models.ListLinksRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindQuery(&req); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
 			Message: "Неверный формат запроса",
 			Error:   err.Error(),
@@ -185,8 +235,27 @@ func (h *LinkHandler) listLinks(c *gin.Context) {
 		return
 	}
 
-	links, aliases, err := h.linkService.ListLinks(c, req.Username, req.Topic)
+	page, err := newPage(req.Limit, req.Cursor, req.Sort, req.Order)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверные параметры страницы",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	filter := models.LinksFilter{Query: req.Query, Tag: req.Tag, Recursive: req.Recursive}
+
+	links, next, err := h.linkService.ListLinks(c, username(c), req.Topic, page, filter)
 	if err != nil {
+		if errors.Is(err, storage.ErrInvalidPage) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверные параметры страницы",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Пользователь не найден",
@@ -203,6 +272,14 @@ func (h *LinkHandler) listLinks(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
 			Message: "Не удалось получить список ссылок",
 			Error:   err.Error(),
@@ -210,5 +287,167 @@ func (h *LinkHandler) listLinks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ListLinksResponse{Links: links, Aliases: aliases})
+	c.JSON(http.StatusOK, models.ListLinksResponse{Links: links, NextCursor: next})
+}
+
+func (h *LinkHandler) searchLinks(c *gin.Context) {
+	var req models.SearchLinksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Поисковый запрос не может быть пустым",
+			Error:   "empty query",
+		})
+		return
+	}
+
+	if req.Limit <= 0 || req.Limit > maxSearchLimit {
+		req.Limit = defaultSearchLimit
+	}
+
+	links, err := h.linkService.SearchLinks(c, username(c), req.Query, req.Limit)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось выполнить поиск",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListLinksResponse{Links: links})
+}
+
+func (h *LinkHandler) updateLink(c *gin.Context) {
+	var req models.UpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if req.NewAlias != nil && *req.NewAlias == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Алиас не может быть пустым",
+			Error:   "empty alias",
+		})
+		return
+	}
+
+	link, err := h.linkService.UpdateLink(c, username(c), req.Topic, req.Alias, models.LinkUpdate{
+		URL:         req.Link,
+		Alias:       req.NewAlias,
+		Title:       req.Title,
+		Description: req.Description,
+		Note:        req.Note,
+	})
+	if err != nil {
+		h.abortWithLinkError(c, err, "Не удалось изменить ссылку")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UpdateLinkResponse{Link: link})
+}
+
+func (h *LinkHandler) moveLink(c *gin.Context) {
+	var req models.MoveLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	alias, err := h.linkService.MoveLink(c, username(c), req.Topic, req.Alias, req.ToTopic)
+	if err != nil {
+		h.abortWithLinkError(c, err, "Не удалось переместить ссылку")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MoveLinkResponse{Topic: req.ToTopic, Alias: alias})
+}
+
+func (h *LinkHandler) copyLink(c *gin.Context) {
+	var req models.CopyLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	alias, err := h.linkService.CopyLink(c, username(c), req.Topic, req.Alias, req.ToTopic)
+	if err != nil {
+		h.abortWithLinkError(c, err, "Не удалось скопировать ссылку")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CopyLinkResponse{Topic: req.ToTopic, Alias: alias})
+}
+
+// abortWithLinkError answers with the status of a storage error of an
+// operation on an existing link, message describes any other failure.
+func (h *LinkHandler) abortWithLinkError(c *gin.Context, err error, message string) {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Пользователь не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrTopicNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+			Message: "Недостаточно прав для топика",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrAliasNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Алиас не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrAliasAlreadyExists) {
+		c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
+			Message: "Алиас уже существует",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+		Message: message,
+		Error:   err.Error(),
+	})
 }
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"expvar"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves the expvar metrics, such as the state of the
+// url-shortener circuit breaker, at /debug/vars. It is registered behind the
+// API tokens, as the metrics are not meant for anonymous callers.
+type MetricsHandler struct{}
+
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{}
+}
+
+func (h *MetricsHandler) Register(router gin.IRouter) {
+	router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
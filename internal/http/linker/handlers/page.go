@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+
+	ascOrder  = "asc"
+	descOrder = "desc"
+)
+
+// newPage builds the page of a listing request. The sort key is checked by
+// the storage, which knows the keys of each listing.
+func newPage(limit int, cursor, sort, order string) (models.Page, error) {
+	if limit <= 0 || limit > maxPageSize {
+		limit = defaultPageSize
+	}
+
+	if order != "" && order != ascOrder && order != descOrder {
+		return models.Page{}, storage.ErrInvalidPage
+	}
+
+	return models.Page{
+		Limit:  limit,
+		Cursor: cursor,
+		SortBy: models.SortKey(sort),
+		Desc:   order == descOrder,
+	}, nil
+}
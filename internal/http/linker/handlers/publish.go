@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/publish"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// PublicTopicPath is the route of the pages of published topics.
+const PublicTopicPath = "/p/"
+
+type PublishService interface {
+	Publish(ctx context.Context, username, topic string) (slug string, err error)
+	Unpublish(ctx context.Context, username, topic string) (err error)
+	ListPublished(ctx context.Context, username string) (topics []models.PublishedTopic, err error)
+	Page(ctx context.Context, slug string) (page publish.Page, err error)
+}
+
+// PublishHandler publishes the topics of the user of the request.
+type PublishHandler struct {
+	log            *slog.Logger
+	cfg            *config.PublishConfig
+	publishService PublishService
+}
+
+func NewPublishHandler(log *slog.Logger, cfg *config.PublishConfig, publishService PublishService) *PublishHandler {
+	return &PublishHandler{
+		log:            log,
+		cfg:            cfg,
+		publishService: publishService,
+	}
+}
+
+func (h *PublishHandler) Register(router gin.IRouter) {
+	router.POST("/topics/publish", h.publishTopic)
+	router.DELETE("/topics/publish", h.unpublishTopic)
+	router.GET("/topics/published", h.listPublishedTopics)
+}
+
+func (h *PublishHandler) publishTopic(c *gin.Context) {
+	var req models.PublishTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	slug, err := h.publishService.Publish(c, username(c), req.Topic)
+	if err != nil {
+		abortWithPublishError(c, h.log, err, "Не удалось опубликовать топик")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PublishTopicResponse{
+		Topic: req.Topic,
+		Slug:  slug,
+		URL:   publicURL(h.cfg, PublicTopicPath+slug),
+	})
+}
+
+func (h *PublishHandler) unpublishTopic(c *gin.Context) {
+	var req models.UnpublishTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.publishService.Unpublish(c, username(c), req.Topic); err != nil {
+		abortWithPublishError(c, h.log, err, "Не удалось снять топик с публикации")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UnpublishTopicResponse{Topic: req.Topic})
+}
+
+func (h *PublishHandler) listPublishedTopics(c *gin.Context) {
+	topics, err := h.publishService.ListPublished(c, username(c))
+	if err != nil {
+		abortWithPublishError(c, h.log, err, "Не удалось получить список опубликованных топиков")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListPublishedTopicsResponse{Topics: topics})
+}
+
+// PublicTopicHandler serves published topics to anyone who knows the slug:
+// an HTML page at /p/<slug> and its Atom and RSS feeds at /p/<slug>/atom and
+// /p/<slug>/rss.
+type PublicTopicHandler struct {
+	log            *slog.Logger
+	cfg            *config.PublishConfig
+	publishService PublishService
+}
+
+func NewPublicTopicHandler(log *slog.Logger, cfg *config.PublishConfig, publishService PublishService) *PublicTopicHandler {
+	return &PublicTopicHandler{
+		log:            log,
+		cfg:            cfg,
+		publishService: publishService,
+	}
+}
+
+func (h *PublicTopicHandler) Register(router gin.IRouter) {
+	router.GET(PublicTopicPath+":slug", h.servePage(publish.WriteHTML, "text/html; charset=utf-8"))
+	router.GET(PublicTopicPath+":slug"+publish.AtomPath, h.servePage(publish.WriteAtom, "application/atom+xml; charset=utf-8"))
+	router.GET(PublicTopicPath+":slug"+publish.RSSPath, h.servePage(publish.WriteRSS, "application/rss+xml; charset=utf-8"))
+}
+
+// servePage renders the published topic with write, the page is rendered
+// into a buffer first so that a failure still gets an error response.
+func (h *PublicTopicHandler) servePage(write func(io.Writer, publish.Page, string) error, contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := c.Param("slug")
+
+		page, err := h.publishService.Page(c, slug)
+		if err != nil {
+			abortWithPublishError(c, h.log, err, "Не удалось получить топик")
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := write(&buf, page, publicURL(h.cfg, PublicTopicPath+slug)); err != nil {
+			h.log.Error("failed to render published topic", slog.String("slug", slug), slog.String("err", err.Error()))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+				Message: "Не удалось получить топик",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// publicURL makes path absolute with the configured public address.
+func publicURL(cfg *config.PublishConfig, path string) string {
+	return strings.TrimSuffix(cfg.BaseURL, "/") + path
+}
+
+// abortWithPublishError answers with the status of a storage error of a
+// publishing operation, message describes any other failure, which is logged.
+func abortWithPublishError(c *gin.Context, log *slog.Logger, err error, message string) {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Пользователь не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrTopicNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrTopicNotPublished) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не опубликован",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+			Message: "Опубликовать можно только свой топик",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Error("failed to handle publish request", slog.String("path", c.Request.URL.Path), slog.String("err", err.Error()))
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+		Message: message,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/config"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type RedirectService interface {
+	PickLink(ctx context.Context, username, topic, alias string) (link models.Link, err error)
+	PickLinkByCode(ctx context.Context, username, code string) (link models.Link, err error)
+}
+
+// RedirectHandler sends browsers to saved links, so aliases work as short
+// links: /r/<username>/<topic>/<alias> and /s/<username>/<code>, where code is
+// the random one returned with the link. With RequireAuth the handler is
+// served behind the bearer token auth and users follow their own links only.
+type RedirectHandler struct {
+	log             *slog.Logger
+	redirectService RedirectService
+	cfg             *config.RedirectConfig
+}
+
+func NewRedirectHandler(log *slog.Logger, cfg *config.RedirectConfig, redirectService RedirectService) *RedirectHandler {
+	return &RedirectHandler{
+		log:             log,
+		redirectService: redirectService,
+		cfg:             cfg,
+	}
+}
+
+func (h *RedirectHandler) Register(router gin.IRouter) {
+	if h.cfg.RequireAuth {
+		router = router.Group("", ownLinksOnly)
+	}
+
+	router.GET("/r/:username/*path", h.redirectAlias)
+	router.GET("/s/:username/:code", h.redirectCode)
+}
+
+// ownLinksOnly lets authenticated users follow their own links only.
+func ownLinksOnly(c *gin.Context) {
+	if username(c) != c.Param("username") {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+			Message: "Нет доступа к ссылкам другого пользователя",
+			Error:   "forbidden",
+		})
+		return
+	}
+
+	c.Next()
+}
+
+func (h *RedirectHandler) redirectAlias(c *gin.Context) {
+	// The topic is a path itself, the alias is the last segment.
+	path := strings.Trim(c.Param("path"), "/")
+
+	idx := strings.LastIndex(path, storage.TopicSeparator)
+	if idx < 0 {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Ссылка не найдена",
+			Error:   storage.ErrAliasNotFound.Error(),
+		})
+		return
+	}
+
+	link, err := h.redirectService.PickLink(c, c.Param("username"), path[:idx], path[idx+1:])
+	if err != nil {
+		h.abortWithRedirectError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, link.URL)
+}
+
+func (h *RedirectHandler) redirectCode(c *gin.Context) {
+	link, err := h.redirectService.PickLinkByCode(c, c.Param("username"), c.Param("code"))
+	if err != nil {
+		h.abortWithRedirectError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, link.URL)
+}
+
+func (h *RedirectHandler) abortWithRedirectError(c *gin.Context, err error) {
+	// Links the user can't read and malformed paths are not found either, the
+	// response doesn't tell them from the missing ones.
+	if errors.Is(err, storage.ErrUserNotFound) ||
+		errors.Is(err, storage.ErrTopicNotFound) ||
+		errors.Is(err, storage.ErrAliasNotFound) ||
+		errors.Is(err, storage.ErrPermissionDenied) ||
+		errors.Is(err, storage.ErrInvalidTopicPath) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Ссылка не найдена",
+			Error:   storage.ErrAliasNotFound.Error(),
+		})
+		return
+	}
+
+	h.log.Error("failed to redirect", slog.String("path", c.Request.URL.Path), slog.String("err", err.Error()))
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+		Message: "Не удалось получить ссылку",
+		Error:   "internal error",
+	})
+}
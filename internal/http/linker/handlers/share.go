@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type ShareService interface {
+	ShareTopic(ctx context.Context, owner, topic, username string, role models.ShareRole) (err error)
+	UnshareTopic(ctx context.Context, owner, topic, username string) (err error)
+	ListTopicShares(ctx context.Context, owner, topic string) (shares []models.TopicShare, err error)
+	ListSharedTopics(ctx context.Context, username string) (topics []models.SharedTopic, err error)
+}
+
+// ShareHandler shares the topics of the user of the request with other users
+// and lists the topics other users shared with them.
+type ShareHandler struct {
+	shareService ShareService
+}
+
+func NewShareHandler(log *slog.Logger, shareService ShareService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+	}
+}
+
+func (h *ShareHandler) Register(router gin.IRouter) {
+	router.POST("/topics/shares", h.shareTopic)
+	router.DELETE("/topics/shares", h.unshareTopic)
+	router.GET("/topics/shares", h.listTopicShares)
+	router.GET("/topics/shared", h.listSharedTopics)
+}
+
+func (h *ShareHandler) shareTopic(c *gin.Context) {
+	var req models.ShareTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.shareService.ShareTopic(c, username(c), req.Topic, req.Username, req.Role); err != nil {
+		h.abortWithShareError(c, err, "Не удалось поделиться топиком")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ShareTopicResponse{Topic: req.Topic, Username: req.Username, Role: req.Role})
+}
+
+func (h *ShareHandler) unshareTopic(c *gin.Context) {
+	var req models.UnshareTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.shareService.UnshareTopic(c, username(c), req.Topic, req.Username); err != nil {
+		h.abortWithShareError(c, err, "Не удалось закрыть доступ к топику")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UnshareTopicResponse{Topic: req.Topic, Username: req.Username})
+}
+
+func (h *ShareHandler) listTopicShares(c *gin.Context) {
+	var req models.ListTopicSharesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	shares, err := h.shareService.ListTopicShares(c, username(c), req.Topic)
+	if err != nil {
+		h.abortWithShareError(c, err, "Не удалось получить список доступов")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListTopicSharesResponse{Shares: shares})
+}
+
+func (h *ShareHandler) listSharedTopics(c *gin.Context) {
+	topics, err := h.shareService.ListSharedTopics(c, username(c))
+	if err != nil {
+		h.abortWithShareError(c, err, "Не удалось получить список топиков")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListSharedTopicsResponse{Topics: topics})
+}
+
+// abortWithShareError answers with the status of a storage error of a share
+// operation, message describes any other failure.
+func (h *ShareHandler) abortWithShareError(c *gin.Context, err error, message string) {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Пользователь не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrTopicNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не найден",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrShareNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не открыт этому пользователю",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+			Message: "Поделиться можно только своим топиком",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrInvalidShareRole) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверная роль, допустимы viewer и editor",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, storage.ErrShareWithOwner) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Нельзя поделиться топиком с самим собой",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+		Message: message,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	builtinShortener "github.com/Sleeps17/linker/internal/clients/url-shortener/builtin"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type ShortURLService interface {
+	ShortURL(ctx context.Context, code string) (url string, err error)
+}
+
+// ShortURLHandler serves the short URLs of the built-in shortener from
+// /u/<code>, they are public like those of any shortener.
+type ShortURLHandler struct {
+	log             *slog.Logger
+	shortURLService ShortURLService
+}
+
+func NewShortURLHandler(log *slog.Logger, shortURLService ShortURLService) *ShortURLHandler {
+	return &ShortURLHandler{
+		log:             log,
+		shortURLService: shortURLService,
+	}
+}
+
+func (h *ShortURLHandler) Register(router gin.IRouter) {
+	router.GET(builtinShortener.RedirectPath+":code", h.redirectShortURL)
+}
+
+func (h *ShortURLHandler) redirectShortURL(c *gin.Context) {
+	url, err := h.shortURLService.ShortURL(c, c.Param("code"))
+	if err != nil {
+		if errors.Is(err, storage.ErrShortCodeNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Ссылка не найдена",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		h.log.Error("failed to redirect short url", slog.String("err", err.Error()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось получить ссылку",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+type TagService interface {
+	TagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	UntagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	ListLinksByTag(ctx context.Context, username, tag string) (links []models.Link, err error)
+}
+
+type TagHandler struct {
+	tagService TagService
+}
+
+func NewTagHandler(log *slog.Logger, tagService TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+func (h *TagHandler) Register(router gin.IRouter) {
+	router.POST("/tags", h.tagLink)
+	router.DELETE("/tags", h.untagLink)
+	router.GET("/tags/links", h.listLinksByTag)
+}
+
+func (h *TagHandler) tagLink(c *gin.Context) {
+	var req models.TagLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !isValidTag(req.Tag) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Тег не может быть пустым или содержать пробелы",
+			Error:   "invalid tag",
+		})
+		return
+	}
+
+	if err := h.tagService.TagLink(c, username(c), req.Topic, req.Alias, req.Tag); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Топик не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrAliasNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Алиас не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось добавить тег",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TagLinkResponse{Alias: req.Alias, Tag: req.Tag})
+}
+
+func (h *TagHandler) untagLink(c *gin.Context) {
+	var req models.UntagLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.tagService.UntagLink(c, username(c), req.Topic, req.Alias, req.Tag); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Топик не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrAliasNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Алиас не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrTagNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Тег не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось удалить тег",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UntagLinkResponse{Alias: req.Alias, Tag: req.Tag})
+}
+
+func (h *TagHandler) listLinksByTag(c *gin.Context) {
+	var req models.ListLinksByTagRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	links, err := h.tagService.ListLinksByTag(c, username(c), req.Tag)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось получить список ссылок",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListLinksResponse{Links: links})
+}
+
+func isValidTag(tag string) bool {
+	return tag != "" && strings.IndexFunc(tag, unicode.IsSpace) == -1
+}
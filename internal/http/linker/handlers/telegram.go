@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type TelegramService interface {
+	IssueLinkCode(ctx context.Context, username string) (code string, expiresAt time.Time, err error)
+}
+
+// TelegramHandler issues one-time codes that bind a Telegram account to the
+// user of the request, the code is sent to the bot with /link_account.
+type TelegramHandler struct {
+	telegramService TelegramService
+}
+
+func NewTelegramHandler(log *slog.Logger, telegramService TelegramService) *TelegramHandler {
+	return &TelegramHandler{
+		telegramService: telegramService,
+	}
+}
+
+func (h *TelegramHandler) Register(router gin.IRouter) {
+	router.POST("/telegram/code", h.issueLinkCode)
+}
+
+func (h *TelegramHandler) issueLinkCode(c *gin.Context) {
+	code, expiresAt, err := h.telegramService.IssueLinkCode(c, username(c))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось создать код привязки",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LinkCodeResponse{Code: code, ExpiresAt: expiresAt})
+}
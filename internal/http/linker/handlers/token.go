@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type TokenService interface {
+	IssueToken(ctx context.Context, username, name string) (token string, info models.APIToken, err error)
+	ListTokens(ctx context.Context, username string) (tokens []models.APIToken, err error)
+	RevokeToken(ctx context.Context, username string, tokenID uint32) (err error)
+}
+
+// TokenHandler manages the API tokens of the user the request is made by.
+type TokenHandler struct {
+	tokenService TokenService
+}
+
+func NewTokenHandler(log *slog.Logger, tokenService TokenService) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+func (h *TokenHandler) Register(router gin.IRouter) {
+	router.POST("/tokens", h.createToken)
+	router.GET("/tokens", h.listTokens)
+	router.DELETE("/tokens/:id", h.revokeToken)
+}
+
+func (h *TokenHandler) createToken(c *gin.Context) {
+	var req models.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, info, err := h.tokenService.IssueToken(c, username(c), req.Name)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось создать токен",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CreateTokenResponse{Token: token, Info: info})
+}
+
+func (h *TokenHandler) listTokens(c *gin.Context) {
+	tokens, err := h.tokenService.ListTokens(c, username(c))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось получить список токенов",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListTokensResponse{Tokens: tokens})
+}
+
+func (h *TokenHandler) revokeToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный идентификатор токена",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(c, username(c), uint32(id)); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Токен не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось отозвать токен",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RevokeTokenResponse{TokenID: uint32(id)})
+}
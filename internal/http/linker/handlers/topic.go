@@ -13,7 +13,8 @@ import (
 type TopicService interface {
 	PostTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
 	DeleteTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
-	ListTopics(ctx context.Context, username string) (topics []string, err error)
+	ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) (topics []string, next string, err error)
+	RenameTopic(ctx context.Context, username, topic, newTopic string) (err error)
 }
 
 type TopicHandler struct {
@@ -26,10 +27,12 @@ func NewTopicHandler(log *slog.Logger, topicService TopicService) *TopicHandler
 	}
 }
 
-func (h *TopicHandler) Register(router *gin.Engine) {
+func (h *TopicHandler) Register(router gin.IRouter) {
 	router.POST("/topics", h.postTopic)
 	router.DELETE("/topics", h.deleteTopic)
 	router.GET("/topics", h.listTopics)
+	router.PATCH("/topics", h.renameTopic)
+	router.PATCH("/topics/move", h.moveTopic)
 }
 
 func (h *TopicHandler) postTopic(c *gin.Context) {
@@ -42,8 +45,16 @@ func (h *TopicHandler) postTopic(c *gin.Context) {
 		return
 	}
 
-	id, err := h.topicService.PostTopic(c, req.Username, req.Topic)
+	id, err := h.topicService.PostTopic(c, username(c), req.Topic)
 	if err != nil {
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверный путь топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrTopicAlreadyExists) {
 			c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
 				Message: "Топик с таким названием уже существует",
@@ -72,7 +83,7 @@ func (h *TopicHandler) deleteTopic(c *gin.Context) {
 		return
 	}
 
-	id, err := h.topicService.DeleteTopic(c, req.Username, req.Topic)
+	id, err := h.topicService.DeleteTopic(c, username(c), req.Topic)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
@@ -87,12 +98,22 @@ func (h *TopicHandler) deleteTopic(c *gin.Context) {
 				Message: "Топик не найден",
 				Error:   err.Error(),
 			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
 		}
 
 		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
 			Message: "Не удалось удалить топик",
 			Error:   err.Error(),
 		})
+		return
 	}
 
 	c.JSON(http.StatusOK, models.DeleteTopicResponse{TopicID: id})
@@ -100,7 +121,7 @@ func (h *TopicHandler) deleteTopic(c *gin.Context) {
 
 func (h *TopicHandler) listTopics(c *gin.Context) {
 	var req models.ListTopicsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindQuery(&req); err != nil {
 		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
 			Message: "Неверный формат запроса",
 			Error:   err.Error(),
@@ -108,8 +129,34 @@ func (h *TopicHandler) listTopics(c *gin.Context) {
 		return
 	}
 
-	topics, err := h.topicService.ListTopics(c, req.Username)
+	page, err := newPage(req.Limit, req.Cursor, req.Sort, req.Order)
 	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверные параметры страницы",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	filter := models.TopicsFilter{Query: req.Query, Direct: req.Direct}
+	if filter.Parent, err = storage.CleanTopicParent(req.Parent); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный путь топика",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	topics, next, err := h.topicService.ListTopics(c, username(c), page, filter)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidPage) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверные параметры страницы",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, storage.ErrUserNotFound) {
 			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
 				Message: "Пользователь не найден",
@@ -118,6 +165,22 @@ func (h *TopicHandler) listTopics(c *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, storage.ErrTopicNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Топик не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrPermissionDenied) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+				Message: "Недостаточно прав для топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
 		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
 			Message: "Не удалось получить список топиков",
 			Error:   err.Error(),
@@ -125,5 +188,112 @@ func (h *TopicHandler) listTopics(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.ListTopicsResponse{Topics: topics})
+	c.JSON(http.StatusOK, models.ListTopicsResponse{Topics: topics, NextCursor: next})
+}
+
+func (h *TopicHandler) renameTopic(c *gin.Context) {
+	var req models.RenameTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if req.NewTopic == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Новое название топика не может быть пустым",
+			Error:   "empty new topic",
+		})
+		return
+	}
+
+	if !h.relocateTopic(c, username(c), req.Topic, req.NewTopic) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RenameTopicResponse{Topic: req.NewTopic})
+}
+
+func (h *TopicHandler) moveTopic(c *gin.Context) {
+	var req models.MoveTopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	parent, err := storage.CleanTopicParent(req.ToParent)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный путь топика",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	topic := storage.MoveTopicPath(req.Topic, parent)
+	if !h.relocateTopic(c, username(c), req.Topic, topic) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MoveTopicResponse{Topic: topic})
+}
+
+// relocateTopic renames topic together with its subtopics, reporting whether
+// it succeeded. On failure the response is already written.
+func (h *TopicHandler) relocateTopic(c *gin.Context, username, topic, newTopic string) bool {
+	err := h.topicService.RenameTopic(c, username, topic, newTopic)
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, storage.ErrUserNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Пользователь не найден",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if errors.Is(err, storage.ErrTopicNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+			Message: "Топик не найден",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if errors.Is(err, storage.ErrPermissionDenied) {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ApiError{
+			Message: "Недостаточно прав для топика",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if errors.Is(err, storage.ErrTopicAlreadyExists) {
+		c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
+			Message: "Топик с таким названием уже существует",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	if errors.Is(err, storage.ErrInvalidTopicPath) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный путь топика, топик нельзя переместить внутрь самого себя",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+		Message: "Не удалось переместить топик",
+		Error:   err.Error(),
+	})
+	return false
 }
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+)
+
+type TrashService interface {
+	ListTrash(ctx context.Context, username string) (items []models.TrashItem, err error)
+	RestoreTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
+	RestoreLink(ctx context.Context, username, topic, alias string) (err error)
+}
+
+type TrashHandler struct {
+	trashService TrashService
+}
+
+func NewTrashHandler(log *slog.Logger, trashService TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+func (h *TrashHandler) Register(router gin.IRouter) {
+	router.GET("/trash", h.listTrash)
+	router.POST("/trash/restore", h.restore)
+}
+
+func (h *TrashHandler) listTrash(c *gin.Context) {
+	items, err := h.trashService.ListTrash(c, username(c))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось получить содержимое корзины",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListTrashResponse{Items: items})
+}
+
+func (h *TrashHandler) restore(c *gin.Context) {
+	var req models.RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+			Message: "Неверный формат запроса",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var err error
+	if req.Alias == "" {
+		_, err = h.trashService.RestoreTopic(c, username(c), req.Topic)
+	} else {
+		err = h.trashService.RestoreLink(c, username(c), req.Topic, req.Alias)
+	}
+
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "Пользователь не найден",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrNotInTrash) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ApiError{
+				Message: "В корзине нет такого топика или ссылки",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrInvalidTopicPath) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ApiError{
+				Message: "Неверный путь топика",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrTopicAlreadyExists) {
+			c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
+				Message: "Топик с таким названием уже существует",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, storage.ErrAliasAlreadyExists) {
+			c.AbortWithStatusJSON(http.StatusConflict, models.ApiError{
+				Message: "Ссылка с таким алиасом уже существует",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ApiError{
+			Message: "Не удалось восстановить из корзины",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RestoreResponse{Topic: req.Topic, Alias: req.Alias})
+}
@@ -13,13 +13,20 @@ type Server struct {
 	router *gin.Engine
 }
 
-func NewServer(cfg *config.ServerConfig, handlers ...handlers.Handler) *Server {
+// NewServer serves the public handlers as is and the api handlers only to
+// requests with a valid bearer token.
+func NewServer(cfg *config.ServerConfig, authenticator Authenticator, public []handlers.Handler, api ...handlers.Handler) *Server {
 	g := gin.Default()
 
-	for _, handler := range handlers {
+	for _, handler := range public {
 		handler.Register(g)
 	}
 
+	authorized := g.Group("", bearerAuth(authenticator))
+	for _, handler := range api {
+		handler.Register(authorized)
+	}
+
 	srv := &http.Server{
 		Handler:      g,
 		Addr:         cfg.Port,
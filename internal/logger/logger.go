@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -13,30 +14,35 @@ const (
 )
 
 func Setup(env string) *slog.Logger {
+	return SetupTo(env, os.Stdout)
+}
+
+// SetupTo is Setup writing to w, so that commands printing their result to stdout can keep the logs apart.
+func SetupTo(env string, w io.Writer) *slog.Logger {
 	var log *slog.Logger
 
 	switch env {
 	case localEnv:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			slog.NewTextHandler(w, &slog.HandlerOptions{
 				Level: slog.LevelDebug,
 			}),
 		)
 	case devEnv:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
 	case prodEnv:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}),
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelWarn}),
 		)
 	case testEnv:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}),
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelError}),
 		)
 	default:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}),
+			slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelWarn}),
 		)
 	}
 
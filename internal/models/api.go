@@ -1,13 +1,14 @@
 package models
 
+import "time"
+
 type ApiError struct {
 	Message string `json:"message"`
 	Error   string `json:"error"`
 }
 
 type PostTopicRequest struct {
-	Username string `json:"username"`
-	Topic    string `json:"topic"`
+	Topic string `json:"topic"`
 }
 
 type PostTopicResponse struct {
@@ -15,8 +16,7 @@ type PostTopicResponse struct {
 }
 
 type DeleteTopicRequest struct {
-	Username string `json:"username"`
-	Topic    string `json:"topic"`
+	Topic string `json:"topic"`
 }
 
 type DeleteTopicResponse struct {
@@ -24,18 +24,45 @@ type DeleteTopicResponse struct {
 }
 
 type ListTopicsRequest struct {
-	Username string `json:"username"`
+	Parent string `form:"parent"`
+	Direct bool   `form:"direct"`
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order"`
+	Query  string `form:"query"`
 }
 
 type ListTopicsResponse struct {
-	Topics []string `json:"topics"`
+	Topics     []string `json:"topics"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
 
-type PostLinkRequest struct {
-	Username string `json:"username"`
+type RenameTopicRequest struct {
 	Topic    string `json:"topic"`
-	Link     string `json:"link"`
-	Alias    string `json:"alias"`
+	NewTopic string `json:"new_topic"`
+}
+
+type RenameTopicResponse struct {
+	Topic string `json:"topic"`
+}
+
+type MoveTopicRequest struct {
+	Topic    string `json:"topic"`
+	ToParent string `json:"to_parent"`
+}
+
+type MoveTopicResponse struct {
+	Topic string `json:"topic"`
+}
+
+type PostLinkRequest struct {
+	Topic       string `json:"topic"`
+	Link        string `json:"link"`
+	Alias       string `json:"alias"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Note        string `json:"note"`
 }
 
 type PostLinkResponse struct {
@@ -43,19 +70,21 @@ type PostLinkResponse struct {
 }
 
 type PickLinkRequest struct {
-	Username string `form:"username"`
-	Topic    string `form:"topic"`
-	Alias    string `form:"alias"`
+	Topic string `form:"topic"`
+	Alias string `form:"alias"`
 }
 
+// PickLinkResponse returns the link with the short code it is redirected to
+// from by /s/<username>/<code>, and its short URL once it is shortened.
 type PickLinkResponse struct {
-	Link string `json:"link"`
+	Link     string `json:"link"`
+	ShortURL string `json:"short_url"`
+	Code     string `json:"code"`
 }
 
 type DeleteLinkRequest struct {
-	Username string `json:"username"`
-	Topic    string `json:"topic"`
-	Alias    string `json:"alias"`
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
 }
 
 type DeleteLinkResponse struct {
@@ -63,11 +92,202 @@ type DeleteLinkResponse struct {
 }
 
 type ListLinksRequest struct {
-	Username string `form:"username"`
-	Topic    string `form:"topic"`
+	Topic     string `form:"topic"`
+	Limit     int    `form:"limit"`
+	Cursor    string `form:"cursor"`
+	Sort      string `form:"sort"`
+	Order     string `form:"order"`
+	Query     string `form:"query"`
+	Tag       string `form:"tag"`
+	Recursive bool   `form:"recursive"`
 }
 
 type ListLinksResponse struct {
-	Links   []string `json:"links"`
-	Aliases []string `json:"aliases"`
+	Links      []Link `json:"links"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type UpdateLinkRequest struct {
+	Topic       string  `json:"topic"`
+	Alias       string  `json:"alias"`
+	Link        *string `json:"link"`
+	NewAlias    *string `json:"new_alias"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Note        *string `json:"note"`
+}
+
+type UpdateLinkResponse struct {
+	Link Link `json:"link"`
+}
+
+type MoveLinkRequest struct {
+	Topic   string `json:"topic"`
+	Alias   string `json:"alias"`
+	ToTopic string `json:"to_topic"`
+}
+
+type MoveLinkResponse struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
+}
+
+type CopyLinkRequest struct {
+	Topic   string `json:"topic"`
+	Alias   string `json:"alias"`
+	ToTopic string `json:"to_topic"`
+}
+
+type CopyLinkResponse struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
+}
+
+type TagLinkRequest struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+type TagLinkResponse struct {
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+type UntagLinkRequest struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+type UntagLinkResponse struct {
+	Alias string `json:"alias"`
+	Tag   string `json:"tag"`
+}
+
+type ListLinksByTagRequest struct {
+	Tag string `form:"tag"`
+}
+
+type SearchLinksRequest struct {
+	Query string `form:"query"`
+	Limit int    `form:"limit"`
+}
+
+type ListTrashResponse struct {
+	Items []TrashItem `json:"items"`
+}
+
+// RestoreRequest restores a deleted link, or a deleted topic when Alias is
+// empty.
+type RestoreRequest struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias"`
+}
+
+type RestoreResponse struct {
+	Topic string `json:"topic"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ImportBookmarksRequest is the form of a bookmark file upload, the file itself
+// is sent in the "file" field. Topic is the parent of the imported folders.
+type ImportBookmarksRequest struct {
+	Topic string `form:"topic"`
+}
+
+// ImportAccountRequest is the form of an account export upload, the file
+// itself is sent in the "file" field.
+type ImportAccountRequest struct {
+	Format string `form:"format"`
+	Policy string `form:"policy"`
+}
+
+type ExportAccountRequest struct {
+	Format string `form:"format"`
+}
+
+type CreateTokenRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateTokenResponse is the only place where the token itself is shown.
+type CreateTokenResponse struct {
+	Token string   `json:"token"`
+	Info  APIToken `json:"info"`
+}
+
+type ListTokensResponse struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+type RevokeTokenResponse struct {
+	TokenID uint32 `json:"token_id"`
+}
+
+// LinkCodeResponse carries a one-time code for the /link_account command of
+// the bot.
+type LinkCodeResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ShareTopicRequest struct {
+	Topic    string    `json:"topic"`
+	Username string    `json:"username"`
+	Role     ShareRole `json:"role"`
+}
+
+type ShareTopicResponse struct {
+	Topic    string    `json:"topic"`
+	Username string    `json:"username"`
+	Role     ShareRole `json:"role"`
+}
+
+type UnshareTopicRequest struct {
+	Topic    string `json:"topic"`
+	Username string `json:"username"`
+}
+
+type UnshareTopicResponse struct {
+	Topic    string `json:"topic"`
+	Username string `json:"username"`
+}
+
+type ListTopicSharesRequest struct {
+	Topic string `form:"topic"`
+}
+
+type ListTopicSharesResponse struct {
+	Shares []TopicShare `json:"shares"`
+}
+
+// ListSharedTopicsResponse lists the topics of other users shared with the
+// user of the request, their links are addressed by the Path of the topic.
+type ListSharedTopicsResponse struct {
+	Topics []SharedTopic `json:"topics"`
+}
+
+type PublishTopicRequest struct {
+	Topic string `json:"topic"`
+}
+
+// PublishTopicResponse carries the slug of the topic and the address of its
+// page, the feeds are at URL/atom and URL/rss.
+type PublishTopicResponse struct {
+	Topic string `json:"topic"`
+	Slug  string `json:"slug"`
+	URL   string `json:"url"`
+}
+
+type UnpublishTopicRequest struct {
+	Topic string `json:"topic"`
+}
+
+type UnpublishTopicResponse struct {
+	Topic string `json:"topic"`
+}
+
+type ListPublishedTopicsResponse struct {
+	Topics []PublishedTopic `json:"topics"`
 }
@@ -1,7 +1,17 @@
 package models
 
 type CmdArgs struct {
-	Topic string
-	Link  string
-	Alias string
+	Topic       string
+	Link        string
+	Alias       string
+	Tag         string
+	To          string
+	NewAlias    string
+	Title       string
+	Description string
+	Note        string
+	Code        string
+	User        string
+	Role        string
+	Recursive   bool
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Link is a saved link together with the context the user attached to it.
+// URL is the link as the user saved it, ShortURL its short URL once the
+// shortener has made one and ShortCode the code the shortener knows it by.
+// Code is the random code the link is redirected to from by
+// /s/<username>/<code>.
+type Link struct {
+	ID          uint32    `json:"id"`
+	URL         string    `json:"url"`
+	ShortURL    string    `json:"short_url"`
+	ShortCode   string    `json:"-"`
+	Code        string    `json:"code"`
+	Alias       string    `json:"alias"`
+	Topic       string    `json:"topic"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Note        string    `json:"note"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LinkUpdate lists the fields of a link to change, nil fields are kept.
+type LinkUpdate struct {
+	URL         *string
+	Alias       *string
+	Title       *string
+	Description *string
+	Note        *string
+}
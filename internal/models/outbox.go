@@ -0,0 +1,23 @@
+package models
+
+// ShortenerTaskKind says what a queued shortener task does.
+type ShortenerTaskKind string
+
+const (
+	ShortenerSave   ShortenerTaskKind = "save"
+	ShortenerDelete ShortenerTaskKind = "delete"
+)
+
+// ShortenerTask is a change the URL shortener has to follow, queued in the
+// outbox together with the link change. A save task shortens URL under Alias
+// and stores the short URL next to link LinkID, whose URL is kept as is, a
+// delete task deletes the short URL with code Alias. Attempts counts the tries
+// so far, including the current one.
+type ShortenerTask struct {
+	ID       uint32
+	Kind     ShortenerTaskKind
+	LinkID   uint32
+	URL      string
+	Alias    string
+	Attempts int
+}
@@ -0,0 +1,39 @@
+package models
+
+// SortKey names the field a listing is ordered by.
+type SortKey string
+
+const (
+	SortByCreatedAt SortKey = "created_at"
+	SortByAlias     SortKey = "alias"
+	SortByURL       SortKey = "url"
+	SortByName      SortKey = "name"
+)
+
+// Page selects one page of a listing. Cursor is the next cursor returned with
+// the previous page and is only valid with the same SortBy and Desc. A zero
+// Limit returns the whole listing.
+type Page struct {
+	Limit  int
+	Cursor string
+	SortBy SortKey
+	Desc   bool
+}
+
+// LinksFilter narrows a link listing. Query matches a substring of the alias,
+// URL or title, Tag keeps links carrying the tag. Recursive adds the links of
+// all subtopics of the listed topic.
+type LinksFilter struct {
+	Query     string
+	Tag       string
+	Recursive bool
+}
+
+// TopicsFilter narrows a topic listing to paths containing Query. Parent
+// keeps the subtopics of a topic, Direct only its children; with an empty
+// Parent Direct keeps the top-level topics.
+type TopicsFilter struct {
+	Query  string
+	Parent string
+	Direct bool
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PublishedTopic is a topic of Owner readable by anyone at its Slug.
+type PublishedTopic struct {
+	Owner       string    `json:"owner"`
+	Topic       string    `json:"topic"`
+	Slug        string    `json:"slug"`
+	PublishedAt time.Time `json:"published_at"`
+}
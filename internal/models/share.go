@@ -0,0 +1,25 @@
+package models
+
+// ShareRole is the access a user has to a topic of another user: viewers
+// read the links, editors also post, update and delete them.
+type ShareRole string
+
+const (
+	ShareViewer ShareRole = "viewer"
+	ShareEditor ShareRole = "editor"
+)
+
+// TopicShare is a user a topic is shared with.
+type TopicShare struct {
+	Username string    `json:"username"`
+	Role     ShareRole `json:"role"`
+}
+
+// SharedTopic is a topic of Owner shared with the user, Path addresses it in
+// the link methods.
+type SharedTopic struct {
+	Owner string    `json:"owner"`
+	Topic string    `json:"topic"`
+	Path  string    `json:"path"`
+	Role  ShareRole `json:"role"`
+}
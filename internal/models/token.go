@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// APIToken describes an API token of a user. The token itself is only known
+// to the user, Name tells the tokens apart.
+type APIToken struct {
+	ID        uint32    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// ImportResult counts what an import has changed. Updated links were
+// overwritten, skipped ones were already saved or kept on a conflict.
+type ImportResult struct {
+	Topics  int `json:"topics"`
+	Links   int `json:"links"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TrashItem is a deleted topic or link waiting in the trash to be restored or
+// purged. Alias is empty for topics. Subtopics and Links count what was
+// deleted together with the item.
+type TrashItem struct {
+	ID        uint32    `json:"id"`
+	Topic     string    `json:"topic"`
+	Alias     string    `json:"alias,omitempty"`
+	Subtopics int       `json:"subtopics"`
+	Links     int       `json:"links"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
@@ -0,0 +1,174 @@
+package publish
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"io"
+	"time"
+)
+
+// Feeds of a published topic have an entry per link, newest first. Links
+// are identified by their ids, so an edited link is not a new entry.
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Link      atomLink   `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary,omitempty"`
+	Category  []atomTerm `xml:"category"`
+}
+
+type atomTerm struct {
+	Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Category    []string `xml:"category"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// WriteAtom writes page as an Atom feed, pageURL is the public address of
+// the HTML page of the topic.
+func WriteAtom(w io.Writer, page Page, pageURL string) error {
+	feed := atomFeed{
+		ID:      feedID(page.Topic),
+		Title:   page.Topic.Topic,
+		Updated: page.updated().Format(time.RFC3339),
+		Author:  atomAuthor{Name: page.Topic.Owner},
+		Links: []atomLink{
+			{Href: pageURL, Rel: "alternate", Type: "text/html"},
+			{Href: pageURL + AtomPath, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+
+	for _, link := range page.Links {
+		entry := atomEntry{
+			ID:        entryID(page.Topic, link),
+			Title:     linkTitle(link),
+			Link:      atomLink{Href: link.URL},
+			Published: link.CreatedAt.Format(time.RFC3339),
+			Updated:   link.UpdatedAt.Format(time.RFC3339),
+			Summary:   link.Description,
+		}
+		for _, tag := range link.Tags {
+			entry.Category = append(entry.Category, atomTerm{Term: tag})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return writeXML(w, feed)
+}
+
+// WriteRSS writes page as an RSS 2.0 feed, pageURL is the public address of
+// the HTML page of the topic.
+func WriteRSS(w io.Writer, page Page, pageURL string) error {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         page.Topic.Topic,
+			Link:          pageURL,
+			Description:   fmt.Sprintf("Ссылки топика %s пользователя %s", page.Topic.Topic, page.Topic.Owner),
+			LastBuildDate: page.updated().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, link := range page.Links {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       linkTitle(link),
+			Link:        link.URL,
+			Description: link.Description,
+			GUID:        rssGUID{Value: entryID(page.Topic, link)},
+			PubDate:     link.CreatedAt.Format(time.RFC1123Z),
+			Category:    link.Tags,
+		})
+	}
+
+	return writeXML(w, feed)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// updated is the time of the latest change of the page.
+func (p Page) updated() time.Time {
+	updated := p.Topic.PublishedAt
+	for _, link := range p.Links {
+		if link.UpdatedAt.After(updated) {
+			updated = link.UpdatedAt
+		}
+	}
+
+	return updated
+}
+
+func feedID(topic models.PublishedTopic) string {
+	return "urn:linker:" + topic.Slug
+}
+
+func entryID(topic models.PublishedTopic, link models.Link) string {
+	return fmt.Sprintf("%s:%d", feedID(topic), link.ID)
+}
+
+// linkTitle falls back to the alias for links saved without a title.
+func linkTitle(link models.Link) string {
+	if link.Title != "" {
+		return link.Title
+	}
+
+	return link.Alias
+}
@@ -0,0 +1,57 @@
+package publish
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// Feeds are served next to the page of a topic.
+const (
+	AtomPath = "/atom"
+	RSSPath  = "/rss"
+)
+
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"title": linkTitle,
+	"date":  func(t time.Time) string { return t.Format(time.DateOnly) },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Page.Topic.Topic}}</title>
+<link rel="alternate" type="application/atom+xml" title="Atom" href="{{.AtomURL}}">
+<link rel="alternate" type="application/rss+xml" title="RSS" href="{{.RSSURL}}">
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+li { margin-bottom: 1rem; }
+.meta { color: #666; font-size: 0.875rem; }
+</style>
+</head>
+<body>
+<h1>{{.Page.Topic.Topic}}</h1>
+<p class="meta">{{.Page.Topic.Owner}} · <a href="{{.AtomURL}}">Atom</a> · <a href="{{.RSSURL}}">RSS</a></p>
+{{if .Page.Links}}<ul>
+{{range .Page.Links}}<li>
+<a href="{{.URL}}" rel="nofollow noopener">{{title .}}</a>
+{{if .Description}}<div>{{.Description}}</div>{{end}}
+<div class="meta">{{date .CreatedAt}}{{if ne .Topic $.Page.Topic.Topic}} · {{.Topic}}{{end}}{{range .Tags}} · #{{.}}{{end}}</div>
+</li>
+{{end}}</ul>
+{{else}}<p>В топике пока нет ссылок.</p>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML renders page as a read-only HTML page, pageURL is its public
+// address. Links go through html/template, so unsafe URLs are not rendered
+// as links.
+func WriteHTML(w io.Writer, page Page, pageURL string) error {
+	return pageTemplate.Execute(w, struct {
+		Page    Page
+		AtomURL string
+		RSSURL  string
+	}{page, pageURL + AtomPath, pageURL + RSSPath})
+}
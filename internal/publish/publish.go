@@ -0,0 +1,88 @@
+package publish
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+)
+
+const (
+	// Slugs are the only protection of a published topic, so they are long
+	// enough not to be guessed.
+	slugBytes = 16
+
+	// PageLinks is the number of the newest links shown on a page and in the
+	// feeds of a published topic.
+	PageLinks = 100
+)
+
+// Store is the part of the storage published topics are served from.
+type Store interface {
+	PublishTopic(ctx context.Context, username, topic, slug string) (publishedSlug string, err error)
+	UnpublishTopic(ctx context.Context, username, topic string) (err error)
+	ListPublishedTopics(ctx context.Context, username string) (topics []models.PublishedTopic, err error)
+	PublishedTopic(ctx context.Context, slug string) (topic models.PublishedTopic, err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
+}
+
+// Page is a published topic with its newest links, those of the subtopics
+// included.
+type Page struct {
+	Topic models.PublishedTopic
+	Links []models.Link
+}
+
+// Service publishes topics of users under random slugs and reads them back
+// for anyone who knows the slug.
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Publish makes topic of username public and returns its slug. A published
+// topic keeps its slug.
+func (s *Service) Publish(ctx context.Context, username, topic string) (string, error) {
+	const op = "publish.Publish"
+
+	secret := make([]byte, slugBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.store.PublishTopic(ctx, username, topic, base64.RawURLEncoding.EncodeToString(secret))
+}
+
+func (s *Service) Unpublish(ctx context.Context, username, topic string) error {
+	return s.store.UnpublishTopic(ctx, username, topic)
+}
+
+func (s *Service) ListPublished(ctx context.Context, username string) ([]models.PublishedTopic, error) {
+	return s.store.ListPublishedTopics(ctx, username)
+}
+
+// Page reads the published topic with the slug. It fails with
+// storage.ErrTopicNotPublished for unknown slugs.
+func (s *Service) Page(ctx context.Context, slug string) (Page, error) {
+	const op = "publish.Page"
+
+	topic, err := s.store.PublishedTopic(ctx, slug)
+	if err != nil {
+		return Page{}, err
+	}
+
+	links, _, err := s.store.ListLinks(
+		ctx, topic.Owner, topic.Topic,
+		models.Page{Limit: PageLinks, SortBy: models.SortByCreatedAt, Desc: true},
+		models.LinksFilter{Recursive: true},
+	)
+	if err != nil {
+		return Page{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Page{Topic: topic, Links: links}, nil
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const linkCodeBytes = 8
+
+// NewLinkCode returns a random code to address a link by in redirect URLs.
+// Codes are not derived from link ids, so the links of a user can't be
+// enumerated.
+func NewLinkCode() (string, error) {
+	code := make([]byte, linkCodeBytes)
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(code), nil
+}
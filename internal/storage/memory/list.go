@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"cmp"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+// position is the place of an item in a sorted listing, it orders the same
+// way as the keyset queries of the sql backends.
+type position struct {
+	time  time.Time
+	value string
+	id    uint32
+}
+
+func (p position) compare(other position) int {
+	if c := p.time.Compare(other.time); c != 0 {
+		return c
+	}
+
+	if c := strings.Compare(p.value, other.value); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(p.id, other.id)
+}
+
+func linkPosition(key models.SortKey, link models.Link) position {
+	if key == models.SortByCreatedAt {
+		return position{time: link.CreatedAt, id: link.ID}
+	}
+
+	return position{value: storage.LinkSortValue(key, link), id: link.ID}
+}
+
+func linkCursorPosition(cursor storage.Cursor) position {
+	if cursor.SortBy == models.SortByCreatedAt {
+		at, _ := cursor.Time()
+		return position{time: at, id: cursor.ID}
+	}
+
+	return position{value: cursor.Value, id: cursor.ID}
+}
+
+func topicPosition(key models.SortKey, t *topic) position {
+	if key == models.SortByName {
+		return position{value: t.topic, id: t.id}
+	}
+
+	return position{id: t.id}
+}
+
+// paginate sorts items, drops everything up to the cursor position and cuts
+// one page. next is empty on the last page.
+func paginate[T any](
+	items []T,
+	page models.Page,
+	after *position,
+	positionOf func(T) position,
+	cursorOf func(T) string,
+) (result []T, next string) {
+	direction := 1
+	if page.Desc {
+		direction = -1
+	}
+
+	slices.SortFunc(items, func(a, b T) int {
+		return direction * positionOf(a).compare(positionOf(b))
+	})
+
+	if after != nil {
+		items = slices.DeleteFunc(items, func(item T) bool {
+			return direction*positionOf(item).compare(*after) <= 0
+		})
+	}
+
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+		next = cursorOf(items[page.Limit-1])
+	}
+
+	return items, next
+}
+
+func containsFold(value, query string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(query))
+}
+
+func matchLink(filter models.LinksFilter, link models.Link) bool {
+	if filter.Query != "" &&
+		!containsFold(link.Alias, filter.Query) &&
+		!containsFold(link.URL, filter.Query) &&
+		!containsFold(link.Title, filter.Query) {
+		return false
+	}
+
+	if filter.Tag != "" && !slices.Contains(link.Tags, filter.Tag) {
+		return false
+	}
+
+	return true
+}
+
+func matchTopic(filter models.TopicsFilter, path string) bool {
+	prefix := storage.SubtreePrefix(filter.Parent)
+	if !strings.HasPrefix(path, prefix) || path == filter.Parent {
+		return false
+	}
+
+	return !filter.Direct || !strings.Contains(path[len(prefix):], storage.TopicSeparator)
+}
@@ -0,0 +1,473 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	zeroTopicId = 0
+)
+
+var (
+	emptyTopics = []string{}
+	emptyLinks  = []models.Link{}
+	emptyLink   = models.Link{}
+)
+
+type topic struct {
+	id     uint32
+	userId uint32
+	topic  string
+}
+
+// link keeps everything but the topic name, which is resolved on read.
+type link struct {
+	userId  uint32
+	topicId uint32
+	data    models.Link
+}
+
+// trashed is a deleted topic or link, alias is empty for topics.
+type trashed struct {
+	id        uint32
+	userId    uint32
+	topic     string
+	alias     string
+	snapshot  storage.TrashSnapshot
+	deletedAt time.Time
+}
+
+// Storage keeps users, topics and links in process memory. It mirrors the
+// constraints of the postgresql schema, so callers observe the same errors.
+type Storage struct {
+	mu sync.RWMutex
+
+	users  map[string]uint32
+	topics []*topic
+	links  []*link
+	trash  []*trashed
+	shares []*topicShare
+
+	publications []*publication
+
+	shortURLs map[string]string
+	outbox    []*shortenerTask
+	tokens    []*apiToken
+	telegram  map[int64]uint32
+	linkCodes map[string]linkCode
+
+	lastUserId   uint32
+	lastTopicId  uint32
+	lastLinkId   uint32
+	lastTrashId  uint32
+	lastOutboxId uint32
+	lastTokenId  uint32
+}
+
+func New() storage.Storage {
+	return &Storage{
+		users:     make(map[string]uint32),
+		shortURLs: make(map[string]string),
+		telegram:  make(map[int64]uint32),
+		linkCodes: make(map[string]linkCode),
+	}
+}
+
+func (s *Storage) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *Storage) PostTopic(ctx context.Context, username, topicName string) (uint32, error) {
+	topicName, err := storage.CleanTopicPath(topicName)
+	if err != nil {
+		return zeroTopicId, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId := s.upsertUser(username)
+
+	if s.findTopic(userId, topicName) != nil {
+		return zeroTopicId, storage.ErrTopicAlreadyExists
+	}
+
+	s.insertAncestors(userId, topicName)
+
+	return s.insertTopic(userId, topicName).id, nil
+}
+
+func (s *Storage) DeleteTopic(ctx context.Context, username, topicName string) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.findUserTopic(username, topicName)
+	if err != nil {
+		return zeroTopicId, err
+	}
+	userId := t.userId
+
+	// Subtopics go together with the topic.
+	var snapshot storage.TrashSnapshot
+
+	removed := make(map[uint32]*topic)
+	for _, other := range s.topics {
+		if other.userId == userId && storage.InSubtree(other.topic, t.topic) {
+			removed[other.id] = other
+			snapshot.Topics = append(snapshot.Topics, other.topic)
+		}
+	}
+	slices.Sort(snapshot.Topics)
+
+	for _, l := range s.links {
+		if lt, ok := removed[l.topicId]; ok {
+			snapshot.Links = append(snapshot.Links, l.model(lt))
+			s.dropShortURL(l.data)
+		}
+	}
+
+	s.insertTrash(userId, t.topic, "", snapshot)
+
+	s.links = filter(s.links, func(l *link) bool { return removed[l.topicId] == nil })
+	s.topics = filter(s.topics, func(other *topic) bool { return removed[other.id] == nil })
+	s.shares = filter(s.shares, func(share *topicShare) bool { return removed[share.topicId] == nil })
+	s.publications = filter(s.publications, func(p *publication) bool { return removed[p.topicId] == nil })
+
+	return t.id, nil
+}
+
+func (s *Storage) ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) ([]string, string, error) {
+	page, err := storage.TopicsPage(page)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	cursor, ok, err := storage.DecodeCursor(page)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, found := s.users[username]
+	if !found {
+		return emptyTopics, "", storage.ErrUserNotFound
+	}
+
+	if filter.Parent != "" && s.findTopic(userId, filter.Parent) == nil {
+		return emptyTopics, "", storage.ErrTopicNotFound
+	}
+
+	userTopics := make([]*topic, 0)
+	for _, t := range s.topics {
+		if t.userId == userId && containsFold(t.topic, filter.Query) && matchTopic(filter, t.topic) {
+			userTopics = append(userTopics, t)
+		}
+	}
+
+	var after *position
+	if ok {
+		after = &position{value: cursor.Value, id: cursor.ID}
+	}
+
+	userTopics, next := paginate(
+		userTopics, page, after,
+		func(t *topic) position { return topicPosition(page.SortBy, t) },
+		func(t *topic) string { return storage.TopicCursor(page, t.id, t.topic) },
+	)
+
+	topics := make([]string, 0, len(userTopics))
+	for _, t := range userTopics {
+		topics = append(topics, t.topic)
+	}
+
+	return topics, next, nil
+}
+
+func (s *Storage) PostLink(ctx context.Context, username, topicName string, l models.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.insertLink(username, topicName, l)
+
+	return err
+}
+
+func (s *Storage) insertLink(username, topicName string, l models.Link) (*link, error) {
+	t, err := s.findAccessibleTopic(username, topicName, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.findLink(t.id, l.Alias) != nil {
+		return nil, storage.ErrAliasAlreadyExists
+	}
+
+	code, err := storage.NewLinkCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	s.lastLinkId++
+	inserted := &link{
+		userId:  t.userId,
+		topicId: t.id,
+		data: models.Link{
+			ID:          s.lastLinkId,
+			URL:         l.URL,
+			Code:        code,
+			Alias:       l.Alias,
+			Title:       l.Title,
+			Description: l.Description,
+			Note:        l.Note,
+			Tags:        []string{},
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+	}
+	s.links = append(s.links, inserted)
+
+	return inserted, nil
+}
+
+func (s *Storage) PickLink(ctx context.Context, username, topicName, alias string) (models.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.findAccessibleTopic(username, topicName, models.ShareViewer)
+	if err != nil {
+		return emptyLink, err
+	}
+
+	l := s.findLink(t.id, alias)
+	if l == nil {
+		return emptyLink, storage.ErrAliasNotFound
+	}
+
+	return l.model(t), nil
+}
+
+// PickLinkByCode finds a link of username by its code, wherever it is.
+func (s *Storage) PickLinkByCode(ctx context.Context, username, code string) (models.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return emptyLink, storage.ErrUserNotFound
+	}
+
+	for _, l := range s.links {
+		if l.userId == userId && l.data.Code == code {
+			return l.model(s.topicById(l.topicId)), nil
+		}
+	}
+
+	return emptyLink, storage.ErrAliasNotFound
+}
+
+func (s *Storage) ListLinks(ctx context.Context, username, topicName string, page models.Page, filter models.LinksFilter) ([]models.Link, string, error) {
+	page, err := storage.LinksPage(page)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	cursor, ok, err := storage.DecodeCursor(page)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.findAccessibleTopic(username, topicName, models.ShareViewer)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	links := make([]models.Link, 0)
+	for _, l := range s.links {
+		if l.userId != t.userId || !matchLink(filter, l.data) {
+			continue
+		}
+
+		if l.topicId == t.id {
+			links = append(links, l.model(t))
+			continue
+		}
+
+		if filter.Recursive {
+			if lt := s.topicById(l.topicId); storage.InSubtree(lt.topic, t.topic) {
+				links = append(links, l.model(lt))
+			}
+		}
+	}
+
+	var after *position
+	if ok {
+		p := linkCursorPosition(cursor)
+		after = &p
+	}
+
+	links, next := paginate(
+		links, page, after,
+		func(link models.Link) position { return linkPosition(page.SortBy, link) },
+		func(link models.Link) string { return storage.LinkCursor(page, link) },
+	)
+
+	return links, next, nil
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, username, topicName, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.deleteLink(username, topicName, alias)
+
+	return err
+}
+
+// deleteLink moves the link to the trash and returns it.
+func (s *Storage) deleteLink(username, topicName, alias string) (*link, error) {
+	t, err := s.findAccessibleTopic(username, topicName, models.ShareEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	l := s.findLink(t.id, alias)
+	if l == nil {
+		return nil, storage.ErrAliasNotFound
+	}
+
+	// The link goes to the trash of the owner of the topic.
+	s.insertTrash(t.userId, t.topic, alias, storage.TrashSnapshot{Links: []models.Link{l.model(t)}})
+
+	s.links = filter(s.links, func(other *link) bool { return other != l })
+
+	return l, nil
+}
+
+// upsertUser returns the id of username, creating the user if needed.
+func (s *Storage) upsertUser(username string) uint32 {
+	userId, ok := s.users[username]
+	if !ok {
+		s.lastUserId++
+		userId = s.lastUserId
+		s.users[username] = userId
+	}
+
+	return userId
+}
+
+// username returns the name of the user with userId, users are never deleted.
+func (s *Storage) username(userId uint32) string {
+	for username, id := range s.users {
+		if id == userId {
+			return username
+		}
+	}
+
+	return ""
+}
+
+func (s *Storage) insertTopic(userId uint32, topicName string) *topic {
+	s.lastTopicId++
+
+	t := &topic{
+		id:     s.lastTopicId,
+		userId: userId,
+		topic:  topicName,
+	}
+	s.topics = append(s.topics, t)
+
+	return t
+}
+
+// insertAncestors creates the missing ancestors of topicName.
+func (s *Storage) insertAncestors(userId uint32, topicName string) {
+	for _, ancestor := range storage.TopicAncestors(topicName) {
+		if s.findTopic(userId, ancestor) == nil {
+			s.insertTopic(userId, ancestor)
+		}
+	}
+}
+
+// findUserTopic resolves a topic of username. Shared topics are refused,
+// methods that accept them use findAccessibleTopic.
+func (s *Storage) findUserTopic(username, topicName string) (*topic, error) {
+	if _, _, shared := storage.ParseSharedTopic(topicName); shared {
+		return nil, storage.ErrPermissionDenied
+	}
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	t := s.findTopic(userId, topicName)
+	if t == nil {
+		return nil, storage.ErrTopicNotFound
+	}
+
+	return t, nil
+}
+
+func (s *Storage) findTopic(userId uint32, topicName string) *topic {
+	for _, t := range s.topics {
+		if t.userId == userId && t.topic == topicName {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) findLink(topicId uint32, alias string) *link {
+	for _, l := range s.links {
+		if l.topicId == topicId && l.data.Alias == alias {
+			return l
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) topicById(topicId uint32) *topic {
+	for _, t := range s.topics {
+		if t.id == topicId {
+			return t
+		}
+	}
+
+	return nil
+}
+
+func (l *link) model(t *topic) models.Link {
+	result := l.data
+	result.Topic = t.topic
+	result.Tags = slices.Clone(l.data.Tags)
+
+	return result
+}
+
+func filter[T any](items []T, keep func(T) bool) []T {
+	result := items[:0]
+	for _, item := range items {
+		if keep(item) {
+			result = append(result, item)
+		}
+	}
+
+	// Clear the tail so that removed elements can be garbage collected.
+	clear(items[len(result):])
+
+	return result
+}
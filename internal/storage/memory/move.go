@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/pkg/random"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RenameTopic changes the path of topic and of all its subtopics, so it also
+// moves subtrees around the hierarchy.
+func (s *Storage) RenameTopic(ctx context.Context, username, topicName, newTopic string) error {
+	newTopic, err := storage.CleanTopicPath(newTopic)
+	if err != nil {
+		return err
+	}
+
+	if newTopic != topicName && storage.InSubtree(newTopic, topicName) {
+		return storage.ErrInvalidTopicPath
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.findUserTopic(username, topicName)
+	if err != nil {
+		return err
+	}
+
+	if newTopic == topicName {
+		return nil
+	}
+
+	subtree := make(map[*topic]string)
+	for _, other := range s.topics {
+		if other.userId == t.userId && storage.InSubtree(other.topic, topicName) {
+			subtree[other] = newTopic + strings.TrimPrefix(other.topic, topicName)
+		}
+	}
+
+	for _, path := range subtree {
+		if s.findTopic(t.userId, path) != nil {
+			return storage.ErrTopicAlreadyExists
+		}
+	}
+
+	for other, path := range subtree {
+		other.topic = path
+	}
+
+	s.insertAncestors(t.userId, newTopic)
+
+	return nil
+}
+
+func (s *Storage) UpdateLink(ctx context.Context, username, topicName, alias string, update models.LinkUpdate) (models.Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.findAccessibleTopic(username, topicName, models.ShareEditor)
+	if err != nil {
+		return emptyLink, err
+	}
+
+	l := s.findLink(t.id, alias)
+	if l == nil {
+		return emptyLink, storage.ErrAliasNotFound
+	}
+
+	if update.Alias != nil {
+		if other := s.findLink(t.id, *update.Alias); other != nil && other != l {
+			return emptyLink, storage.ErrAliasAlreadyExists
+		}
+	}
+
+	old := l.data
+
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+
+	set(&l.data.URL, update.URL)
+	set(&l.data.Alias, update.Alias)
+	set(&l.data.Title, update.Title)
+	set(&l.data.Description, update.Description)
+	set(&l.data.Note, update.Note)
+	l.data.UpdatedAt = time.Now().UTC()
+
+	// The short URL of the old URL goes, the new one is shortened anew.
+	if l.data.URL != old.URL {
+		s.dropShortURL(old)
+		l.data.ShortURL, l.data.ShortCode = "", ""
+		s.insertShortenerTask(models.ShortenerSave, l.data.ID, l.data.URL, l.data.Alias)
+	}
+
+	return l.model(t), nil
+}
+
+func (s *Storage) MoveLink(ctx context.Context, username, topicName, alias, toTopic string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.findUserLink(username, topicName, alias)
+	if err != nil {
+		return "", err
+	}
+
+	if topicName == toTopic {
+		return alias, nil
+	}
+
+	target, newAlias, err := s.linkTarget(l.userId, toTopic, alias)
+	if err != nil {
+		return "", err
+	}
+
+	l.topicId = target.id
+	l.data.Alias = newAlias
+	l.data.UpdatedAt = time.Now().UTC()
+
+	return newAlias, nil
+}
+
+func (s *Storage) CopyLink(ctx context.Context, username, topicName, alias, toTopic string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.findUserLink(username, topicName, alias)
+	if err != nil {
+		return "", err
+	}
+
+	target, newAlias, err := s.linkTarget(l.userId, toTopic, alias)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := storage.NewLinkCode()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+
+	data := l.data
+	s.lastLinkId++
+	data.ID = s.lastLinkId
+	data.Code = code
+	data.Alias = newAlias
+	data.Tags = slices.Clone(l.data.Tags)
+	// The short URL stays with the original link.
+	data.ShortURL, data.ShortCode = "", ""
+	data.CreatedAt = now
+	data.UpdatedAt = now
+
+	s.links = append(s.links, &link{
+		userId:  l.userId,
+		topicId: target.id,
+		data:    data,
+	})
+
+	return newAlias, nil
+}
+
+// linkTarget resolves the topic a link is moved or copied to. The link keeps
+// its alias unless the topic already has one like it, then a random alias is
+// picked.
+func (s *Storage) linkTarget(userId uint32, topicName, alias string) (*topic, string, error) {
+	t := s.findTopic(userId, topicName)
+	if t == nil {
+		return nil, "", storage.ErrTopicNotFound
+	}
+
+	for s.findLink(t.id, alias) != nil {
+		alias = random.Alias()
+	}
+
+	return t, alias, nil
+}
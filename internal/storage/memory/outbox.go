@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"time"
+)
+
+// shortenerTask is a queued shortener task with its schedule.
+type shortenerTask struct {
+	data          models.ShortenerTask
+	lastErr       string
+	nextAttemptAt time.Time
+}
+
+func (s *Storage) PostShortenedLink(ctx context.Context, username, topicName string, l models.Link) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inserted, err := s.insertLink(username, topicName, l)
+	if err != nil {
+		return err
+	}
+
+	s.insertShortenerTask(models.ShortenerSave, inserted.data.ID, l.URL, l.Alias)
+
+	return nil
+}
+
+// DeleteShortenedLink deletes the link together with its short URL.
+func (s *Storage) DeleteShortenedLink(ctx context.Context, username, topicName, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted, err := s.deleteLink(username, topicName, alias)
+	if err != nil {
+		return err
+	}
+
+	s.dropShortURL(deleted.data)
+
+	return nil
+}
+
+// ClaimShortenerTasks returns the tasks due now and postpones them by lease,
+// so that the tasks of a worker that died are retried.
+func (s *Storage) ClaimShortenerTasks(ctx context.Context, limit int, lease time.Duration) ([]models.ShortenerTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	var tasks []models.ShortenerTask
+	for _, task := range s.outbox {
+		if len(tasks) == limit {
+			break
+		}
+
+		if task.nextAttemptAt.After(now) {
+			continue
+		}
+
+		task.data.Attempts++
+		task.nextAttemptAt = now.Add(lease)
+		tasks = append(tasks, task.data)
+	}
+
+	return tasks, nil
+}
+
+// CompleteShortenerTask removes the task. A completed save stores shortURL
+// and code with the link, unless the link has been deleted or changed
+// meanwhile, then the short URL is queued for deletion.
+func (s *Storage) CompleteShortenerTask(ctx context.Context, task models.ShortenerTask, shortURL, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeShortenerTask(task.ID)
+
+	if task.Kind != models.ShortenerSave {
+		return nil
+	}
+
+	for _, l := range s.links {
+		if l.data.ID == task.LinkID && l.data.URL == task.URL {
+			l.data.ShortURL = shortURL
+			l.data.ShortCode = code
+			return nil
+		}
+	}
+
+	s.insertShortenerTask(models.ShortenerDelete, 0, "", code)
+
+	return nil
+}
+
+func (s *Storage) RetryShortenerTask(ctx context.Context, taskID uint32, retryAt time.Time, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, task := range s.outbox {
+		if task.data.ID == taskID {
+			task.nextAttemptAt = retryAt
+			task.lastErr = lastErr
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) DiscardShortenerTask(ctx context.Context, taskID uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeShortenerTask(taskID)
+
+	return nil
+}
+
+// dropShortURL queues the deletion of the short URL of a link that is deleted
+// or points elsewhere, by its stored code. A link whose shortening is still
+// queued has no short URL yet, the shortening is dropped instead.
+func (s *Storage) dropShortURL(l models.Link) {
+	s.outbox = filter(s.outbox, func(task *shortenerTask) bool {
+		return task.data.Kind != models.ShortenerSave || task.data.LinkID != l.ID
+	})
+
+	if l.ShortCode != "" {
+		s.insertShortenerTask(models.ShortenerDelete, 0, "", l.ShortCode)
+	}
+}
+
+func (s *Storage) insertShortenerTask(kind models.ShortenerTaskKind, linkId uint32, url, alias string) {
+	s.lastOutboxId++
+	s.outbox = append(s.outbox, &shortenerTask{
+		data: models.ShortenerTask{
+			ID:     s.lastOutboxId,
+			Kind:   kind,
+			LinkID: linkId,
+			URL:    url,
+			Alias:  alias,
+		},
+		nextAttemptAt: time.Now(),
+	})
+}
+
+func (s *Storage) removeShortenerTask(taskID uint32) {
+	s.outbox = filter(s.outbox, func(task *shortenerTask) bool { return task.data.ID != taskID })
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+	"time"
+)
+
+type publication struct {
+	slug        string
+	topicId     uint32
+	publishedAt time.Time
+}
+
+func (s *Storage) PublishTopic(ctx context.Context, username, topicName, slug string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.findUserTopic(username, topicName)
+	if err != nil {
+		return "", err
+	}
+
+	if p := s.publication(t.id); p != nil {
+		return p.slug, nil
+	}
+
+	s.publications = append(s.publications, &publication{
+		slug:        slug,
+		topicId:     t.id,
+		publishedAt: time.Now().UTC(),
+	})
+
+	return slug, nil
+}
+
+func (s *Storage) UnpublishTopic(ctx context.Context, username, topicName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.findUserTopic(username, topicName)
+	if err != nil {
+		return err
+	}
+
+	p := s.publication(t.id)
+	if p == nil {
+		return storage.ErrTopicNotPublished
+	}
+
+	s.publications = filter(s.publications, func(other *publication) bool { return other != p })
+
+	return nil
+}
+
+func (s *Storage) ListPublishedTopics(ctx context.Context, username string) ([]models.PublishedTopic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	topics := make([]models.PublishedTopic, 0)
+	for _, p := range s.publications {
+		if t := s.topicById(p.topicId); t.userId == userId {
+			topics = append(topics, p.model(username, t))
+		}
+	}
+
+	slices.SortFunc(topics, func(a, b models.PublishedTopic) int { return cmp.Compare(a.Topic, b.Topic) })
+
+	return topics, nil
+}
+
+func (s *Storage) PublishedTopic(ctx context.Context, slug string) (models.PublishedTopic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, p := range s.publications {
+		if p.slug == slug {
+			t := s.topicById(p.topicId)
+			return p.model(s.username(t.userId), t), nil
+		}
+	}
+
+	return models.PublishedTopic{}, storage.ErrTopicNotPublished
+}
+
+func (s *Storage) publication(topicId uint32) *publication {
+	for _, p := range s.publications {
+		if p.topicId == topicId {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func (p *publication) model(owner string, t *topic) models.PublishedTopic {
+	return models.PublishedTopic{
+		Owner:       owner,
+		Topic:       t.topic,
+		Slug:        p.slug,
+		PublishedAt: p.publishedAt,
+	}
+}
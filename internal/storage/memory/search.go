@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"sort"
+)
+
+func (s *Storage) SearchLinks(ctx context.Context, username, query string, limit int) ([]models.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return emptyLinks, storage.ErrUserNotFound
+	}
+
+	terms := storage.SearchTerms(query)
+
+	type scored struct {
+		link  models.Link
+		score float64
+	}
+
+	found := make([]scored, 0)
+	for _, l := range s.links {
+		if l.userId != userId {
+			continue
+		}
+
+		link := l.model(s.topicById(l.topicId))
+		if score := storage.SearchScore(link, terms); score > 0 {
+			found = append(found, scored{link: link, score: score})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].score != found[j].score {
+			return found[i].score > found[j].score
+		}
+
+		return found[i].link.ID > found[j].link.ID
+	})
+
+	links := make([]models.Link, 0, min(len(found), limit))
+	for _, f := range found[:min(len(found), limit)] {
+		links = append(links, f.link)
+	}
+
+	return links, nil
+}
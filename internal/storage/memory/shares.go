@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+)
+
+type topicShare struct {
+	topicId uint32
+	userId  uint32
+	role    models.ShareRole
+}
+
+func (s *Storage) ShareTopic(ctx context.Context, owner, topicName, username string, role models.ShareRole) error {
+	if !storage.ValidShareRole(role) {
+		return storage.ErrInvalidShareRole
+	}
+
+	if owner == username {
+		return storage.ErrShareWithOwner
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, userId, err := s.findShare(owner, topicName, username)
+	if err != nil {
+		return err
+	}
+
+	if share := s.share(t.id, userId); share != nil {
+		share.role = role
+		return nil
+	}
+
+	s.shares = append(s.shares, &topicShare{topicId: t.id, userId: userId, role: role})
+
+	return nil
+}
+
+func (s *Storage) UnshareTopic(ctx context.Context, owner, topicName, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, userId, err := s.findShare(owner, topicName, username)
+	if err != nil {
+		return err
+	}
+
+	share := s.share(t.id, userId)
+	if share == nil {
+		return storage.ErrShareNotFound
+	}
+
+	s.shares = filter(s.shares, func(other *topicShare) bool { return other != share })
+
+	return nil
+}
+
+func (s *Storage) ListTopicShares(ctx context.Context, owner, topicName string) ([]models.TopicShare, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, err := s.findUserTopic(owner, topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]models.TopicShare, 0)
+	for _, share := range s.shares {
+		if share.topicId == t.id {
+			shares = append(shares, models.TopicShare{Username: s.username(share.userId), Role: share.role})
+		}
+	}
+
+	slices.SortFunc(shares, func(a, b models.TopicShare) int { return cmp.Compare(a.Username, b.Username) })
+
+	return shares, nil
+}
+
+func (s *Storage) ListSharedTopics(ctx context.Context, username string) ([]models.SharedTopic, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	topics := make([]models.SharedTopic, 0)
+	for _, share := range s.shares {
+		if share.userId != userId {
+			continue
+		}
+
+		t := s.topicById(share.topicId)
+		owner := s.username(t.userId)
+		topics = append(topics, models.SharedTopic{
+			Owner: owner,
+			Topic: t.topic,
+			Path:  storage.SharedTopicPath(owner, t.topic),
+			Role:  share.role,
+		})
+	}
+
+	slices.SortFunc(topics, func(a, b models.SharedTopic) int {
+		return cmp.Or(cmp.Compare(a.Owner, b.Owner), cmp.Compare(a.Topic, b.Topic))
+	})
+
+	return topics, nil
+}
+
+// findShare resolves a topic of owner and the user it is shared with.
+func (s *Storage) findShare(owner, topicName, username string) (*topic, uint32, error) {
+	t, err := s.findUserTopic(owner, topicName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, 0, storage.ErrUserNotFound
+	}
+
+	return t, userId, nil
+}
+
+func (s *Storage) share(topicId, userId uint32) *topicShare {
+	for _, share := range s.shares {
+		if share.topicId == topicId && share.userId == userId {
+			return share
+		}
+	}
+
+	return nil
+}
+
+// findAccessibleTopic is findUserTopic for topics that may be shared: it
+// resolves own topics as well as @<owner>/<topic> ones username has the role
+// for. Topics that aren't shared with username are not found.
+func (s *Storage) findAccessibleTopic(username, topicName string, role models.ShareRole) (*topic, error) {
+	owner, path, shared := storage.ParseSharedTopic(topicName)
+	if !shared {
+		owner, path = username, topicName
+	}
+
+	if owner == username {
+		return s.findUserTopic(username, path)
+	}
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	t, err := s.findUserTopic(owner, path)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, storage.ErrTopicNotFound
+		}
+
+		return nil, err
+	}
+
+	// A share of an ancestor covers the topic as well.
+	found, allowed := false, false
+	for _, share := range s.shares {
+		shared := s.topicById(share.topicId)
+		if share.userId != userId || shared.userId != t.userId || !storage.InSubtree(t.topic, shared.topic) {
+			continue
+		}
+
+		found = true
+		allowed = allowed || storage.RoleAllows(share.role, role)
+	}
+
+	switch {
+	case !found:
+		return nil, storage.ErrTopicNotFound
+	case !allowed:
+		return nil, storage.ErrPermissionDenied
+	}
+
+	return t, nil
+}
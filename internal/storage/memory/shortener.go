@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/storage"
+)
+
+func (s *Storage) SaveShortURL(ctx context.Context, code, url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shortURLs[code]; ok {
+		return storage.ErrShortCodeExists
+	}
+
+	s.shortURLs[code] = url
+
+	return nil
+}
+
+func (s *Storage) ShortURL(ctx context.Context, code string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	url, ok := s.shortURLs[code]
+	if !ok {
+		return "", storage.ErrShortCodeNotFound
+	}
+
+	return url, nil
+}
+
+func (s *Storage) DeleteShortURL(ctx context.Context, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shortURLs[code]; !ok {
+		return storage.ErrShortCodeNotFound
+	}
+
+	delete(s.shortURLs, code)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+)
+
+func (s *Storage) TagLink(ctx context.Context, username, topicName, alias, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.findUserLink(username, topicName, alias)
+	if err != nil {
+		return err
+	}
+
+	idx, found := slices.BinarySearch(l.data.Tags, tag)
+	if !found {
+		l.data.Tags = slices.Insert(l.data.Tags, idx, tag)
+	}
+
+	return nil
+}
+
+func (s *Storage) UntagLink(ctx context.Context, username, topicName, alias, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.findUserLink(username, topicName, alias)
+	if err != nil {
+		return err
+	}
+
+	idx, found := slices.BinarySearch(l.data.Tags, tag)
+	if !found {
+		return storage.ErrTagNotFound
+	}
+
+	l.data.Tags = slices.Delete(l.data.Tags, idx, idx+1)
+
+	return nil
+}
+
+func (s *Storage) ListLinksByTag(ctx context.Context, username, tag string) ([]models.Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return emptyLinks, storage.ErrUserNotFound
+	}
+
+	links := make([]models.Link, 0)
+	for _, l := range s.links {
+		if l.userId != userId {
+			continue
+		}
+
+		if _, found := slices.BinarySearch(l.data.Tags, tag); found {
+			links = append(links, l.model(s.topicById(l.topicId)))
+		}
+	}
+
+	return links, nil
+}
+
+func (s *Storage) findUserLink(username, topicName, alias string) (*link, error) {
+	t, err := s.findUserTopic(username, topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	l := s.findLink(t.id, alias)
+	if l == nil {
+		return nil, storage.ErrAliasNotFound
+	}
+
+	return l, nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+type linkCode struct {
+	userId    uint32
+	expiresAt time.Time
+}
+
+func (s *Storage) TelegramUser(ctx context.Context, telegramID int64, username string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId, ok := s.telegram[telegramID]
+	if !ok {
+		userId = s.telegramUserId(telegramID, username)
+		s.telegram[telegramID] = userId
+	}
+
+	return s.username(userId), nil
+}
+
+// telegramUserId returns the user an account seen for the first time is
+// bound to: username when no other account or API token claims it yet, a
+// user of the account's own otherwise.
+func (s *Storage) telegramUserId(telegramID int64, username string) uint32 {
+	if userId, ok := s.users[username]; ok && username != "" && !s.userClaimed(userId) {
+		return userId
+	}
+
+	return s.upsertUser(storage.TelegramUsername(telegramID))
+}
+
+func (s *Storage) userClaimed(userId uint32) bool {
+	for _, id := range s.telegram {
+		if id == userId {
+			return true
+		}
+	}
+
+	for _, token := range s.tokens {
+		if token.userId == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Storage) CreateLinkCode(ctx context.Context, username, codeHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for hash, code := range s.linkCodes {
+		if !code.expiresAt.After(now) {
+			delete(s.linkCodes, hash)
+		}
+	}
+
+	s.linkCodes[codeHash] = linkCode{
+		userId:    s.upsertUser(username),
+		expiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (s *Storage) UseLinkCode(ctx context.Context, telegramID int64, codeHash string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.linkCodes[codeHash]
+	if !ok || !code.expiresAt.After(time.Now()) {
+		return "", storage.ErrLinkCodeNotFound
+	}
+
+	delete(s.linkCodes, codeHash)
+	s.telegram[telegramID] = code.userId
+
+	return s.username(code.userId), nil
+}
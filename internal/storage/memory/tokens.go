@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+type apiToken struct {
+	userId uint32
+	hash   string
+	data   models.APIToken
+}
+
+func (s *Storage) CreateToken(ctx context.Context, username, name, tokenHash string) (models.APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastTokenId++
+	token := &apiToken{
+		userId: s.upsertUser(username),
+		hash:   tokenHash,
+		data: models.APIToken{
+			ID:        s.lastTokenId,
+			Name:      name,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+	s.tokens = append(s.tokens, token)
+
+	return token.data, nil
+}
+
+func (s *Storage) ListTokens(ctx context.Context, username string) ([]models.APIToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	tokens := make([]models.APIToken, 0)
+	for _, token := range s.tokens {
+		if token.userId == userId {
+			tokens = append(tokens, token.data)
+		}
+	}
+
+	return tokens, nil
+}
+
+func (s *Storage) RevokeToken(ctx context.Context, username string, tokenID uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return storage.ErrUserNotFound
+	}
+
+	count := len(s.tokens)
+	s.tokens = filter(s.tokens, func(token *apiToken) bool {
+		return token.userId != userId || token.data.ID != tokenID
+	})
+
+	if len(s.tokens) == count {
+		return storage.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) TokenUser(ctx context.Context, tokenHash string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.tokens {
+		if token.hash == tokenHash {
+			return s.username(token.userId), nil
+		}
+	}
+
+	return "", storage.ErrTokenNotFound
+}
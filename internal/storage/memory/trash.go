@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"slices"
+	"time"
+)
+
+func (s *Storage) ListTrash(ctx context.Context, username string) ([]models.TrashItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	// The trash is in deletion order, the latest items go first.
+	items := make([]models.TrashItem, 0)
+	for idx := len(s.trash) - 1; idx >= 0; idx-- {
+		if item := s.trash[idx]; item.userId == userId {
+			items = append(items, item.snapshot.TrashItem(item.id, item.topic, item.alias, item.deletedAt))
+		}
+	}
+
+	return items, nil
+}
+
+// RestoreTopic brings back a deleted topic with its subtopics and links.
+func (s *Storage) RestoreTopic(ctx context.Context, username, topicName string) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.findTrash(username, topicName, "")
+	if err != nil {
+		return zeroTopicId, err
+	}
+
+	if err := item.snapshot.CheckPaths(item.topic); err != nil {
+		return zeroTopicId, err
+	}
+
+	if s.findTopic(item.userId, topicName) != nil {
+		return zeroTopicId, storage.ErrTopicAlreadyExists
+	}
+
+	s.insertAncestors(item.userId, topicName)
+	t := s.insertTopic(item.userId, topicName)
+
+	// Subtopics can't exist without the topic, so they are all missing.
+	for _, subtopic := range item.snapshot.Topics {
+		if s.findTopic(item.userId, subtopic) == nil {
+			s.insertTopic(item.userId, subtopic)
+		}
+	}
+
+	if err := s.restoreLinks(item.userId, item.snapshot.Links); err != nil {
+		return zeroTopicId, err
+	}
+
+	s.deleteTrash(item)
+
+	return t.id, nil
+}
+
+// RestoreLink puts a deleted link back to its topic, the topic is created
+// again if it has been deleted since.
+func (s *Storage) RestoreLink(ctx context.Context, username, topicName, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, err := s.findTrash(username, topicName, alias)
+	if err != nil {
+		return err
+	}
+
+	if err := item.snapshot.CheckPaths(item.topic); err != nil {
+		return err
+	}
+
+	if err := s.restoreLinks(item.userId, item.snapshot.Links); err != nil {
+		return err
+	}
+
+	s.deleteTrash(item)
+
+	return nil
+}
+
+func (s *Storage) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.trash)
+	s.trash = filter(s.trash, func(item *trashed) bool { return !item.deletedAt.Before(deletedBefore) })
+
+	return int64(count - len(s.trash)), nil
+}
+
+func (s *Storage) insertTrash(userId uint32, topicName, alias string, snapshot storage.TrashSnapshot) {
+	s.lastTrashId++
+	s.trash = append(s.trash, &trashed{
+		id:        s.lastTrashId,
+		userId:    userId,
+		topic:     topicName,
+		alias:     alias,
+		snapshot:  snapshot,
+		deletedAt: time.Now().UTC(),
+	})
+}
+
+// findTrash returns the latest deleted item of username with topicName and
+// alias.
+func (s *Storage) findTrash(username, topicName, alias string) (*trashed, error) {
+	userId, ok := s.users[username]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+
+	for idx := len(s.trash) - 1; idx >= 0; idx-- {
+		if item := s.trash[idx]; item.userId == userId && item.topic == topicName && item.alias == alias {
+			return item, nil
+		}
+	}
+
+	return nil, storage.ErrNotInTrash
+}
+
+func (s *Storage) deleteTrash(item *trashed) {
+	s.trash = filter(s.trash, func(other *trashed) bool { return other != item })
+}
+
+// restoreLinks inserts links from a trash snapshot keeping their timestamps
+// and tags, missing topics are created. Nothing is changed when an alias is
+// already taken. Short URLs are deleted together with the links, so the
+// restored links are queued to be shortened again.
+func (s *Storage) restoreLinks(userId uint32, links []models.Link) error {
+	for _, l := range links {
+		if t := s.findTopic(userId, l.Topic); t != nil && s.findLink(t.id, l.Alias) != nil {
+			return storage.ErrAliasAlreadyExists
+		}
+	}
+
+	for _, l := range links {
+		t := s.findTopic(userId, l.Topic)
+		if t == nil {
+			s.insertAncestors(userId, l.Topic)
+			t = s.insertTopic(userId, l.Topic)
+		}
+
+		data := l
+		s.lastLinkId++
+		data.ID = s.lastLinkId
+		// Links deleted before they had codes get new ones.
+		if data.Code == "" {
+			code, err := storage.NewLinkCode()
+			if err != nil {
+				return err
+			}
+			data.Code = code
+		}
+		data.Topic = ""
+		data.Tags = slices.Clone(l.Tags)
+		data.ShortURL, data.ShortCode = "", ""
+
+		s.links = append(s.links, &link{
+			userId:  userId,
+			topicId: t.id,
+			data:    data,
+		})
+
+		s.insertShortenerTask(models.ShortenerSave, data.ID, data.URL, data.Alias)
+	}
+
+	return nil
+}
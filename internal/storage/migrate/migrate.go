@@ -0,0 +1,305 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+
+	createMigrationsTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	selectVersionsQuery = `SELECT version FROM schema_migrations ORDER BY version;`
+)
+
+var (
+	ErrNoDownMigration = errors.New("down migration is missing")
+	ErrUnknownVersion  = errors.New("database has a migration unknown to this build")
+)
+
+// Dialect describes how bind parameters are written by the sql driver.
+type Dialect struct {
+	Placeholder func(n int) string
+}
+
+var (
+	Postgres = Dialect{Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) }}
+	SQLite   = Dialect{Placeholder: func(int) string { return "?" }}
+)
+
+// Migration is a single schema change loaded from a pair of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies versioned migrations and records them in the
+// schema_migrations table. Every migration runs in its own transaction, and
+// migrators of several instances sharing a database run each migration once.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New loads all migrations from the root of fsys.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies all pending migrations and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	const op = "migrate.Up"
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	count := 0
+	for _, migration := range m.migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		insertQuery := fmt.Sprintf(
+			`INSERT INTO schema_migrations (version, name) VALUES (%s, %s) ON CONFLICT (version) DO NOTHING;`,
+			m.dialect.Placeholder(1), m.dialect.Placeholder(2),
+		)
+
+		ran, err := m.run(ctx, migration.Up, insertQuery, migration.Version, migration.Name)
+		if err != nil {
+			return count, fmt.Errorf("%s: version %d: %w", op, migration.Version, err)
+		}
+
+		if ran {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Down rolls back the last steps applied migrations and returns how many
+// were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	const op = "migrate.Down"
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+
+		if migration.Down == "" {
+			return count, fmt.Errorf("%s: version %d: %w", op, migration.Version, ErrNoDownMigration)
+		}
+
+		deleteQuery := fmt.Sprintf(`DELETE FROM schema_migrations WHERE version = %s;`, m.dialect.Placeholder(1))
+
+		// A migration rolled back by another instance meanwhile is a step
+		// done all the same.
+		if _, err := m.run(ctx, migration.Down, deleteQuery, migration.Version); err != nil {
+			return count, fmt.Errorf("%s: version %d: %w", op, migration.Version, err)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// Version returns the latest applied migration version, zero for an empty
+// database.
+func (m *Migrator) Version(ctx context.Context) (uint, error) {
+	const op = "migrate.Version"
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var version uint
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+// Latest returns the version of the newest known migration.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// run executes script in a transaction together with claimQuery, which adds
+// or removes the row of the migration in schema_migrations. The row is
+// claimed before the script runs: an instance running the same migration at
+// the same time waits for the row and then finds nothing to claim, so the
+// script is run once. run reports whether the script was run.
+func (m *Migrator) run(ctx context.Context, script, claimQuery string, args ...any) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, claimQuery, args...)
+	if err != nil {
+		return false, err
+	}
+
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if claimed == 0 {
+		return false, nil
+	}
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint]bool, error) {
+	if _, err := m.db.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.db.QueryContext(ctx, selectVersionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close() }()
+
+	known := make(map[uint]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+	}
+
+	applied := make(map[uint]bool)
+
+	var version uint
+	for cursor.Next() {
+		if err := cursor.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		if !known[version] {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+		}
+
+		applied[version] = true
+	}
+
+	return applied, cursor.Err()
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	const op = "migrate.load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || path.Ext(fileName) != ".sql" {
+			continue
+		}
+
+		var base string
+		var up bool
+		switch {
+		case strings.HasSuffix(fileName, upSuffix):
+			base, up = strings.TrimSuffix(fileName, upSuffix), true
+		case strings.HasSuffix(fileName, downSuffix):
+			base = strings.TrimSuffix(fileName, downSuffix)
+		default:
+			return nil, fmt.Errorf("%s: %s: expected %s or %s suffix", op, fileName, upSuffix, downSuffix)
+		}
+
+		rawVersion, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(rawVersion, 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%s: %s: invalid version %q", op, fileName, rawVersion)
+		}
+
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("%s: version %d is used by %q and %q", op, version, migration.Name, name)
+		}
+
+		if up {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%s: version %d has no up migration", op, migration.Version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
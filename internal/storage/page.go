@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/Sleeps17/linker/internal/models"
+	"strings"
+	"time"
+)
+
+// Cursor marks where the next page of a listing starts: right after the item
+// with sort value Value and id ID. It remembers the ordering it was issued for,
+// so it can't be replayed against a differently sorted listing.
+type Cursor struct {
+	SortBy models.SortKey `json:"s"`
+	Desc   bool           `json:"d,omitempty"`
+	Value  string         `json:"v"`
+	ID     uint32         `json:"id"`
+}
+
+// Encode returns the opaque form of the cursor handed out to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Time returns Value of a created_at cursor.
+func (c Cursor) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return time.Time{}, ErrInvalidPage
+	}
+
+	return t, nil
+}
+
+// DecodeCursor parses page.Cursor, ok is false for the first page.
+func DecodeCursor(page models.Page) (cursor Cursor, ok bool, err error) {
+	if page.Cursor == "" {
+		return Cursor{}, false, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(page.Cursor)
+	if err != nil {
+		return Cursor{}, false, ErrInvalidPage
+	}
+
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, false, ErrInvalidPage
+	}
+
+	if cursor.SortBy != page.SortBy || cursor.Desc != page.Desc {
+		return Cursor{}, false, ErrInvalidPage
+	}
+
+	if cursor.SortBy == models.SortByCreatedAt {
+		if _, err := cursor.Time(); err != nil {
+			return Cursor{}, false, err
+		}
+	}
+
+	return cursor, true, nil
+}
+
+// LinksPage validates the sort key of a link listing, defaulting to creation
+// order.
+func LinksPage(page models.Page) (models.Page, error) {
+	switch page.SortBy {
+	case "":
+		page.SortBy = models.SortByCreatedAt
+	case models.SortByCreatedAt, models.SortByAlias, models.SortByURL:
+	default:
+		return page, ErrInvalidPage
+	}
+
+	if page.Limit < 0 {
+		return page, ErrInvalidPage
+	}
+
+	return page, nil
+}
+
+// TopicsPage validates the sort key of a topic listing, defaulting to creation
+// order.
+func TopicsPage(page models.Page) (models.Page, error) {
+	switch page.SortBy {
+	case "":
+		page.SortBy = models.SortByCreatedAt
+	case models.SortByCreatedAt, models.SortByName:
+	default:
+		return page, ErrInvalidPage
+	}
+
+	if page.Limit < 0 {
+		return page, ErrInvalidPage
+	}
+
+	return page, nil
+}
+
+// LinkCursor returns the cursor of the page following link.
+func LinkCursor(page models.Page, link models.Link) string {
+	return Cursor{
+		SortBy: page.SortBy,
+		Desc:   page.Desc,
+		Value:  LinkSortValue(page.SortBy, link),
+		ID:     link.ID,
+	}.Encode()
+}
+
+// LinkSortValue returns the field of link a listing sorted by key is ordered by.
+func LinkSortValue(key models.SortKey, link models.Link) string {
+	switch key {
+	case models.SortByAlias:
+		return link.Alias
+	case models.SortByURL:
+		return link.URL
+	default:
+		return link.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// TopicCursor returns the cursor of the page following the topic. Topics in
+// creation order are ordered by id alone.
+func TopicCursor(page models.Page, topicId uint32, topic string) string {
+	cursor := Cursor{SortBy: page.SortBy, Desc: page.Desc, ID: topicId}
+	if page.SortBy == models.SortByName {
+		cursor.Value = topic
+	}
+
+	return cursor.Encode()
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ContainsPattern turns a filter query into a LIKE pattern with '\' as the
+// escape character.
+func ContainsPattern(query string) string {
+	return "%" + likeEscaper.Replace(query) + "%"
+}
+
+// PrefixPattern is a LIKE pattern matching values starting with prefix.
+func PrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
@@ -0,0 +1,6 @@
+ALTER TABLE "links"
+    DROP COLUMN "title",
+    DROP COLUMN "description",
+    DROP COLUMN "note",
+    DROP COLUMN "created_at",
+    DROP COLUMN "updated_at";
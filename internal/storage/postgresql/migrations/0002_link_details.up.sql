@@ -0,0 +1,6 @@
+ALTER TABLE "links"
+    ADD COLUMN "title" TEXT NOT NULL DEFAULT '',
+    ADD COLUMN "description" TEXT NOT NULL DEFAULT '',
+    ADD COLUMN "note" TEXT NOT NULL DEFAULT '',
+    ADD COLUMN "created_at" TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN "updated_at" TIMESTAMPTZ NOT NULL DEFAULT now();
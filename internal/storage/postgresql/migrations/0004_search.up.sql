@@ -0,0 +1,12 @@
+-- The 'simple' configuration keeps words as is, links are saved in several
+-- languages. Punctuation is stripped from URLs so that host and path parts
+-- become separate words.
+ALTER TABLE "links" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', "alias"), 'A') ||
+    setweight(to_tsvector('simple', "title"), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace("link", '[^[:alnum:]]+', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', "note"), 'C') ||
+    setweight(to_tsvector('simple', "description"), 'C')
+) STORED;
+
+CREATE INDEX "links_search_vector_idx" ON "links" USING GIN ("search_vector");
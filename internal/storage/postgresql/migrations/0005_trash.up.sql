@@ -0,0 +1,14 @@
+-- Deleted topics and links are moved here as snapshots, so that the unique
+-- constraints of live topics and links don't account for deleted ones.
+-- Alias is empty for topics.
+CREATE TABLE "trash" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users(id),
+    "topic" TEXT NOT NULL,
+    "alias" TEXT NOT NULL DEFAULT '',
+    "snapshot" JSONB NOT NULL,
+    "deleted_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "trash_user_id_idx" ON "trash" (user_id, topic, alias);
+CREATE INDEX "trash_deleted_at_idx" ON "trash" (deleted_at);
@@ -0,0 +1,2 @@
+ALTER TABLE "links" DROP COLUMN "short_code";
+ALTER TABLE "links" DROP COLUMN "short_url";
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "link_codes";
+DROP TABLE IF EXISTS "telegram_accounts";
@@ -0,0 +1,14 @@
+-- Telegram accounts are bound to users by their numeric ids, which unlike
+-- Telegram usernames never change.
+CREATE TABLE "telegram_accounts" (
+    "telegram_id" BIGINT PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users(id),
+    "linked_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One-time codes binding a Telegram account to a user, stored as hashes.
+CREATE TABLE "link_codes" (
+    "code_hash" TEXT PRIMARY KEY,
+    "user_id" INT NOT NULL REFERENCES users(id),
+    "expires_at" TIMESTAMPTZ NOT NULL
+);
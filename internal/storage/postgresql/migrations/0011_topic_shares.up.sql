@@ -0,0 +1,10 @@
+-- Topics shared with other users, a share covers the subtopics as well.
+CREATE TABLE "topic_shares" (
+    "topic_id" INT NOT NULL REFERENCES topics(id) ON DELETE CASCADE,
+    "user_id" INT NOT NULL REFERENCES users(id),
+    "role" TEXT NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (topic_id, user_id)
+);
+
+CREATE INDEX "topic_shares_user_id_idx" ON "topic_shares" (user_id);
@@ -0,0 +1,2 @@
+-- Renamed topics can't be told from the ones created as "at-...", they keep
+-- their new paths.
@@ -0,0 +1,102 @@
+-- Paths starting with "@" address topics shared by other users, so own topics
+-- created before sharing with such paths are renamed: "@work/backend" becomes
+-- "at-work/backend", or "at-work-2/backend" when the user has "at-work", or
+-- "at-work-3/backend" when "at-work-2" is taken too. Descendants share the
+-- first segment of the path, so a subtree is renamed as a whole. Paths in the
+-- trash are renamed the same way.
+CREATE TEMPORARY TABLE "own_topic_roots" AS
+SELECT DISTINCT "user_id", split_part("topic", '/', 1) AS "root"
+FROM "topics"
+WHERE "topic" LIKE '@%';
+
+-- Every name a renamed root can't take: roots kept as they are and the names
+-- the other renamed roots get without a suffix.
+CREATE TEMPORARY TABLE "taken_topic_roots" AS
+SELECT DISTINCT "user_id", split_part("topic", '/', 1) AS "root"
+FROM "topics"
+WHERE "topic" NOT LIKE '@%';
+
+INSERT INTO "taken_topic_roots" ("user_id", "root")
+SELECT "user_id", 'at-' || substr("root", 2) FROM "own_topic_roots";
+
+CREATE TEMPORARY TABLE "own_topic_renames" AS
+WITH RECURSIVE "candidates" ("user_id", "root", "n") AS (
+    SELECT "user_id", "root", 2
+    FROM "own_topic_roots" AS "own"
+    WHERE EXISTS (
+        SELECT 1 FROM "topics"
+        WHERE "topics"."user_id" = "own"."user_id"
+          AND split_part("topics"."topic", '/', 1) = 'at-' || substr("own"."root", 2)
+    )
+    UNION ALL
+    SELECT "candidates"."user_id", "candidates"."root", "candidates"."n" + 1
+    FROM "candidates"
+    WHERE EXISTS (
+        SELECT 1 FROM "taken_topic_roots" AS "taken"
+        WHERE "taken"."user_id" = "candidates"."user_id"
+          AND "taken"."root" = 'at-' || substr("candidates"."root", 2) || '-' || "candidates"."n"
+    )
+)
+SELECT "user_id", "root", 'at-' || substr("root", 2) || '-' || max("n") AS "new_root"
+FROM "candidates"
+GROUP BY "user_id", "root";
+
+CREATE TEMPORARY TABLE "own_topic_paths" AS
+SELECT "paths"."user_id", "paths"."path",
+    coalesce("renames"."new_root", 'at-' || substr("paths"."root", 2))
+        || substr("paths"."path", length("paths"."root") + 1) AS "new_path"
+FROM (
+    SELECT DISTINCT "user_id", "path", split_part("path", '/', 1) AS "root"
+    FROM (
+        SELECT "user_id", "topic" AS "path" FROM "topics"
+        UNION
+        SELECT "user_id", "topic" FROM "trash"
+        UNION
+        SELECT "trash"."user_id", "topics"."value"
+        FROM "trash", jsonb_array_elements_text(CASE jsonb_typeof("trash"."snapshot" -> 'topics')
+            WHEN 'array' THEN "trash"."snapshot" -> 'topics' ELSE '[]' END) AS "topics" ("value")
+        UNION
+        SELECT "trash"."user_id", "links"."value" ->> 'topic'
+        FROM "trash", jsonb_array_elements(CASE jsonb_typeof("trash"."snapshot" -> 'links')
+            WHEN 'array' THEN "trash"."snapshot" -> 'links' ELSE '[]' END) AS "links" ("value")
+    ) AS "all_paths"
+    WHERE "path" LIKE '@%'
+) AS "paths"
+LEFT JOIN "own_topic_renames" AS "renames"
+    ON "renames"."user_id" = "paths"."user_id" AND "renames"."root" = "paths"."root";
+
+UPDATE "topics"
+SET "topic" = "paths"."new_path"
+FROM "own_topic_paths" AS "paths"
+WHERE "paths"."user_id" = "topics"."user_id" AND "paths"."path" = "topics"."topic";
+
+UPDATE "trash"
+SET "topic" = "paths"."new_path"
+FROM "own_topic_paths" AS "paths"
+WHERE "paths"."user_id" = "trash"."user_id" AND "paths"."path" = "trash"."topic";
+
+UPDATE "trash"
+SET "snapshot" = jsonb_set("snapshot", '{topics}', (
+    SELECT coalesce(jsonb_agg(to_jsonb(coalesce("paths"."new_path", "topics"."value")) ORDER BY "topics"."idx"), '[]')
+    FROM jsonb_array_elements_text("trash"."snapshot" -> 'topics') WITH ORDINALITY AS "topics" ("value", "idx")
+    LEFT JOIN "own_topic_paths" AS "paths"
+        ON "paths"."user_id" = "trash"."user_id" AND "paths"."path" = "topics"."value"
+))
+WHERE jsonb_typeof("snapshot" -> 'topics') = 'array';
+
+UPDATE "trash"
+SET "snapshot" = jsonb_set("snapshot", '{links}', (
+    SELECT coalesce(jsonb_agg(
+        CASE WHEN "paths"."new_path" IS NULL THEN "links"."value"
+             ELSE jsonb_set("links"."value", '{topic}', to_jsonb("paths"."new_path"))
+        END ORDER BY "links"."idx"), '[]')
+    FROM jsonb_array_elements("trash"."snapshot" -> 'links') WITH ORDINALITY AS "links" ("value", "idx")
+    LEFT JOIN "own_topic_paths" AS "paths"
+        ON "paths"."user_id" = "trash"."user_id" AND "paths"."path" = "links"."value" ->> 'topic'
+))
+WHERE jsonb_typeof("snapshot" -> 'links') = 'array';
+
+DROP TABLE "own_topic_paths";
+DROP TABLE "own_topic_renames";
+DROP TABLE "taken_topic_roots";
+DROP TABLE "own_topic_roots";
@@ -3,27 +3,36 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
 	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/storage/migrate"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+	"io/fs"
 )
 
 const (
-	emptyLink   = ""
 	zeroTopicId = 0
 	zeroUserId  = 0
+	zeroLinkId  = 0
+	zeroTrashId = 0
 )
 
 var (
-	emptyTopics  = []string{}
-	emptyLinks   = []string{}
-	emptyAliases = []string{}
+	emptyTopics = []string{}
+	emptyLinks  = []models.Link{}
+	emptyLink   = models.Link{}
 )
 
+//go:embed migrations/*.sql
+var migrations embed.FS
+
 type Storage struct {
-	db *sql.DB
+	db       *sql.DB
+	migrator *migrate.Migrator
 }
 
 func MustNew(ctx context.Context, connString string) storage.Storage {
@@ -36,44 +45,54 @@ func MustNew(ctx context.Context, connString string) storage.Storage {
 		panic("failed ping db: " + err.Error())
 	}
 
-	s := &Storage{db: db}
+	migrationsDir, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic("failed to load migrations: " + err.Error())
+	}
 
-	if err := s.init(context.TODO()); err != nil {
-		panic("failed to init database: " + err.Error())
+	migrator, err := migrate.New(db, migrate.Postgres, migrationsDir)
+	if err != nil {
+		panic("failed to load migrations: " + err.Error())
 	}
 
-	return s
+	return &Storage{db: db, migrator: migrator}
 }
 
 func (s *Storage) Close(ctx context.Context) error {
 	return s.db.Close()
 }
 
+func (s *Storage) Migrator() *migrate.Migrator {
+	return s.migrator
+}
+
 func (s *Storage) PostTopic(ctx context.Context, username, topic string) (uint32, error) {
 	const op = "postgresql.PostTopic"
 
-	var userId uint32
-	userId, err := s.findUser(ctx, username)
+	topic, err := storage.CleanTopicPath(topic)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			userId, err = s.insertUser(ctx, username)
-
-			if err != nil {
-				return zeroTopicId, fmt.Errorf("%s: %w", op, err)
-			}
-		} else {
-			return zeroTopicId, fmt.Errorf("%s: %w", op, err)
-		}
+		return zeroTopicId, err
 	}
 
 	var topicId uint32
-	err = s.db.QueryRowContext(ctx, insertTopicQuery, userId, topic).Scan(&topicId)
+	err = s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, err := s.upsertUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := s.insertAncestors(ctx, tx, userId, topic); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, insertTopicQuery, userId, topic).Scan(&topicId)
+	})
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
-			return 0, storage.ErrTopicAlreadyExists
+		if isUniqueViolation(err) {
+			return zeroTopicId, storage.ErrTopicAlreadyExists
 		}
 
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return topicId, nil
@@ -82,132 +101,195 @@ func (s *Storage) PostTopic(ctx context.Context, username, topic string) (uint32
 func (s *Storage) DeleteTopic(ctx context.Context, username, topic string) (uint32, error) {
 	const op = "postgresql.DeleteTopic"
 
-	userId, err := s.findUser(ctx, username)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return zeroTopicId, storage.ErrUserNotFound
+	var topicId uint32
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, _, err := s.findUserTopic(ctx, tx, username, topic)
+		if err != nil {
+			return err
 		}
 
-		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
-	}
+		// Subtopics go together with the topic.
+		subtopics := storage.PrefixPattern(storage.SubtreePrefix(topic))
 
-	topicId, err := s.findTopic(ctx, userId, topic)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return zeroTopicId, storage.ErrTopicNotFound
+		if err := s.trashTopic(ctx, tx, userId, topic, subtopics); err != nil {
+			return err
 		}
 
-		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
-	}
+		links, err := s.queryLinks(ctx, tx, listSubtreeLinksQuery, userId, topic, subtopics)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 
-	_, err = s.db.ExecContext(ctx, deleteLinksByTopicQuery, userId, topicId)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return zeroTopicId, fmt.Errorf("%s: %w", op, err)
+		for _, link := range links {
+			if err := s.dropShortURL(ctx, tx, link); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
 		}
-	}
 
-	if err := s.db.QueryRowContext(ctx, deleteTopicQuery, userId, topic).Scan(&topicId); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return zeroTopicId, storage.ErrTopicNotFound
+		if _, err := tx.ExecContext(ctx, deleteSubtreeLinksQuery, userId, topic, subtopics); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
+		if _, err := tx.ExecContext(ctx, deleteSubtopicsQuery, userId, subtopics); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.QueryRowContext(ctx, deleteTopicQuery, userId, topic).Scan(&topicId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrTopicNotFound
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return zeroTopicId, err
 	}
 
 	return topicId, nil
 }
 
-func (s *Storage) ListTopics(ctx context.Context, username string) ([]string, error) {
+func (s *Storage) ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) ([]string, string, error) {
 	const op = "postgresql.ListTopics"
 
-	userId, err := s.findUser(ctx, username)
+	page, err := storage.TopicsPage(page)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	userId, err := s.findUser(ctx, s.db, username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return emptyTopics, storage.ErrUserNotFound
+			return emptyTopics, "", storage.ErrUserNotFound
 		}
 
-		return emptyTopics, fmt.Errorf("%s: %w", op, err)
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	cursor, err := s.db.QueryContext(ctx, listTopicsQuery, userId)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return emptyTopics, nil
+	if filter.Parent != "" {
+		if _, err := s.findTopic(ctx, s.db, userId, filter.Parent); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return emptyTopics, "", storage.ErrTopicNotFound
+			}
+
+			return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
 		}
-		return emptyTopics, fmt.Errorf("%s: %w", op, err)
 	}
 
+	query, args, err := buildListTopicsQuery(userId, page, filter)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	cursor, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
 	topics := make([]string, 0)
+	ids := make([]uint32, 0)
 
-	var topic string
+	var (
+		topicId uint32
+		topic   string
+	)
 	for cursor.Next() {
-		if err := cursor.Scan(&topic); err != nil {
-			return emptyTopics, fmt.Errorf("%s: %w", op, err)
+		if err := cursor.Scan(&topicId, &topic); err != nil {
+			return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
 		}
 
 		topics = append(topics, topic)
+		ids = append(ids, topicId)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var next string
+	if page.Limit > 0 && len(topics) > page.Limit {
+		topics = topics[:page.Limit]
+		next = storage.TopicCursor(page, ids[page.Limit-1], topics[page.Limit-1])
 	}
 
-	return topics, nil
+	return topics, next, nil
+}
+
+func (s *Storage) PostLink(ctx context.Context, username, topic string, link models.Link) error {
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.insertLink(ctx, tx, username, topic, link)
+		return err
+	})
 }
 
-func (s *Storage) PostLink(ctx context.Context, username, topic, link, alias string) error {
+// insertLink adds link to topic and returns its id.
+func (s *Storage) insertLink(ctx context.Context, tx *sql.Tx, username, topic string, link models.Link) (uint32, error) {
 	const op = "postgresql.PostLink"
 
-	userId, err := s.findUser(ctx, username)
+	userId, topicId, _, err := s.findAccessibleTopic(ctx, tx, username, topic, models.ShareEditor)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrUserNotFound
-		}
-
-		return fmt.Errorf("%s: %w", op, err)
+		return zeroLinkId, err
 	}
 
-	topicId, err := s.findTopic(ctx, userId, topic)
+	code, err := storage.NewLinkCode()
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrTopicNotFound
-		}
-
-		return fmt.Errorf("%s: %w", op, err)
+		return zeroLinkId, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = s.db.ExecContext(ctx, insertLinkQuery, userId, topicId, link, alias)
+	var linkId uint32
+	err = tx.QueryRowContext(
+		ctx, insertLinkQuery,
+		userId, topicId,
+		link.URL, link.Alias, link.Title, link.Description, link.Note, code,
+	).Scan(&linkId)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
-			return storage.ErrAliasAlreadyExists
+		if isUniqueViolation(err) {
+			return zeroLinkId, storage.ErrAliasAlreadyExists
 		}
 
-		return fmt.Errorf("%s: %w", op, err)
+		return zeroLinkId, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return linkId, nil
 }
 
-func (s *Storage) PickLink(ctx context.Context, username, topic, alias string) (string, error) {
+func (s *Storage) PickLink(ctx context.Context, username, topic, alias string) (models.Link, error) {
 	const op = "postgresql.PickLink"
 
-	userId, err := s.findUser(ctx, username)
+	userId, topicId, _, err := s.findAccessibleTopic(ctx, s.db, username, topic, models.ShareViewer)
+	if err != nil {
+		return emptyLink, err
+	}
+
+	link, err := scanLink(s.db.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return emptyLink, storage.ErrUserNotFound
+			return emptyLink, storage.ErrAliasNotFound
 		}
 
 		return emptyLink, fmt.Errorf("%s: %w", op, err)
 	}
 
-	topicId, err := s.findTopic(ctx, userId, topic)
+	return link, nil
+}
+
+// PickLinkByCode finds a link of username by its code, wherever it is.
+func (s *Storage) PickLinkByCode(ctx context.Context, username, code string) (models.Link, error) {
+	const op = "postgresql.PickLinkByCode"
+
+	userId, err := s.findUser(ctx, s.db, username)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return emptyLink, storage.ErrTopicNotFound
+			return emptyLink, storage.ErrUserNotFound
 		}
 
 		return emptyLink, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var link string
-	err = s.db.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias).Scan(&link)
+	link, err := scanLink(s.db.QueryRowContext(ctx, selectUserLinkByCodeQuery, userId, code))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return emptyLink, storage.ErrAliasNotFound
@@ -219,112 +301,101 @@ func (s *Storage) PickLink(ctx context.Context, username, topic, alias string) (
 	return link, nil
 }
 
-func (s *Storage) ListLinks(ctx context.Context, username, topic string) ([]string, []string, error) {
+func (s *Storage) ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) ([]models.Link, string, error) {
 	const op = "postgresql.ListLinks"
 
-	userId, err := s.findUser(ctx, username)
+	page, err := storage.LinksPage(page)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return emptyLinks, emptyAliases, storage.ErrUserNotFound
-		}
-
-		return emptyLinks, emptyAliases, fmt.Errorf("%s: %w", op, err)
+		return emptyLinks, "", err
 	}
 
-	topicId, err := s.findTopic(ctx, userId, topic)
+	userId, topicId, path, err := s.findAccessibleTopic(ctx, s.db, username, topic, models.ShareViewer)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return emptyLinks, emptyAliases, storage.ErrTopicNotFound
-		}
+		return emptyLinks, "", err
+	}
 
-		return emptyLinks, emptyAliases, fmt.Errorf("%s: %w", op, err)
+	q := newListQuery(listLinksQuery, userId, topicId)
+	if filter.Recursive {
+		q = newListQuery(listSubtreeLinksQuery, userId, path, storage.PrefixPattern(storage.SubtreePrefix(path)))
 	}
 
-	cursor, err := s.db.QueryContext(ctx, listLinksQuery, userId, topicId)
+	query, args, err := buildListLinksQuery(q, page, filter)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return emptyLinks, emptyAliases, nil
-		}
-		return emptyLinks, emptyAliases, fmt.Errorf("failed to select data: %w", err)
+		return emptyLinks, "", err
 	}
 
-	links := make([]string, 0)
-	aliases := make([]string, 0)
-
-	var link, alias string
-	for cursor.Next() {
-		if err := cursor.Scan(&link, &alias); err != nil {
-			return emptyLinks, emptyAliases, fmt.Errorf("failed to scan data: %w", err)
-		}
+	links, err := s.queryLinks(ctx, s.db, query, args...)
+	if err != nil {
+		return emptyLinks, "", fmt.Errorf("%s: %w", op, err)
+	}
 
-		links = append(links, link)
-		aliases = append(aliases, alias)
+	var next string
+	if page.Limit > 0 && len(links) > page.Limit {
+		links = links[:page.Limit]
+		next = storage.LinkCursor(page, links[page.Limit-1])
 	}
 
-	return links, aliases, nil
+	return links, next, nil
 }
 
 func (s *Storage) DeleteLink(ctx context.Context, username, topic, alias string) error {
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.deleteLink(ctx, tx, username, topic, alias)
+		return err
+	})
+}
+
+// deleteLink moves the link to the trash and returns it.
+func (s *Storage) deleteLink(ctx context.Context, tx *sql.Tx, username, topic, alias string) (models.Link, error) {
 	const op = "postgresql.DeleteLink"
 
-	userId, err := s.findUser(ctx, username)
+	userId, topicId, path, err := s.findAccessibleTopic(ctx, tx, username, topic, models.ShareEditor)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrUserNotFound
-		}
-
-		return fmt.Errorf("%s: %w", op, err)
+		return emptyLink, err
 	}
 
-	topicId, err := s.findTopic(ctx, userId, topic)
+	link, err := scanLink(tx.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrTopicNotFound
+			return emptyLink, storage.ErrAliasNotFound
 		}
 
-		return fmt.Errorf("%s: %w", op, err)
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
 	}
 
-	res, err := s.db.ExecContext(ctx, deleteLinkQuery, userId, topicId, alias)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrAliasNotFound
-		}
-		return fmt.Errorf("%s: %w", op, err)
+	// The link goes to the trash of the owner of the topic.
+	snapshot := storage.TrashSnapshot{Links: []models.Link{link}}
+	if err := s.insertTrash(ctx, tx, userId, path, alias, snapshot); err != nil {
+		return emptyLink, err
 	}
 
-	affectedRowsCount, _ := res.RowsAffected()
-	if affectedRowsCount == 0 {
-		return storage.ErrAliasNotFound
+	if _, err := tx.ExecContext(ctx, deleteLinkQuery, userId, topicId, alias); err != nil {
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return link, nil
 }
 
-func (s *Storage) init(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, createUsersTableQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create USERS table: %w", err)
-	}
+// insertAncestors creates the missing ancestors of topic.
+func (s *Storage) insertAncestors(ctx context.Context, q querier, userId uint32, topic string) error {
+	const op = "postgresql.InsertAncestors"
 
-	_, err = s.db.ExecContext(ctx, createTopicsTableQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create TOPICS table: %w", err)
-	}
-
-	_, err = s.db.ExecContext(ctx, createLinksTableQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create LINKS table: %w", err)
+	for _, ancestor := range storage.TopicAncestors(topic) {
+		if _, err := q.ExecContext(ctx, insertAncestorQuery, userId, ancestor); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	return nil
 }
 
-func (s *Storage) insertUser(ctx context.Context, username string) (uint32, error) {
-	const op = "postgresql.AddUser"
+// upsertUser returns the id of username, creating the user if needed. It is a
+// single statement, so concurrent first requests of a new user don't race.
+func (s *Storage) upsertUser(ctx context.Context, q querier, username string) (uint32, error) {
+	const op = "postgresql.UpsertUser"
 
 	var userId uint32
-	err := s.db.QueryRowContext(ctx, insertUserQuery, username).Scan(&userId)
+	err := q.QueryRowContext(ctx, upsertUserQuery, username).Scan(&userId)
 	if err != nil {
 		return zeroUserId, fmt.Errorf("%s: %w", op, err)
 	}
@@ -332,24 +403,111 @@ func (s *Storage) insertUser(ctx context.Context, username string) (uint32, erro
 	return userId, nil
 }
 
-func (s *Storage) findUser(ctx context.Context, username string) (uint32, error) {
+func (s *Storage) findUser(ctx context.Context, q querier, username string) (uint32, error) {
 	const op = "postgresql.FindUser"
 
 	var userId uint32
-	if err := s.db.QueryRowContext(ctx, selectUserQuery, username).Scan(&userId); err != nil {
+	if err := q.QueryRowContext(ctx, selectUserQuery, username).Scan(&userId); err != nil {
 		return zeroUserId, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return userId, nil
 }
 
-func (s *Storage) findTopic(ctx context.Context, userId uint32, topic string) (uint32, error) {
+func (s *Storage) findTopic(ctx context.Context, q querier, userId uint32, topic string) (uint32, error) {
 	const op = "postgresql.FindTopic"
 
 	var topicId uint32
-	if err := s.db.QueryRowContext(ctx, selectTopicQuery, userId, topic).Scan(&topicId); err != nil {
+	if err := q.QueryRowContext(ctx, selectTopicQuery, userId, topic).Scan(&topicId); err != nil {
 		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return topicId, nil
 }
+
+// findUserTopic resolves username and topic to their ids, translating missing
+// rows into storage errors. Shared topics are refused, methods that accept
+// them use findAccessibleTopic.
+func (s *Storage) findUserTopic(ctx context.Context, q querier, username, topic string) (uint32, uint32, error) {
+	const op = "postgresql.FindUserTopic"
+
+	if _, _, shared := storage.ParseSharedTopic(topic); shared {
+		return zeroUserId, zeroTopicId, storage.ErrPermissionDenied
+	}
+
+	userId, err := s.findUser(ctx, q, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTopicId, storage.ErrUserNotFound
+		}
+
+		return zeroUserId, zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	topicId, err := s.findTopic(ctx, q, userId, topic)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTopicId, storage.ErrTopicNotFound
+		}
+
+		return zeroUserId, zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, topicId, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLink(row scanner) (models.Link, error) {
+	var link models.Link
+
+	err := row.Scan(
+		&link.ID,
+		&link.URL,
+		&link.ShortURL,
+		&link.ShortCode,
+		&link.Code,
+		&link.Alias,
+		&link.Topic,
+		&link.Title,
+		&link.Description,
+		&link.Note,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+		pq.Array(&link.Tags),
+	)
+
+	return link, err
+}
+
+// queryLinks runs a query selecting linkColumns and collects the result.
+func (s *Storage) queryLinks(ctx context.Context, q querier, query string, args ...any) ([]models.Link, error) {
+	cursor, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close() }()
+
+	links := make([]models.Link, 0)
+	for cursor.Next() {
+		link, err := scanLink(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation"
+}
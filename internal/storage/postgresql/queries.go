@@ -1,39 +1,138 @@
 package postgresql
 
+const (
+	linkColumns = `links.id, links.link, links.short_url, links.short_code, links.code, links.alias, topics.topic,
+		links.title, links.description, links.note, links.created_at, links.updated_at,
+		ARRAY(SELECT t.tag FROM link_tags lt JOIN tags t ON t.id = lt.tag_id WHERE lt.link_id = links.id ORDER BY t.tag)`
+	linksTable = `links JOIN topics ON topics.id = links.topic_id`
+)
+
 var (
-	createUsersTableQuery = `CREATE TABLE IF NOT EXISTS "users" (
-    	"id" SERIAL PRIMARY KEY, 
-    	"username" TEXT UNIQUE NOT NULL
-	);`
 	selectUserQuery = `SELECT id FROM users WHERE username = $1;`
-	insertUserQuery = `INSERT INTO users (username) VALUES ($1) RETURNING id;`
+	upsertUserQuery = `INSERT INTO users (username) VALUES ($1)
+		ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username RETURNING id;`
 	//deleteUserQuery = `DELETE FROM users WHERE username = $1;`
 
-	createTopicsTableQuery = `CREATE TABLE IF NOT EXISTS "topics" (
-    	"id" SERIAL PRIMARY KEY,
-    	"user_id" INT NOT NULL,
-    	"topic" TEXT NOT NULL,
-    	FOREIGN KEY (user_id) REFERENCES users(id),
-    	UNIQUE (user_id, topic)
-	);`
 	insertTopicQuery        = `INSERT INTO topics (user_id, topic) VALUES ($1, $2) RETURNING id;`
-	deleteLinksByTopicQuery = `DELETE FROM links WHERE user_id = $1 AND topic_id = $2;`
-	deleteTopicQuery        = `DELETE FROM topics WHERE user_id = $1 AND topic = $2 RETURNING id;`
-	selectTopicQuery        = `SELECT id FROM topics WHERE user_id = $1 AND topic = $2;`
-	listTopicsQuery         = `SELECT topic FROM topics WHERE user_id = $1;`
-
-	createLinksTableQuery = `CREATE TABLE IF NOT EXISTS "links" (
-    	"id" SERIAL PRIMARY KEY,
-    	"user_id" INT NOT NULL,
-    	"topic_id" INT NOT NULL,
-    	"link" TEXT NOT NULL,
-    	"alias" TEXT NOT NULL,
-    	FOREIGN KEY (user_id) REFERENCES users(id),
-    	FOREIGN KEY (topic_id) REFERENCES topics(id),
-    	UNIQUE (user_id, topic_id, alias)
-	);`
-	insertLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias) VALUES ($1, $2, $3, $4);`
-	selectLinkQuery = `SELECT link FROm links WHERE user_id = $1 AND topic_id = $2 AND alias = $3;`
-	listLinksQuery  = `SELECT link, alias FROM links WHERE user_id = $1 AND topic_id = $2;`
-	deleteLinkQuery = `DELETE FROM links WHERE user_id = $1 AND topic_id = $2 AND alias = $3`
+	insertAncestorQuery     = `INSERT INTO topics (user_id, topic) VALUES ($1, $2) ON CONFLICT (user_id, topic) DO NOTHING;`
+	deleteSubtreeLinksQuery = `DELETE FROM links WHERE topic_id IN
+		(SELECT id FROM topics WHERE user_id = $1 AND (topic = $2 OR topic LIKE $3 ESCAPE '\'));`
+	deleteSubtopicsQuery = `DELETE FROM topics WHERE user_id = $1 AND topic LIKE $2 ESCAPE '\';`
+	deleteTopicQuery     = `DELETE FROM topics WHERE user_id = $1 AND topic = $2 RETURNING id;`
+	selectTopicQuery     = `SELECT id FROM topics WHERE user_id = $1 AND topic = $2;`
+	listTopicsQuery      = `SELECT id, topic FROM topics WHERE user_id = $1`
+	renameSubtreeQuery   = `UPDATE topics SET topic = $3::text || substr(topic, char_length($2::text) + 1)
+		WHERE user_id = $1 AND (topic = $2 OR topic LIKE $4 ESCAPE '\');`
+	listSubtreeQuery = `SELECT topic FROM topics
+		WHERE user_id = $1 AND (topic = $2 OR topic LIKE $3 ESCAPE '\') ORDER BY topic;`
+
+	insertLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`
+	selectLinkQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = $1 AND links.topic_id = $2 AND links.alias = $3;`
+	selectUserLinkByCodeQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = $1 AND links.code = $2;`
+	listLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = $1 AND links.topic_id = $2`
+	listSubtreeLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = $1 AND (topics.topic = $2 OR topics.topic LIKE $3 ESCAPE '\')`
+	deleteLinkQuery     = `DELETE FROM links WHERE user_id = $1 AND topic_id = $2 AND alias = $3`
+	selectLinkIdQuery   = `SELECT id FROM links WHERE user_id = $1 AND topic_id = $2 AND alias = $3;`
+	selectLinkByIdQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + ` WHERE links.id = $1;`
+	updateLinkQuery     = `UPDATE links SET
+		link = COALESCE($2, link), alias = COALESCE($3, alias), title = COALESCE($4, title),
+		description = COALESCE($5, description), note = COALESCE($6, note), updated_at = now()
+		WHERE id = $1;`
+	moveLinkQuery = `UPDATE links SET topic_id = $2, alias = $3, updated_at = now() WHERE id = $1;`
+	copyLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, code)
+		SELECT user_id, $2, link, $3, title, description, note, $4 FROM links WHERE id = $1 RETURNING id;`
+	restoreLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, created_at, updated_at, code)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id;`
+
+	upsertTagQuery = `INSERT INTO tags (user_id, tag) VALUES ($1, $2)
+		ON CONFLICT (user_id, tag) DO UPDATE SET tag = EXCLUDED.tag RETURNING id;`
+	insertLinkTagQuery = `INSERT INTO link_tags (link_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+	deleteLinkTagQuery = `DELETE FROM link_tags
+		WHERE link_id = $1 AND tag_id = (SELECT id FROM tags WHERE user_id = $2 AND tag = $3);`
+	copyLinkTagsQuery   = `INSERT INTO link_tags (link_id, tag_id) SELECT $2, tag_id FROM link_tags WHERE link_id = $1;`
+	listLinksByTagQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + `
+		JOIN link_tags ON link_tags.link_id = links.id
+		JOIN tags ON tags.id = link_tags.tag_id
+		WHERE tags.user_id = $1 AND tags.tag = $2;`
+
+	searchLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + `, to_tsquery('simple', $2) query
+		WHERE links.user_id = $1 AND links.search_vector @@ query
+		ORDER BY ts_rank(links.search_vector, query) DESC, links.id DESC
+		LIMIT $3;`
+
+	insertTrashQuery = `INSERT INTO trash (user_id, topic, alias, snapshot) VALUES ($1, $2, $3, $4);`
+	listTrashQuery   = `SELECT id, topic, alias, snapshot, deleted_at FROM trash
+		WHERE user_id = $1 ORDER BY deleted_at DESC, id DESC;`
+	selectTrashQuery = `SELECT id, snapshot FROM trash
+		WHERE user_id = $1 AND topic = $2 AND alias = $3 ORDER BY deleted_at DESC, id DESC LIMIT 1;`
+	deleteTrashQuery = `DELETE FROM trash WHERE id = $1;`
+	purgeTrashQuery  = `DELETE FROM trash WHERE deleted_at < $1;`
+
+	insertShortURLQuery = `INSERT INTO short_urls (code, url) VALUES ($1, $2);`
+	selectShortURLQuery = `SELECT url FROM short_urls WHERE code = $1;`
+	deleteShortURLQuery = `DELETE FROM short_urls WHERE code = $1;`
+
+	insertShortenerTaskQuery = `INSERT INTO shortener_outbox (kind, link_id, url, alias) VALUES ($1, $2, $3, $4);`
+	claimShortenerTasksQuery = `UPDATE shortener_outbox SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (SELECT id FROM shortener_outbox WHERE next_attempt_at <= now()
+			ORDER BY next_attempt_at, id LIMIT $2 FOR UPDATE SKIP LOCKED)
+		RETURNING id, kind, link_id, url, alias, attempts;`
+	retryShortenerTaskQuery       = `UPDATE shortener_outbox SET next_attempt_at = $2, last_error = $3 WHERE id = $1;`
+	deleteShortenerTaskQuery      = `DELETE FROM shortener_outbox WHERE id = $1;`
+	deleteLinkShortenerTasksQuery = `DELETE FROM shortener_outbox WHERE kind = $1 AND link_id = $2;`
+	shortenLinkQuery              = `UPDATE links SET short_url = $2, short_code = $3 WHERE id = $1 AND link = $4;`
+	resetShortURLQuery            = `UPDATE links SET short_url = '', short_code = '' WHERE id = $1;`
+
+	insertTokenQuery     = `INSERT INTO api_tokens (user_id, name, token_hash) VALUES ($1, $2, $3) RETURNING id, created_at;`
+	listTokensQuery      = `SELECT id, name, created_at FROM api_tokens WHERE user_id = $1 ORDER BY id;`
+	deleteTokenQuery     = `DELETE FROM api_tokens WHERE user_id = $1 AND id = $2;`
+	selectTokenUserQuery = `SELECT users.username FROM api_tokens JOIN users ON users.id = api_tokens.user_id
+		WHERE api_tokens.token_hash = $1;`
+
+	selectTelegramUserQuery = `SELECT users.username FROM telegram_accounts JOIN users ON users.id = telegram_accounts.user_id
+		WHERE telegram_accounts.telegram_id = $1;`
+	insertTelegramAccountQuery = `INSERT INTO telegram_accounts (telegram_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (telegram_id) DO NOTHING;`
+	lockUserQuery    = `SELECT id FROM users WHERE username = $1 FOR UPDATE;`
+	userClaimedQuery = `SELECT EXISTS (SELECT 1 FROM telegram_accounts WHERE user_id = $1)
+		OR EXISTS (SELECT 1 FROM api_tokens WHERE user_id = $1);`
+	bindTelegramAccountQuery = `INSERT INTO telegram_accounts (telegram_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (telegram_id) DO UPDATE SET user_id = excluded.user_id, linked_at = now();`
+	insertLinkCodeQuery = `INSERT INTO link_codes (code_hash, user_id, expires_at) VALUES ($1, $2, $3);`
+	purgeLinkCodesQuery = `DELETE FROM link_codes WHERE expires_at <= now();`
+	useLinkCodeQuery    = `DELETE FROM link_codes WHERE code_hash = $1 AND expires_at > now() RETURNING user_id;`
+
+	upsertShareQuery = `INSERT INTO topic_shares (topic_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (topic_id, user_id) DO UPDATE SET role = excluded.role;`
+	deleteShareQuery     = `DELETE FROM topic_shares WHERE topic_id = $1 AND user_id = $2;`
+	listTopicSharesQuery = `SELECT users.username, topic_shares.role FROM topic_shares
+		JOIN users ON users.id = topic_shares.user_id
+		WHERE topic_shares.topic_id = $1 ORDER BY users.username;`
+	listSharedTopicsQuery = `SELECT owners.username, topics.topic, topic_shares.role FROM topic_shares
+		JOIN topics ON topics.id = topic_shares.topic_id
+		JOIN users AS owners ON owners.id = topics.user_id
+		WHERE topic_shares.user_id = $1 ORDER BY owners.username, topics.topic;`
+	// selectShareRolesQuery finds the shares of the topic and of its ancestors.
+	selectShareRolesQuery = `SELECT topic_shares.role FROM topic_shares
+		JOIN topics ON topics.id = topic_shares.topic_id
+		WHERE topics.user_id = $1 AND topic_shares.user_id = $2
+			AND (topics.topic = $3 OR substr($3, 1, length(topics.topic) + 1) = topics.topic || '/');`
+
+	insertPublicationQuery = `INSERT INTO published_topics (slug, topic_id) VALUES ($1, $2)
+		ON CONFLICT (topic_id) DO NOTHING;`
+	selectPublicationSlugQuery = `SELECT slug FROM published_topics WHERE topic_id = $1;`
+	deletePublicationQuery     = `DELETE FROM published_topics WHERE topic_id = $1;`
+	listPublicationsQuery      = `SELECT topics.topic, published_topics.slug, published_topics.published_at FROM published_topics
+		JOIN topics ON topics.id = published_topics.topic_id
+		WHERE topics.user_id = $1 ORDER BY topics.topic;`
+	selectPublicationQuery = `SELECT users.username, topics.topic, published_topics.slug, published_topics.published_at
+		FROM published_topics
+		JOIN topics ON topics.id = published_topics.topic_id
+		JOIN users ON users.id = topics.user_id
+		WHERE published_topics.slug = $1;`
 )
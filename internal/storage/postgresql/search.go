@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"strings"
+)
+
+// SearchLinks finds links of the user by words of query, ranked with the
+// full-text search vector. Every word is matched as a prefix.
+func (s *Storage) SearchLinks(ctx context.Context, username, query string, limit int) ([]models.Link, error) {
+	const op = "postgresql.SearchLinks"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLinks, storage.ErrUserNotFound
+		}
+
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	terms := storage.SearchTerms(query)
+	if len(terms) == 0 {
+		return emptyLinks, nil
+	}
+
+	// Terms consist of letters and digits only, so they are safe to use in
+	// the tsquery syntax.
+	for i := range terms {
+		terms[i] += ":*"
+	}
+
+	links, err := s.queryLinks(ctx, s.db, searchLinksQuery, userId, strings.Join(terms, " & "), limit)
+	if err != nil {
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/storage"
+)
+
+func (s *Storage) SaveShortURL(ctx context.Context, code, url string) error {
+	const op = "postgresql.SaveShortURL"
+
+	if _, err := s.db.ExecContext(ctx, insertShortURLQuery, code, url); err != nil {
+		if isUniqueViolation(err) {
+			return storage.ErrShortCodeExists
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ShortURL(ctx context.Context, code string) (string, error) {
+	const op = "postgresql.ShortURL"
+
+	var url string
+	if err := s.db.QueryRowContext(ctx, selectShortURLQuery, code).Scan(&url); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrShortCodeNotFound
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return url, nil
+}
+
+func (s *Storage) DeleteShortURL(ctx context.Context, code string) error {
+	const op = "postgresql.DeleteShortURL"
+
+	result, err := s.db.ExecContext(ctx, deleteShortURLQuery, code)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted == 0 {
+		return storage.ErrShortCodeNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+func (s *Storage) TelegramUser(ctx context.Context, telegramID int64, username string) (string, error) {
+	const op = "postgresql.TelegramUser"
+
+	var bound string
+
+	err := s.db.QueryRowContext(ctx, selectTelegramUserQuery, telegramID).Scan(&bound)
+	if err == nil {
+		return bound, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, err := s.telegramUserId(ctx, tx, telegramID, username)
+		if err != nil {
+			return err
+		}
+
+		// Another update of the account may have bound it meanwhile.
+		if _, err := tx.ExecContext(ctx, insertTelegramAccountQuery, telegramID, userId); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, selectTelegramUserQuery, telegramID).Scan(&bound)
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bound, nil
+}
+
+// telegramUserId returns the user an account seen for the first time is
+// bound to. An existing user is only given to it when no other account or
+// API token claims the user yet, otherwise the account gets a user of its
+// own and has to be linked to the existing one with a link code.
+func (s *Storage) telegramUserId(ctx context.Context, tx *sql.Tx, telegramID int64, username string) (uint32, error) {
+	const op = "postgresql.TelegramUserId"
+
+	if username != "" {
+		// The user is locked so that first updates of two accounts cannot
+		// both find it unclaimed.
+		var userId uint32
+		err := tx.QueryRowContext(ctx, lockUserQuery, username).Scan(&userId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err == nil {
+			var claimed bool
+			if err := tx.QueryRowContext(ctx, userClaimedQuery, userId).Scan(&claimed); err != nil {
+				return zeroUserId, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if !claimed {
+				return userId, nil
+			}
+		}
+	}
+
+	return s.upsertUser(ctx, tx, storage.TelegramUsername(telegramID))
+}
+
+func (s *Storage) CreateLinkCode(ctx context.Context, username, codeHash string, expiresAt time.Time) error {
+	const op = "postgresql.CreateLinkCode"
+
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, purgeLinkCodesQuery); err != nil {
+			return err
+		}
+
+		userId, err := s.upsertUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, insertLinkCodeQuery, codeHash, userId, expiresAt.UTC())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UseLinkCode(ctx context.Context, telegramID int64, codeHash string) (string, error) {
+	const op = "postgresql.UseLinkCode"
+
+	var username string
+
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		var userId uint32
+		if err := tx.QueryRowContext(ctx, useLinkCodeQuery, codeHash).Scan(&userId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrLinkCodeNotFound
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, bindTelegramAccountQuery, telegramID, userId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.QueryRowContext(ctx, selectTelegramUserQuery, telegramID).Scan(&username); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return username, nil
+}
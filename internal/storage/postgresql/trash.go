@@ -0,0 +1,299 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+func (s *Storage) ListTrash(ctx context.Context, username string) ([]models.TrashItem, error) {
+	const op = "postgresql.ListTrash"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cursor, err := s.db.QueryContext(ctx, listTrashQuery, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	items := make([]models.TrashItem, 0)
+	for cursor.Next() {
+		var (
+			id        uint32
+			topic     string
+			alias     string
+			data      string
+			deletedAt time.Time
+		)
+		if err := cursor.Scan(&id, &topic, &alias, &data, &deletedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		snapshot, err := storage.DecodeTrashSnapshot(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		items = append(items, snapshot.TrashItem(id, topic, alias, deletedAt))
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return items, nil
+}
+
+// RestoreTopic brings back a deleted topic with its subtopics and links.
+func (s *Storage) RestoreTopic(ctx context.Context, username, topic string) (uint32, error) {
+	const op = "postgresql.RestoreTopic"
+
+	var topicId uint32
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, trashId, snapshot, err := s.findTrash(ctx, tx, username, topic, "")
+		if err != nil {
+			return err
+		}
+
+		if err := snapshot.CheckPaths(topic); err != nil {
+			return err
+		}
+
+		if err := s.insertAncestors(ctx, tx, userId, topic); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, insertTopicQuery, userId, topic).Scan(&topicId); err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrTopicAlreadyExists
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		// Subtopics can't exist without the topic, so they are all missing.
+		for _, subtopic := range snapshot.Topics {
+			if _, err := tx.ExecContext(ctx, insertAncestorQuery, userId, subtopic); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if err := s.restoreLinks(ctx, tx, userId, snapshot.Links); err != nil {
+			return err
+		}
+
+		return s.deleteTrash(ctx, tx, trashId)
+	})
+	if err != nil {
+		return zeroTopicId, err
+	}
+
+	return topicId, nil
+}
+
+// RestoreLink puts a deleted link back to its topic, the topic is created
+// again if it has been deleted since.
+func (s *Storage) RestoreLink(ctx context.Context, username, topic, alias string) error {
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, trashId, snapshot, err := s.findTrash(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		if err := snapshot.CheckPaths(topic); err != nil {
+			return err
+		}
+
+		if err := s.restoreLinks(ctx, tx, userId, snapshot.Links); err != nil {
+			return err
+		}
+
+		return s.deleteTrash(ctx, tx, trashId)
+	})
+}
+
+func (s *Storage) PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const op = "postgresql.PurgeTrash"
+
+	res, err := s.db.ExecContext(ctx, purgeTrashQuery, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	purged, _ := res.RowsAffected()
+
+	return purged, nil
+}
+
+// trashTopic saves topic together with its subtopics and their links to the
+// trash, subtopics is the LIKE pattern of the subtopic paths.
+func (s *Storage) trashTopic(ctx context.Context, q querier, userId uint32, topic, subtopics string) error {
+	const op = "postgresql.TrashTopic"
+
+	cursor, err := q.QueryContext(ctx, listSubtreeQuery, userId, topic, subtopics)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	var snapshot storage.TrashSnapshot
+	for cursor.Next() {
+		var path string
+		if err := cursor.Scan(&path); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		snapshot.Topics = append(snapshot.Topics, path)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	snapshot.Links, err = s.queryLinks(ctx, q, listSubtreeLinksQuery, userId, topic, subtopics)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.insertTrash(ctx, q, userId, topic, "", snapshot)
+}
+
+func (s *Storage) insertTrash(ctx context.Context, q querier, userId uint32, topic, alias string, snapshot storage.TrashSnapshot) error {
+	const op = "postgresql.InsertTrash"
+
+	data, err := snapshot.Encode()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := q.ExecContext(ctx, insertTrashQuery, userId, topic, alias, data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// findTrash returns the latest deleted item of username with topic and alias.
+func (s *Storage) findTrash(ctx context.Context, q querier, username, topic, alias string) (uint32, uint32, storage.TrashSnapshot, error) {
+	const op = "postgresql.FindTrash"
+
+	userId, err := s.findUser(ctx, q, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTrashId, storage.TrashSnapshot{}, storage.ErrUserNotFound
+		}
+
+		return zeroUserId, zeroTrashId, storage.TrashSnapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		trashId uint32
+		data    string
+	)
+	if err := q.QueryRowContext(ctx, selectTrashQuery, userId, topic, alias).Scan(&trashId, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTrashId, storage.TrashSnapshot{}, storage.ErrNotInTrash
+		}
+
+		return zeroUserId, zeroTrashId, storage.TrashSnapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	snapshot, err := storage.DecodeTrashSnapshot(data)
+	if err != nil {
+		return zeroUserId, zeroTrashId, storage.TrashSnapshot{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, trashId, snapshot, nil
+}
+
+func (s *Storage) deleteTrash(ctx context.Context, q querier, trashId uint32) error {
+	const op = "postgresql.DeleteTrash"
+
+	if _, err := q.ExecContext(ctx, deleteTrashQuery, trashId); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// restoreLinks inserts links from a trash snapshot keeping their timestamps
+// and tags, missing topics are created. Short URLs are deleted together with
+// the links, so the restored links are queued to be shortened again.
+func (s *Storage) restoreLinks(ctx context.Context, q querier, userId uint32, links []models.Link) error {
+	const op = "postgresql.RestoreLinks"
+
+	topicIds := make(map[string]uint32)
+	for _, link := range links {
+		topicId, ok := topicIds[link.Topic]
+		if !ok {
+			if err := s.insertAncestors(ctx, q, userId, link.Topic); err != nil {
+				return err
+			}
+
+			if _, err := q.ExecContext(ctx, insertAncestorQuery, userId, link.Topic); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			id, err := s.findTopic(ctx, q, userId, link.Topic)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			topicId = id
+			topicIds[link.Topic] = id
+		}
+
+		// Links deleted before they had codes get new ones.
+		code := link.Code
+		if code == "" {
+			var err error
+			if code, err = storage.NewLinkCode(); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		var linkId uint32
+		err := q.QueryRowContext(
+			ctx, restoreLinkQuery,
+			userId, topicId,
+			link.URL, link.Alias, link.Title, link.Description, link.Note,
+			link.CreatedAt, link.UpdatedAt, code,
+		).Scan(&linkId)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrAliasAlreadyExists
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, tag := range link.Tags {
+			var tagId uint32
+			if err := q.QueryRowContext(ctx, upsertTagQuery, userId, tag).Scan(&tagId); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			if _, err := q.ExecContext(ctx, insertLinkTagQuery, linkId, tagId); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if err := s.insertShortenerTask(ctx, q, models.ShortenerSave, linkId, link.URL, link.Alias); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"github.com/Sleeps17/linker/internal/models"
+	"strings"
+	"unicode"
+)
+
+// Weights of link fields in SearchScore, they follow the A/B/C weights of the
+// postgresql search vector.
+const (
+	aliasWeight = 1.0
+	titleWeight = 1.0
+	urlWeight   = 0.4
+	noteWeight  = 0.2
+)
+
+// SearchTerms splits a search query into lower-cased words.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchScore ranks link against terms for backends without a full-text
+// index. Every term has to occur in at least one field, otherwise the score
+// is zero and the link doesn't match.
+func SearchScore(link models.Link, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	fields := []struct {
+		value  string
+		weight float64
+	}{
+		{strings.ToLower(link.Alias), aliasWeight},
+		{strings.ToLower(link.Title), titleWeight},
+		{strings.ToLower(link.URL), urlWeight},
+		{strings.ToLower(link.Note), noteWeight},
+		{strings.ToLower(link.Description), noteWeight},
+	}
+
+	var score float64
+	for _, term := range terms {
+		var termScore float64
+		for _, field := range fields {
+			if strings.Contains(field.value, term) {
+				termScore += field.weight
+			}
+		}
+
+		if termScore == 0 {
+			return 0
+		}
+
+		score += termScore
+	}
+
+	return score
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"github.com/Sleeps17/linker/internal/models"
+	"strings"
+)
+
+// SharedTopicPrefix starts the paths of topics shared by other users:
+// @<owner>/<topic>. Own topics can't start with it.
+const SharedTopicPrefix = "@"
+
+// SharedTopicPath returns the path topic of owner is addressed by.
+func SharedTopicPath(owner, topic string) string {
+	return SharedTopicPrefix + owner + TopicSeparator + topic
+}
+
+// ParseSharedTopic splits a shared topic path into the owner and the path of
+// the topic, ok is false for own topics.
+func ParseSharedTopic(path string) (owner, topic string, ok bool) {
+	rest, ok := strings.CutPrefix(path, SharedTopicPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	owner, topic, _ = strings.Cut(rest, TopicSeparator)
+
+	return owner, topic, true
+}
+
+// ValidShareRole tells whether a topic can be shared with role.
+func ValidShareRole(role models.ShareRole) bool {
+	return role == models.ShareViewer || role == models.ShareEditor
+}
+
+// RoleAllows tells whether role grants the access required, editors can do
+// whatever viewers can.
+func RoleAllows(role, required models.ShareRole) bool {
+	return role == required || role == models.ShareEditor
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"strings"
+	"unicode/utf8"
+)
+
+// created_at holds timestamps written both by the driver and by
+// CURRENT_TIMESTAMP, julianday compares them regardless of the text format.
+var linkSortColumns = map[models.SortKey]string{
+	models.SortByCreatedAt: "julianday(links.created_at)",
+	models.SortByAlias:     "links.alias",
+	models.SortByURL:       "links.link",
+}
+
+// listQuery accumulates the conditions of a listing query and their arguments.
+type listQuery struct {
+	sql  strings.Builder
+	args []any
+}
+
+func newListQuery(base string, args ...any) *listQuery {
+	q := &listQuery{args: args}
+	q.sql.WriteString(base)
+
+	return q
+}
+
+func (q *listQuery) arg(value any) string {
+	q.args = append(q.args, value)
+	return "?"
+}
+
+func (q *listQuery) where(format string, args ...any) {
+	q.sql.WriteString(" AND ")
+	_, _ = fmt.Fprintf(&q.sql, format, args...)
+}
+
+func (q *listQuery) orderBy(page models.Page, columns ...string) {
+	direction := "ASC"
+	if page.Desc {
+		direction = "DESC"
+	}
+
+	for idx, column := range columns {
+		columns[idx] = column + " " + direction
+	}
+
+	q.sql.WriteString(" ORDER BY " + strings.Join(columns, ", "))
+
+	// One extra row tells whether there is a next page.
+	if page.Limit > 0 {
+		q.sql.WriteString(" LIMIT " + q.arg(page.Limit+1))
+	}
+}
+
+// buildListLinksQuery completes q selecting links into the keyset query of a
+// page, ordered by the sort column with the id as a tie-breaker.
+func buildListLinksQuery(q *listQuery, page models.Page, filter models.LinksFilter) (string, []any, error) {
+	if filter.Query != "" {
+		// LIKE is case-insensitive for ASCII in SQLite.
+		pattern := storage.ContainsPattern(filter.Query)
+		q.where(`(links.alias LIKE %s ESCAPE '\' OR links.link LIKE %s ESCAPE '\' OR links.title LIKE %s ESCAPE '\')`,
+			q.arg(pattern), q.arg(pattern), q.arg(pattern))
+	}
+
+	if filter.Tag != "" {
+		q.where(`EXISTS (SELECT 1 FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+			WHERE lt.link_id = links.id AND t.tag = %s)`, q.arg(filter.Tag))
+	}
+
+	column := linkSortColumns[page.SortBy]
+
+	cursor, ok, err := storage.DecodeCursor(page)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if ok {
+		value := q.arg(cursor.Value)
+		if page.SortBy == models.SortByCreatedAt {
+			value = "julianday(" + value + ")"
+		}
+
+		q.where("(%s, links.id) %s (%s, %s)", column, compareOp(page), value, q.arg(cursor.ID))
+	}
+
+	q.orderBy(page, column, "links.id")
+
+	return q.sql.String(), q.args, nil
+}
+
+// buildListTopicsQuery builds the keyset query of a page of topics. Creation
+// order is the order of ids.
+func buildListTopicsQuery(userId uint32, page models.Page, filter models.TopicsFilter) (string, []any, error) {
+	q := newListQuery(listTopicsQuery, userId)
+
+	if filter.Query != "" {
+		q.where(`topic LIKE %s ESCAPE '\'`, q.arg(storage.ContainsPattern(filter.Query)))
+	}
+
+	// instr is case-sensitive unlike LIKE.
+	prefix := storage.SubtreePrefix(filter.Parent)
+	if prefix != "" {
+		q.where(`instr(topic, %s) = 1`, q.arg(prefix))
+	}
+
+	if filter.Direct {
+		q.where(`instr(substr(topic, %s), '/') = 0`, q.arg(utf8.RuneCountInString(prefix)+1))
+	}
+
+	cursor, ok, err := storage.DecodeCursor(page)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if page.SortBy == models.SortByName {
+		if ok {
+			q.where("(topic, id) %s (%s, %s)", compareOp(page), q.arg(cursor.Value), q.arg(cursor.ID))
+		}
+		q.orderBy(page, "topic", "id")
+	} else {
+		if ok {
+			q.where("id %s %s", compareOp(page), q.arg(cursor.ID))
+		}
+		q.orderBy(page, "id")
+	}
+
+	return q.sql.String(), q.args, nil
+}
+
+func compareOp(page models.Page) string {
+	if page.Desc {
+		return "<"
+	}
+
+	return ">"
+}
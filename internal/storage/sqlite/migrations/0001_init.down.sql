@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "links";
+DROP TABLE IF EXISTS "topics";
+DROP TABLE IF EXISTS "users";
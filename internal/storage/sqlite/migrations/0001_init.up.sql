@@ -0,0 +1,24 @@
+-- Tables may already exist in databases created before versioned migrations.
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "username" TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "topics" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL,
+    "topic" TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE (user_id, topic)
+);
+
+CREATE TABLE IF NOT EXISTS "links" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL,
+    "topic_id" INTEGER NOT NULL,
+    "link" TEXT NOT NULL,
+    "alias" TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (topic_id) REFERENCES topics(id),
+    UNIQUE (user_id, topic_id, alias)
+);
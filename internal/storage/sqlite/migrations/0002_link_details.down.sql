@@ -0,0 +1,5 @@
+ALTER TABLE "links" DROP COLUMN "title";
+ALTER TABLE "links" DROP COLUMN "description";
+ALTER TABLE "links" DROP COLUMN "note";
+ALTER TABLE "links" DROP COLUMN "created_at";
+ALTER TABLE "links" DROP COLUMN "updated_at";
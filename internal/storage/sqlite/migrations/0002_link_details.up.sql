@@ -0,0 +1,9 @@
+-- SQLite can't add columns with a non-constant default, existing rows get the
+-- migration time and new rows set the timestamps explicitly.
+ALTER TABLE "links" ADD COLUMN "title" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "description" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "note" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "created_at" TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';
+ALTER TABLE "links" ADD COLUMN "updated_at" TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00';
+
+UPDATE "links" SET "created_at" = CURRENT_TIMESTAMP, "updated_at" = CURRENT_TIMESTAMP;
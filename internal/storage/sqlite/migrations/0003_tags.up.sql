@@ -0,0 +1,14 @@
+CREATE TABLE "tags" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL REFERENCES users(id),
+    "tag" TEXT NOT NULL,
+    UNIQUE (user_id, tag)
+);
+
+CREATE TABLE "link_tags" (
+    "link_id" INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+    "tag_id" INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (link_id, tag_id)
+);
+
+CREATE INDEX "link_tags_tag_id_idx" ON "link_tags" (tag_id);
@@ -0,0 +1,13 @@
+-- Deleted topics and links are moved here as snapshots, so that the unique
+-- constraints of live topics and links don't account for deleted ones.
+-- Alias is empty for topics.
+CREATE TABLE "trash" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL REFERENCES users(id),
+    "topic" TEXT NOT NULL,
+    "alias" TEXT NOT NULL DEFAULT '',
+    "snapshot" TEXT NOT NULL,
+    "deleted_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX "trash_user_id_idx" ON "trash" (user_id, topic, alias);
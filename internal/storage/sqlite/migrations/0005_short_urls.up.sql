@@ -0,0 +1,7 @@
+-- Short codes of the built-in URL shortener. Codes are shared by all users,
+-- like the ones of an external shortener, so they are not tied to a user.
+CREATE TABLE "short_urls" (
+    "code" TEXT PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    "created_at" TIMESTAMP NOT NULL
+);
@@ -0,0 +1,18 @@
+-- Changes the URL shortener has to follow, written in the transaction of the
+-- link change and applied by the shortener sync worker. Alias is the one to
+-- save for "save" tasks and the code to delete for "delete" ones, link_id is
+-- zero for the latter.
+CREATE TABLE "shortener_outbox" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "kind" TEXT NOT NULL,
+    "link_id" INTEGER NOT NULL DEFAULT 0,
+    "url" TEXT NOT NULL DEFAULT '',
+    "alias" TEXT NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "next_attempt_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX "shortener_outbox_next_attempt_at_idx" ON "shortener_outbox" (next_attempt_at);
+CREATE INDEX "shortener_outbox_link_id_idx" ON "shortener_outbox" (link_id);
@@ -0,0 +1,5 @@
+-- The short URL of a link is kept next to the URL the user saved, together
+-- with the code the shortener deletes it by. Both are empty until the link is
+-- shortened.
+ALTER TABLE "links" ADD COLUMN "short_url" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "links" ADD COLUMN "short_code" TEXT NOT NULL DEFAULT '';
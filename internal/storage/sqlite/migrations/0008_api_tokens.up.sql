@@ -0,0 +1,11 @@
+-- API tokens of the REST API. Only hashes of the tokens are stored, a token
+-- itself is shown once when it is issued.
+CREATE TABLE "api_tokens" (
+    "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+    "user_id" INTEGER NOT NULL REFERENCES users(id),
+    "name" TEXT NOT NULL DEFAULT '',
+    "token_hash" TEXT NOT NULL UNIQUE,
+    "created_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX "api_tokens_user_id_idx" ON "api_tokens" (user_id);
@@ -0,0 +1,14 @@
+-- Telegram accounts are bound to users by their numeric ids, which unlike
+-- Telegram usernames never change.
+CREATE TABLE "telegram_accounts" (
+    "telegram_id" INTEGER PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id),
+    "linked_at" TIMESTAMP NOT NULL
+);
+
+-- One-time codes binding a Telegram account to a user, stored as hashes.
+CREATE TABLE "link_codes" (
+    "code_hash" TEXT PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id),
+    "expires_at" TIMESTAMP NOT NULL
+);
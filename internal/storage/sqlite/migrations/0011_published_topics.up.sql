@@ -0,0 +1,6 @@
+-- Topics readable by anyone who knows the slug, a topic is published once.
+CREATE TABLE "published_topics" (
+    "slug" TEXT PRIMARY KEY,
+    "topic_id" INTEGER NOT NULL UNIQUE REFERENCES topics(id) ON DELETE CASCADE,
+    "published_at" TIMESTAMP NOT NULL
+);
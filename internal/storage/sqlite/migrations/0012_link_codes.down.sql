@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "links_code_idx";
+ALTER TABLE "links" DROP COLUMN "code";
@@ -0,0 +1,5 @@
+-- Links are redirected to from /s/<username>/<code> by a random code stored
+-- with the link, so that the links of a user can't be enumerated.
+ALTER TABLE "links" ADD COLUMN "code" TEXT NOT NULL DEFAULT '';
+UPDATE "links" SET "code" = lower(hex(randomblob(8)));
+CREATE UNIQUE INDEX "links_code_idx" ON "links" ("code");
@@ -0,0 +1,104 @@
+-- Paths starting with "@" address topics shared by other users, so own topics
+-- created before sharing with such paths are renamed: "@work/backend" becomes
+-- "at-work/backend", or "at-work-2/backend" when the user has "at-work", or
+-- "at-work-3/backend" when "at-work-2" is taken too. Descendants share the
+-- first segment of the path, so a subtree is renamed as a whole. Paths in the
+-- trash are renamed the same way.
+CREATE TEMP TABLE "own_topic_roots" AS
+SELECT DISTINCT "user_id", substr("topic", 1, instr("topic" || '/', '/') - 1) AS "root"
+FROM "topics"
+WHERE "topic" LIKE '@%';
+
+-- Every name a renamed root can't take: roots kept as they are and the names
+-- the other renamed roots get without a suffix.
+CREATE TEMP TABLE "taken_topic_roots" AS
+SELECT DISTINCT "user_id", substr("topic", 1, instr("topic" || '/', '/') - 1) AS "root"
+FROM "topics"
+WHERE "topic" NOT LIKE '@%';
+
+INSERT INTO "taken_topic_roots" ("user_id", "root")
+SELECT "user_id", 'at-' || substr("root", 2) FROM "own_topic_roots";
+
+CREATE TEMP TABLE "own_topic_renames" AS
+WITH RECURSIVE "candidates" ("user_id", "root", "n") AS (
+    SELECT "user_id", "root", 2
+    FROM "own_topic_roots" AS "own"
+    WHERE EXISTS (
+        SELECT 1 FROM "topics"
+        WHERE "topics"."user_id" = "own"."user_id"
+          AND substr("topic", 1, instr("topic" || '/', '/') - 1) = 'at-' || substr("own"."root", 2)
+    )
+    UNION ALL
+    SELECT "candidates"."user_id", "candidates"."root", "candidates"."n" + 1
+    FROM "candidates"
+    WHERE EXISTS (
+        SELECT 1 FROM "taken_topic_roots" AS "taken"
+        WHERE "taken"."user_id" = "candidates"."user_id"
+          AND "taken"."root" = 'at-' || substr("candidates"."root", 2) || '-' || "candidates"."n"
+    )
+)
+SELECT "user_id", "root", 'at-' || substr("root", 2) || '-' || max("n") AS "new_root"
+FROM "candidates"
+GROUP BY "user_id", "root";
+
+CREATE TEMP TABLE "own_topic_paths" AS
+SELECT "paths"."user_id", "paths"."path",
+    coalesce("renames"."new_root", 'at-' || substr("paths"."root", 2))
+        || substr("paths"."path", length("paths"."root") + 1) AS "new_path"
+FROM (
+    SELECT DISTINCT "user_id", "path", substr("path", 1, instr("path" || '/', '/') - 1) AS "root"
+    FROM (
+        SELECT "user_id", "topic" AS "path" FROM "topics"
+        UNION
+        SELECT "user_id", "topic" FROM "trash"
+        UNION
+        SELECT "trash"."user_id", "topics"."value"
+        FROM "trash", json_each("trash"."snapshot", '$.topics') AS "topics"
+        UNION
+        SELECT "trash"."user_id", json_extract("links"."value", '$.topic')
+        FROM "trash", json_each("trash"."snapshot", '$.links') AS "links"
+    )
+    WHERE "path" LIKE '@%'
+) AS "paths"
+LEFT JOIN "own_topic_renames" AS "renames"
+    ON "renames"."user_id" = "paths"."user_id" AND "renames"."root" = "paths"."root";
+
+UPDATE "topics"
+SET "topic" = (
+    SELECT "new_path" FROM "own_topic_paths" AS "paths"
+    WHERE "paths"."user_id" = "topics"."user_id" AND "paths"."path" = "topics"."topic"
+)
+WHERE "topic" LIKE '@%';
+
+UPDATE "trash"
+SET "topic" = (
+    SELECT "new_path" FROM "own_topic_paths" AS "paths"
+    WHERE "paths"."user_id" = "trash"."user_id" AND "paths"."path" = "trash"."topic"
+)
+WHERE "topic" LIKE '@%';
+
+UPDATE "trash"
+SET "snapshot" = json_set("snapshot", '$.topics', json((
+    SELECT json_group_array(coalesce((
+        SELECT "new_path" FROM "own_topic_paths" AS "paths"
+        WHERE "paths"."user_id" = "trash"."user_id" AND "paths"."path" = "topics"."value"
+    ), "topics"."value"))
+    FROM json_each("trash"."snapshot", '$.topics') AS "topics"
+)))
+WHERE json_type("snapshot", '$.topics') = 'array';
+
+UPDATE "trash"
+SET "snapshot" = json_set("snapshot", '$.links', json((
+    SELECT json_group_array(json_set("links"."value", '$.topic', coalesce((
+        SELECT "new_path" FROM "own_topic_paths" AS "paths"
+        WHERE "paths"."user_id" = "trash"."user_id"
+          AND "paths"."path" = json_extract("links"."value", '$.topic')
+    ), json_extract("links"."value", '$.topic'))))
+    FROM json_each("trash"."snapshot", '$.links') AS "links"
+)))
+WHERE json_type("snapshot", '$.links') = 'array';
+
+DROP TABLE "own_topic_paths";
+DROP TABLE "own_topic_renames";
+DROP TABLE "taken_topic_roots";
+DROP TABLE "own_topic_roots";
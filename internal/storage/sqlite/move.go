@@ -0,0 +1,219 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/pkg/random"
+	"time"
+)
+
+// RenameTopic changes the path of topic and of all its subtopics, so it also
+// moves subtrees around the hierarchy.
+func (s *Storage) RenameTopic(ctx context.Context, username, topic, newTopic string) error {
+	const op = "sqlite.RenameTopic"
+
+	newTopic, err := storage.CleanTopicPath(newTopic)
+	if err != nil {
+		return err
+	}
+
+	if newTopic != topic && storage.InSubtree(newTopic, topic) {
+		return storage.ErrInvalidTopicPath
+	}
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, _, err := s.findUserTopic(ctx, tx, username, topic)
+		if err != nil {
+			return err
+		}
+
+		if newTopic == topic {
+			return nil
+		}
+
+		subtopics := storage.SubtreePrefix(topic)
+		if _, err := tx.ExecContext(ctx, renameSubtreeQuery, newTopic, topic, userId, topic, subtopics); err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrTopicAlreadyExists
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return s.insertAncestors(ctx, tx, userId, newTopic)
+	})
+}
+
+func (s *Storage) UpdateLink(ctx context.Context, username, topic, alias string, update models.LinkUpdate) (models.Link, error) {
+	const op = "sqlite.UpdateLink"
+
+	var link models.Link
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, topicId, _, err := s.findAccessibleTopic(ctx, tx, username, topic, models.ShareEditor)
+		if err != nil {
+			return err
+		}
+
+		old, err := scanLink(tx.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrAliasNotFound
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.ExecContext(
+			ctx, updateLinkQuery,
+			update.URL, update.Alias, update.Title, update.Description, update.Note,
+			time.Now().UTC(), old.ID,
+		)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return storage.ErrAliasAlreadyExists
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		link, err = scanLink(tx.QueryRowContext(ctx, selectLinkByIdQuery, old.ID))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if link.URL == old.URL {
+			return nil
+		}
+
+		// The short URL of the old URL goes, the new one is shortened anew.
+		if err := s.dropShortURL(ctx, tx, old); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, resetShortURLQuery, link.ID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		link.ShortURL, link.ShortCode = "", ""
+
+		if err := s.insertShortenerTask(ctx, tx, models.ShortenerSave, link.ID, link.URL, link.Alias); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return emptyLink, err
+	}
+
+	return link, nil
+}
+
+func (s *Storage) MoveLink(ctx context.Context, username, topic, alias, toTopic string) (string, error) {
+	const op = "sqlite.MoveLink"
+
+	if topic == toTopic {
+		_, err := s.PickLink(ctx, username, topic, alias)
+		return alias, err
+	}
+
+	var newAlias string
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, linkId, err := s.findLink(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		var topicId uint32
+		topicId, newAlias, err = s.linkTarget(ctx, tx, userId, toTopic, alias)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, moveLinkQuery, topicId, newAlias, time.Now().UTC(), linkId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newAlias, nil
+}
+
+func (s *Storage) CopyLink(ctx context.Context, username, topic, alias, toTopic string) (string, error) {
+	const op = "sqlite.CopyLink"
+
+	var newAlias string
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, linkId, err := s.findLink(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		var topicId uint32
+		topicId, newAlias, err = s.linkTarget(ctx, tx, userId, toTopic, alias)
+		if err != nil {
+			return err
+		}
+
+		code, err := storage.NewLinkCode()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		now := time.Now().UTC()
+
+		var copyId uint32
+		if err := tx.QueryRowContext(ctx, copyLinkQuery, topicId, newAlias, now, now, code, linkId).Scan(&copyId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, copyLinkTagsQuery, copyId, linkId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newAlias, nil
+}
+
+// linkTarget resolves the topic a link is moved or copied to. The link keeps
+// its alias unless the topic already has one like it, then a random alias is
+// picked.
+func (s *Storage) linkTarget(ctx context.Context, q querier, userId uint32, topic, alias string) (uint32, string, error) {
+	const op = "sqlite.LinkTarget"
+
+	topicId, err := s.findTopic(ctx, q, userId, topic)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroTopicId, "", storage.ErrTopicNotFound
+		}
+
+		return zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	for {
+		var linkId uint32
+		err := q.QueryRowContext(ctx, selectLinkIdQuery, userId, topicId, alias).Scan(&linkId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return topicId, alias, nil
+		}
+
+		if err != nil {
+			return zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		alias = random.Alias()
+	}
+}
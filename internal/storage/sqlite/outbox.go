@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"time"
+)
+
+func (s *Storage) PostShortenedLink(ctx context.Context, username, topic string, link models.Link) error {
+	const op = "sqlite.PostShortenedLink"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		linkId, err := s.insertLink(ctx, tx, username, topic, link)
+		if err != nil {
+			return err
+		}
+
+		if err := s.insertShortenerTask(ctx, tx, models.ShortenerSave, linkId, link.URL, link.Alias); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteShortenedLink deletes the link together with its short URL.
+func (s *Storage) DeleteShortenedLink(ctx context.Context, username, topic, alias string) error {
+	const op = "sqlite.DeleteShortenedLink"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		link, err := s.deleteLink(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		if err := s.dropShortURL(ctx, tx, link); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+// ClaimShortenerTasks returns the tasks due now and postpones them by lease,
+// so that the tasks of a worker that died are retried.
+func (s *Storage) ClaimShortenerTasks(ctx context.Context, limit int, lease time.Duration) ([]models.ShortenerTask, error) {
+	const op = "sqlite.ClaimShortenerTasks"
+
+	now := time.Now().UTC()
+
+	rows, err := s.db.QueryContext(ctx, claimShortenerTasksQuery, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tasks []models.ShortenerTask
+	for rows.Next() {
+		var task models.ShortenerTask
+		if err := rows.Scan(&task.ID, &task.Kind, &task.LinkID, &task.URL, &task.Alias, &task.Attempts); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+// CompleteShortenerTask removes the task. A completed save stores shortURL
+// and code with the link, unless the link has been deleted or changed
+// meanwhile, then the short URL is queued for deletion.
+func (s *Storage) CompleteShortenerTask(ctx context.Context, task models.ShortenerTask, shortURL, code string) error {
+	const op = "sqlite.CompleteShortenerTask"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, deleteShortenerTaskQuery, task.ID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if task.Kind != models.ShortenerSave {
+			return nil
+		}
+
+		result, err := tx.ExecContext(ctx, shortenLinkQuery, shortURL, code, task.LinkID, task.URL)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if updated > 0 {
+			return nil
+		}
+
+		if err := s.insertShortenerTask(ctx, tx, models.ShortenerDelete, zeroLinkId, "", code); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) RetryShortenerTask(ctx context.Context, taskID uint32, retryAt time.Time, lastErr string) error {
+	const op = "sqlite.RetryShortenerTask"
+
+	if _, err := s.db.ExecContext(ctx, retryShortenerTaskQuery, retryAt.UTC(), lastErr, taskID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DiscardShortenerTask(ctx context.Context, taskID uint32) error {
+	const op = "sqlite.DiscardShortenerTask"
+
+	if _, err := s.db.ExecContext(ctx, deleteShortenerTaskQuery, taskID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// dropShortURL queues the deletion of the short URL of a link that is deleted
+// or points elsewhere, by its stored code. A link whose shortening is still
+// queued has no short URL yet, the shortening is dropped instead.
+func (s *Storage) dropShortURL(ctx context.Context, tx *sql.Tx, link models.Link) error {
+	if _, err := tx.ExecContext(ctx, deleteLinkShortenerTasksQuery, models.ShortenerSave, link.ID); err != nil {
+		return err
+	}
+
+	if link.ShortCode == "" {
+		return nil
+	}
+
+	return s.insertShortenerTask(ctx, tx, models.ShortenerDelete, zeroLinkId, "", link.ShortCode)
+}
+
+func (s *Storage) insertShortenerTask(ctx context.Context, q querier, kind models.ShortenerTaskKind, linkId uint32, url, alias string) error {
+	now := time.Now().UTC()
+
+	_, err := q.ExecContext(ctx, insertShortenerTaskQuery, kind, linkId, url, alias, now, now)
+
+	return err
+}
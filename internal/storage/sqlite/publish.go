@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+func (s *Storage) PublishTopic(ctx context.Context, username, topic, slug string) (string, error) {
+	const op = "sqlite.PublishTopic"
+
+	var published string
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		_, topicId, err := s.findUserTopic(ctx, tx, username, topic)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, insertPublicationQuery, slug, topicId, time.Now().UTC()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.QueryRowContext(ctx, selectPublicationSlugQuery, topicId).Scan(&published); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return published, nil
+}
+
+func (s *Storage) UnpublishTopic(ctx context.Context, username, topic string) error {
+	const op = "sqlite.UnpublishTopic"
+
+	_, topicId, err := s.findUserTopic(ctx, s.db, username, topic)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, deletePublicationQuery, topicId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted == 0 {
+		return storage.ErrTopicNotPublished
+	}
+
+	return nil
+}
+
+func (s *Storage) ListPublishedTopics(ctx context.Context, username string) ([]models.PublishedTopic, error) {
+	const op = "sqlite.ListPublishedTopics"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cursor, err := s.db.QueryContext(ctx, listPublicationsQuery, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	topics := make([]models.PublishedTopic, 0)
+	for cursor.Next() {
+		topic := models.PublishedTopic{Owner: username}
+		if err := cursor.Scan(&topic.Topic, &topic.Slug, &topic.PublishedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		topics = append(topics, topic)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topics, nil
+}
+
+func (s *Storage) PublishedTopic(ctx context.Context, slug string) (models.PublishedTopic, error) {
+	const op = "sqlite.PublishedTopic"
+
+	var topic models.PublishedTopic
+	err := s.db.QueryRowContext(ctx, selectPublicationQuery, slug).Scan(&topic.Owner, &topic.Topic, &topic.Slug, &topic.PublishedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PublishedTopic{}, storage.ErrTopicNotPublished
+		}
+
+		return models.PublishedTopic{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topic, nil
+}
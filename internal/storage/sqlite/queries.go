@@ -0,0 +1,134 @@
+package sqlite
+
+const (
+	linkColumns = `links.id, links.link, links.short_url, links.short_code, links.code, links.alias, topics.topic,
+		links.title, links.description, links.note, links.created_at, links.updated_at,
+		(SELECT json_group_array(t.tag) FROM link_tags lt JOIN tags t ON t.id = lt.tag_id WHERE lt.link_id = links.id)`
+	linksTable = `links JOIN topics ON topics.id = links.topic_id`
+)
+
+var (
+	selectUserQuery = `SELECT id FROM users WHERE username = ?;`
+	upsertUserQuery = `INSERT INTO users (username) VALUES (?)
+		ON CONFLICT (username) DO UPDATE SET username = excluded.username RETURNING id;`
+
+	insertTopicQuery        = `INSERT INTO topics (user_id, topic) VALUES (?, ?) RETURNING id;`
+	insertAncestorQuery     = `INSERT INTO topics (user_id, topic) VALUES (?, ?) ON CONFLICT (user_id, topic) DO NOTHING;`
+	deleteSubtreeLinksQuery = `DELETE FROM links WHERE topic_id IN
+		(SELECT id FROM topics WHERE user_id = ? AND (topic = ? OR instr(topic, ?) = 1));`
+	deleteSubtopicsQuery = `DELETE FROM topics WHERE user_id = ? AND instr(topic, ?) = 1;`
+	deleteTopicQuery     = `DELETE FROM topics WHERE user_id = ? AND topic = ? RETURNING id;`
+	selectTopicQuery     = `SELECT id FROM topics WHERE user_id = ? AND topic = ?;`
+	listTopicsQuery      = `SELECT id, topic FROM topics WHERE user_id = ?`
+	renameSubtreeQuery   = `UPDATE topics SET topic = ? || substr(topic, length(?) + 1)
+		WHERE user_id = ? AND (topic = ? OR instr(topic, ?) = 1);`
+	listSubtreeQuery = `SELECT topic FROM topics
+		WHERE user_id = ? AND (topic = ? OR instr(topic, ?) = 1) ORDER BY topic;`
+
+	insertLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, created_at, updated_at, code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;`
+	selectLinkQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = ? AND links.topic_id = ? AND links.alias = ?;`
+	selectUserLinkByCodeQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = ? AND links.code = ?;`
+	listLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = ? AND links.topic_id = ?`
+	listSubtreeLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable +
+		` WHERE links.user_id = ? AND (topics.topic = ? OR instr(topics.topic, ?) = 1)`
+	deleteLinkQuery     = `DELETE FROM links WHERE user_id = ? AND topic_id = ? AND alias = ?;`
+	selectLinkIdQuery   = `SELECT id FROM links WHERE user_id = ? AND topic_id = ? AND alias = ?;`
+	selectLinkByIdQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + ` WHERE links.id = ?;`
+	updateLinkQuery     = `UPDATE links SET
+		link = COALESCE(?, link), alias = COALESCE(?, alias), title = COALESCE(?, title),
+		description = COALESCE(?, description), note = COALESCE(?, note), updated_at = ?
+		WHERE id = ?;`
+	moveLinkQuery = `UPDATE links SET topic_id = ?, alias = ?, updated_at = ? WHERE id = ?;`
+	copyLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, created_at, updated_at, code)
+		SELECT user_id, ?, link, ?, title, description, note, ?, ?, ? FROM links WHERE id = ? RETURNING id;`
+	restoreLinkQuery = `INSERT INTO links (user_id, topic_id, link, alias, title, description, note, created_at, updated_at, code)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id;`
+
+	upsertTagQuery = `INSERT INTO tags (user_id, tag) VALUES (?, ?)
+		ON CONFLICT (user_id, tag) DO UPDATE SET tag = excluded.tag RETURNING id;`
+	insertLinkTagQuery = `INSERT INTO link_tags (link_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING;`
+	deleteLinkTagQuery = `DELETE FROM link_tags
+		WHERE link_id = ? AND tag_id = (SELECT id FROM tags WHERE user_id = ? AND tag = ?);`
+	copyLinkTagsQuery   = `INSERT INTO link_tags (link_id, tag_id) SELECT ?, tag_id FROM link_tags WHERE link_id = ?;`
+	listLinksByTagQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + `
+		JOIN link_tags ON link_tags.link_id = links.id
+		JOIN tags ON tags.id = link_tags.tag_id
+		WHERE tags.user_id = ? AND tags.tag = ?;`
+
+	listUserLinksQuery = `SELECT ` + linkColumns + ` FROM ` + linksTable + ` WHERE links.user_id = ?;`
+
+	insertTrashQuery = `INSERT INTO trash (user_id, topic, alias, snapshot, deleted_at) VALUES (?, ?, ?, ?, ?);`
+	listTrashQuery   = `SELECT id, topic, alias, snapshot, deleted_at FROM trash
+		WHERE user_id = ? ORDER BY julianday(deleted_at) DESC, id DESC;`
+	selectTrashQuery = `SELECT id, snapshot FROM trash
+		WHERE user_id = ? AND topic = ? AND alias = ? ORDER BY julianday(deleted_at) DESC, id DESC LIMIT 1;`
+	deleteTrashQuery = `DELETE FROM trash WHERE id = ?;`
+	purgeTrashQuery  = `DELETE FROM trash WHERE julianday(deleted_at) < julianday(?);`
+
+	insertShortURLQuery = `INSERT INTO short_urls (code, url, created_at) VALUES (?, ?, ?);`
+	selectShortURLQuery = `SELECT url FROM short_urls WHERE code = ?;`
+	deleteShortURLQuery = `DELETE FROM short_urls WHERE code = ?;`
+
+	insertShortenerTaskQuery = `INSERT INTO shortener_outbox (kind, link_id, url, alias, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?);`
+	claimShortenerTasksQuery = `UPDATE shortener_outbox SET attempts = attempts + 1, next_attempt_at = ?
+		WHERE id IN (SELECT id FROM shortener_outbox WHERE julianday(next_attempt_at) <= julianday(?)
+			ORDER BY next_attempt_at, id LIMIT ?)
+		RETURNING id, kind, link_id, url, alias, attempts;`
+	retryShortenerTaskQuery       = `UPDATE shortener_outbox SET next_attempt_at = ?, last_error = ? WHERE id = ?;`
+	deleteShortenerTaskQuery      = `DELETE FROM shortener_outbox WHERE id = ?;`
+	deleteLinkShortenerTasksQuery = `DELETE FROM shortener_outbox WHERE kind = ? AND link_id = ?;`
+	shortenLinkQuery              = `UPDATE links SET short_url = ?, short_code = ? WHERE id = ? AND link = ?;`
+	resetShortURLQuery            = `UPDATE links SET short_url = '', short_code = '' WHERE id = ?;`
+
+	insertTokenQuery     = `INSERT INTO api_tokens (user_id, name, token_hash, created_at) VALUES (?, ?, ?, ?) RETURNING id;`
+	listTokensQuery      = `SELECT id, name, created_at FROM api_tokens WHERE user_id = ? ORDER BY id;`
+	deleteTokenQuery     = `DELETE FROM api_tokens WHERE user_id = ? AND id = ?;`
+	selectTokenUserQuery = `SELECT users.username FROM api_tokens JOIN users ON users.id = api_tokens.user_id
+		WHERE api_tokens.token_hash = ?;`
+
+	selectTelegramUserQuery = `SELECT users.username FROM telegram_accounts JOIN users ON users.id = telegram_accounts.user_id
+		WHERE telegram_accounts.telegram_id = ?;`
+	insertTelegramAccountQuery = `INSERT INTO telegram_accounts (telegram_id, user_id, linked_at) VALUES (?, ?, ?)
+		ON CONFLICT (telegram_id) DO NOTHING;`
+	userClaimedQuery = `SELECT EXISTS (SELECT 1 FROM telegram_accounts WHERE user_id = ?)
+		OR EXISTS (SELECT 1 FROM api_tokens WHERE user_id = ?);`
+	bindTelegramAccountQuery = `INSERT INTO telegram_accounts (telegram_id, user_id, linked_at) VALUES (?, ?, ?)
+		ON CONFLICT (telegram_id) DO UPDATE SET user_id = excluded.user_id, linked_at = excluded.linked_at;`
+	insertLinkCodeQuery = `INSERT INTO link_codes (code_hash, user_id, expires_at) VALUES (?, ?, ?);`
+	purgeLinkCodesQuery = `DELETE FROM link_codes WHERE julianday(expires_at) <= julianday(?);`
+	useLinkCodeQuery    = `DELETE FROM link_codes WHERE code_hash = ? AND julianday(expires_at) > julianday(?) RETURNING user_id;`
+
+	upsertShareQuery = `INSERT INTO topic_shares (topic_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (topic_id, user_id) DO UPDATE SET role = excluded.role;`
+	deleteShareQuery     = `DELETE FROM topic_shares WHERE topic_id = ? AND user_id = ?;`
+	listTopicSharesQuery = `SELECT users.username, topic_shares.role FROM topic_shares
+		JOIN users ON users.id = topic_shares.user_id
+		WHERE topic_shares.topic_id = ? ORDER BY users.username;`
+	listSharedTopicsQuery = `SELECT owners.username, topics.topic, topic_shares.role FROM topic_shares
+		JOIN topics ON topics.id = topic_shares.topic_id
+		JOIN users AS owners ON owners.id = topics.user_id
+		WHERE topic_shares.user_id = ? ORDER BY owners.username, topics.topic;`
+	// selectShareRolesQuery finds the shares of the topic and of its ancestors.
+	selectShareRolesQuery = `SELECT topic_shares.role FROM topic_shares
+		JOIN topics ON topics.id = topic_shares.topic_id
+		WHERE topics.user_id = ? AND topic_shares.user_id = ?
+			AND (topics.topic = ? OR substr(?, 1, length(topics.topic) + 1) = topics.topic || '/');`
+
+	insertPublicationQuery = `INSERT INTO published_topics (slug, topic_id, published_at) VALUES (?, ?, ?)
+		ON CONFLICT (topic_id) DO NOTHING;`
+	selectPublicationSlugQuery = `SELECT slug FROM published_topics WHERE topic_id = ?;`
+	deletePublicationQuery     = `DELETE FROM published_topics WHERE topic_id = ?;`
+	listPublicationsQuery      = `SELECT topics.topic, published_topics.slug, published_topics.published_at FROM published_topics
+		JOIN topics ON topics.id = published_topics.topic_id
+		WHERE topics.user_id = ? ORDER BY topics.topic;`
+	selectPublicationQuery = `SELECT users.username, topics.topic, published_topics.slug, published_topics.published_at
+		FROM published_topics
+		JOIN topics ON topics.id = published_topics.topic_id
+		JOIN users ON users.id = topics.user_id
+		WHERE published_topics.slug = ?;`
+)
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"sort"
+)
+
+// SearchLinks finds links of the user by words of query. The bundled SQLite
+// has no full-text index, links of a single user are few enough to be ranked
+// in memory with storage.SearchScore.
+func (s *Storage) SearchLinks(ctx context.Context, username, query string, limit int) ([]models.Link, error) {
+	const op = "sqlite.SearchLinks"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLinks, storage.ErrUserNotFound
+		}
+
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	terms := storage.SearchTerms(query)
+	if len(terms) == 0 {
+		return emptyLinks, nil
+	}
+
+	links, err := s.queryLinks(ctx, s.db, listUserLinksQuery, userId)
+	if err != nil {
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rank(links, terms, limit), nil
+}
+
+func rank(links []models.Link, terms []string, limit int) []models.Link {
+	scores := make(map[uint32]float64, len(links))
+
+	found := make([]models.Link, 0)
+	for _, link := range links {
+		if score := storage.SearchScore(link, terms); score > 0 {
+			scores[link.ID] = score
+			found = append(found, link)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if scores[found[i].ID] != scores[found[j].ID] {
+			return scores[found[i].ID] > scores[found[j].ID]
+		}
+
+		return found[i].ID > found[j].ID
+	})
+
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	return found
+}
@@ -0,0 +1,217 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+func (s *Storage) ShareTopic(ctx context.Context, owner, topic, username string, role models.ShareRole) error {
+	const op = "sqlite.ShareTopic"
+
+	if !storage.ValidShareRole(role) {
+		return storage.ErrInvalidShareRole
+	}
+
+	if owner == username {
+		return storage.ErrShareWithOwner
+	}
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		topicId, userId, err := s.findShare(ctx, tx, owner, topic, username)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, upsertShareQuery, topicId, userId, role, time.Now().UTC()); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) UnshareTopic(ctx context.Context, owner, topic, username string) error {
+	const op = "sqlite.UnshareTopic"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		topicId, userId, err := s.findShare(ctx, tx, owner, topic, username)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, deleteShareQuery, topicId, userId)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if deleted == 0 {
+			return storage.ErrShareNotFound
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) ListTopicShares(ctx context.Context, owner, topic string) ([]models.TopicShare, error) {
+	const op = "sqlite.ListTopicShares"
+
+	_, topicId, err := s.findUserTopic(ctx, s.db, owner, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.db.QueryContext(ctx, listTopicSharesQuery, topicId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	shares := make([]models.TopicShare, 0)
+	for cursor.Next() {
+		var share models.TopicShare
+		if err := cursor.Scan(&share.Username, &share.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		shares = append(shares, share)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return shares, nil
+}
+
+func (s *Storage) ListSharedTopics(ctx context.Context, username string) ([]models.SharedTopic, error) {
+	const op = "sqlite.ListSharedTopics"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cursor, err := s.db.QueryContext(ctx, listSharedTopicsQuery, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	topics := make([]models.SharedTopic, 0)
+	for cursor.Next() {
+		var topic models.SharedTopic
+		if err := cursor.Scan(&topic.Owner, &topic.Topic, &topic.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		topic.Path = storage.SharedTopicPath(topic.Owner, topic.Topic)
+		topics = append(topics, topic)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topics, nil
+}
+
+// findShare resolves a topic of owner and the user it is shared with.
+func (s *Storage) findShare(ctx context.Context, q querier, owner, topic, username string) (uint32, uint32, error) {
+	const op = "sqlite.FindShare"
+
+	_, topicId, err := s.findUserTopic(ctx, q, owner, topic)
+	if err != nil {
+		return zeroTopicId, zeroUserId, err
+	}
+
+	userId, err := s.findUser(ctx, q, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroTopicId, zeroUserId, storage.ErrUserNotFound
+		}
+
+		return zeroTopicId, zeroUserId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topicId, userId, nil
+}
+
+// findAccessibleTopic is findUserTopic for topics that may be shared: it
+// resolves own topics as well as @<owner>/<topic> ones username has the role
+// for, returning the ids of the owner and the topic and the path of the topic
+// at the owner. Topics that aren't shared with username are not found.
+func (s *Storage) findAccessibleTopic(ctx context.Context, q querier, username, topic string, role models.ShareRole) (uint32, uint32, string, error) {
+	const op = "sqlite.FindAccessibleTopic"
+
+	owner, path, shared := storage.ParseSharedTopic(topic)
+	if !shared {
+		owner, path = username, topic
+	}
+
+	if owner == username {
+		userId, topicId, err := s.findUserTopic(ctx, q, username, path)
+		return userId, topicId, path, err
+	}
+
+	userId, err := s.findUser(ctx, q, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTopicId, "", storage.ErrUserNotFound
+		}
+
+		return zeroUserId, zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	ownerId, topicId, err := s.findUserTopic(ctx, q, owner, path)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return zeroUserId, zeroTopicId, "", storage.ErrTopicNotFound
+		}
+
+		return zeroUserId, zeroTopicId, "", err
+	}
+
+	cursor, err := q.QueryContext(ctx, selectShareRolesQuery, ownerId, userId, path, path)
+	if err != nil {
+		return zeroUserId, zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	found, allowed := false, false
+	for cursor.Next() {
+		var granted models.ShareRole
+		if err := cursor.Scan(&granted); err != nil {
+			return zeroUserId, zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		found = true
+		allowed = allowed || storage.RoleAllows(granted, role)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return zeroUserId, zeroTopicId, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case !found:
+		return zeroUserId, zeroTopicId, "", storage.ErrTopicNotFound
+	case !allowed:
+		return zeroUserId, zeroTopicId, "", storage.ErrPermissionDenied
+	}
+
+	return ownerId, topicId, path, nil
+}
@@ -0,0 +1,534 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/storage/migrate"
+	"github.com/mattn/go-sqlite3"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+const (
+	zeroTopicId = 0
+	zeroUserId  = 0
+	zeroLinkId  = 0
+	zeroTrashId = 0
+)
+
+var (
+	emptyTopics = []string{}
+	emptyLinks  = []models.Link{}
+	emptyLink   = models.Link{}
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type Storage struct {
+	db       *sql.DB
+	migrator *migrate.Migrator
+}
+
+// MustNew opens (creating if necessary) the database file at path.
+func MustNew(ctx context.Context, path string) storage.Storage {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+	if err != nil {
+		panic("failed open db: " + err.Error())
+	}
+
+	// SQLite allows a single writer at a time, serialize access on our side
+	// instead of failing with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		panic("failed ping db: " + err.Error())
+	}
+
+	migrationsDir, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic("failed to load migrations: " + err.Error())
+	}
+
+	migrator, err := migrate.New(db, migrate.SQLite, migrationsDir)
+	if err != nil {
+		panic("failed to load migrations: " + err.Error())
+	}
+
+	return &Storage{db: db, migrator: migrator}
+}
+
+func (s *Storage) Close(ctx context.Context) error {
+	return s.db.Close()
+}
+
+func (s *Storage) Migrator() *migrate.Migrator {
+	return s.migrator
+}
+
+func (s *Storage) PostTopic(ctx context.Context, username, topic string) (uint32, error) {
+	const op = "sqlite.PostTopic"
+
+	topic, err := storage.CleanTopicPath(topic)
+	if err != nil {
+		return zeroTopicId, err
+	}
+
+	var topicId uint32
+	err = s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, err := s.upsertUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := s.insertAncestors(ctx, tx, userId, topic); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, insertTopicQuery, userId, topic).Scan(&topicId)
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return zeroTopicId, storage.ErrTopicAlreadyExists
+		}
+
+		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topicId, nil
+}
+
+func (s *Storage) DeleteTopic(ctx context.Context, username, topic string) (uint32, error) {
+	const op = "sqlite.DeleteTopic"
+
+	var topicId uint32
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, _, err := s.findUserTopic(ctx, tx, username, topic)
+		if err != nil {
+			return err
+		}
+
+		// Subtopics go together with the topic.
+		subtopics := storage.SubtreePrefix(topic)
+
+		if err := s.trashTopic(ctx, tx, userId, topic, subtopics); err != nil {
+			return err
+		}
+
+		links, err := s.queryLinks(ctx, tx, listSubtreeLinksQuery, userId, topic, subtopics)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, link := range links {
+			if err := s.dropShortURL(ctx, tx, link); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteSubtreeLinksQuery, userId, topic, subtopics); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, deleteSubtopicsQuery, userId, subtopics); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := tx.QueryRowContext(ctx, deleteTopicQuery, userId, topic).Scan(&topicId); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrTopicNotFound
+			}
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return zeroTopicId, err
+	}
+
+	return topicId, nil
+}
+
+func (s *Storage) ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) ([]string, string, error) {
+	const op = "sqlite.ListTopics"
+
+	page, err := storage.TopicsPage(page)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyTopics, "", storage.ErrUserNotFound
+		}
+
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if filter.Parent != "" {
+		if _, err := s.findTopic(ctx, s.db, userId, filter.Parent); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return emptyTopics, "", storage.ErrTopicNotFound
+			}
+
+			return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	query, args, err := buildListTopicsQuery(userId, page, filter)
+	if err != nil {
+		return emptyTopics, "", err
+	}
+
+	cursor, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	topics := make([]string, 0)
+	ids := make([]uint32, 0)
+
+	var (
+		topicId uint32
+		topic   string
+	)
+	for cursor.Next() {
+		if err := cursor.Scan(&topicId, &topic); err != nil {
+			return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		topics = append(topics, topic)
+		ids = append(ids, topicId)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return emptyTopics, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var next string
+	if page.Limit > 0 && len(topics) > page.Limit {
+		topics = topics[:page.Limit]
+		next = storage.TopicCursor(page, ids[page.Limit-1], topics[page.Limit-1])
+	}
+
+	return topics, next, nil
+}
+
+func (s *Storage) PostLink(ctx context.Context, username, topic string, link models.Link) error {
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.insertLink(ctx, tx, username, topic, link)
+		return err
+	})
+}
+
+// insertLink adds link to topic and returns its id.
+func (s *Storage) insertLink(ctx context.Context, tx *sql.Tx, username, topic string, link models.Link) (uint32, error) {
+	const op = "sqlite.PostLink"
+
+	now := time.Now().UTC()
+
+	userId, topicId, _, err := s.findAccessibleTopic(ctx, tx, username, topic, models.ShareEditor)
+	if err != nil {
+		return zeroLinkId, err
+	}
+
+	code, err := storage.NewLinkCode()
+	if err != nil {
+		return zeroLinkId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var linkId uint32
+	err = tx.QueryRowContext(
+		ctx, insertLinkQuery,
+		userId, topicId,
+		link.URL, link.Alias, link.Title, link.Description, link.Note,
+		now, now, code,
+	).Scan(&linkId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return zeroLinkId, storage.ErrAliasAlreadyExists
+		}
+
+		return zeroLinkId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return linkId, nil
+}
+
+func (s *Storage) PickLink(ctx context.Context, username, topic, alias string) (models.Link, error) {
+	const op = "sqlite.PickLink"
+
+	userId, topicId, _, err := s.findAccessibleTopic(ctx, s.db, username, topic, models.ShareViewer)
+	if err != nil {
+		return emptyLink, err
+	}
+
+	link, err := scanLink(s.db.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLink, storage.ErrAliasNotFound
+		}
+
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+// PickLinkByCode finds a link of username by its code, wherever it is.
+func (s *Storage) PickLinkByCode(ctx context.Context, username, code string) (models.Link, error) {
+	const op = "sqlite.PickLinkByCode"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLink, storage.ErrUserNotFound
+		}
+
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := scanLink(s.db.QueryRowContext(ctx, selectUserLinkByCodeQuery, userId, code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLink, storage.ErrAliasNotFound
+		}
+
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+func (s *Storage) ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) ([]models.Link, string, error) {
+	const op = "sqlite.ListLinks"
+
+	page, err := storage.LinksPage(page)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	userId, topicId, path, err := s.findAccessibleTopic(ctx, s.db, username, topic, models.ShareViewer)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	q := newListQuery(listLinksQuery, userId, topicId)
+	if filter.Recursive {
+		q = newListQuery(listSubtreeLinksQuery, userId, path, storage.SubtreePrefix(path))
+	}
+
+	query, args, err := buildListLinksQuery(q, page, filter)
+	if err != nil {
+		return emptyLinks, "", err
+	}
+
+	links, err := s.queryLinks(ctx, s.db, query, args...)
+	if err != nil {
+		return emptyLinks, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var next string
+	if page.Limit > 0 && len(links) > page.Limit {
+		links = links[:page.Limit]
+		next = storage.LinkCursor(page, links[page.Limit-1])
+	}
+
+	return links, next, nil
+}
+
+func (s *Storage) DeleteLink(ctx context.Context, username, topic, alias string) error {
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.deleteLink(ctx, tx, username, topic, alias)
+		return err
+	})
+}
+
+// deleteLink moves the link to the trash and returns it.
+func (s *Storage) deleteLink(ctx context.Context, tx *sql.Tx, username, topic, alias string) (models.Link, error) {
+	const op = "sqlite.DeleteLink"
+
+	userId, topicId, path, err := s.findAccessibleTopic(ctx, tx, username, topic, models.ShareEditor)
+	if err != nil {
+		return emptyLink, err
+	}
+
+	link, err := scanLink(tx.QueryRowContext(ctx, selectLinkQuery, userId, topicId, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLink, storage.ErrAliasNotFound
+		}
+
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The link goes to the trash of the owner of the topic.
+	snapshot := storage.TrashSnapshot{Links: []models.Link{link}}
+	if err := s.insertTrash(ctx, tx, userId, path, alias, snapshot); err != nil {
+		return emptyLink, err
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteLinkQuery, userId, topicId, alias); err != nil {
+		return emptyLink, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+// insertAncestors creates the missing ancestors of topic.
+func (s *Storage) insertAncestors(ctx context.Context, q querier, userId uint32, topic string) error {
+	const op = "sqlite.InsertAncestors"
+
+	for _, ancestor := range storage.TopicAncestors(topic) {
+		if _, err := q.ExecContext(ctx, insertAncestorQuery, userId, ancestor); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// upsertUser returns the id of username, creating the user if needed.
+func (s *Storage) upsertUser(ctx context.Context, q querier, username string) (uint32, error) {
+	const op = "sqlite.UpsertUser"
+
+	var userId uint32
+	err := q.QueryRowContext(ctx, upsertUserQuery, username).Scan(&userId)
+	if err != nil {
+		return zeroUserId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, nil
+}
+
+func (s *Storage) findUser(ctx context.Context, q querier, username string) (uint32, error) {
+	const op = "sqlite.FindUser"
+
+	var userId uint32
+	if err := q.QueryRowContext(ctx, selectUserQuery, username).Scan(&userId); err != nil {
+		return zeroUserId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, nil
+}
+
+func (s *Storage) findTopic(ctx context.Context, q querier, userId uint32, topic string) (uint32, error) {
+	const op = "sqlite.FindTopic"
+
+	var topicId uint32
+	if err := q.QueryRowContext(ctx, selectTopicQuery, userId, topic).Scan(&topicId); err != nil {
+		return zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return topicId, nil
+}
+
+// findUserTopic resolves username and topic to their ids, translating missing
+// rows into storage errors. Shared topics are refused, methods that accept
+// them use findAccessibleTopic.
+func (s *Storage) findUserTopic(ctx context.Context, q querier, username, topic string) (uint32, uint32, error) {
+	const op = "sqlite.FindUserTopic"
+
+	if _, _, shared := storage.ParseSharedTopic(topic); shared {
+		return zeroUserId, zeroTopicId, storage.ErrPermissionDenied
+	}
+
+	userId, err := s.findUser(ctx, q, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTopicId, storage.ErrUserNotFound
+		}
+
+		return zeroUserId, zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	topicId, err := s.findTopic(ctx, q, userId, topic)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroTopicId, storage.ErrTopicNotFound
+		}
+
+		return zeroUserId, zeroTopicId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, topicId, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLink(row scanner) (models.Link, error) {
+	var link models.Link
+	var tags string
+
+	err := row.Scan(
+		&link.ID,
+		&link.URL,
+		&link.ShortURL,
+		&link.ShortCode,
+		&link.Code,
+		&link.Alias,
+		&link.Topic,
+		&link.Title,
+		&link.Description,
+		&link.Note,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+		&tags,
+	)
+	if err != nil {
+		return link, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &link.Tags); err != nil {
+		return link, err
+	}
+	sort.Strings(link.Tags)
+
+	return link, nil
+}
+
+// queryLinks runs a query selecting linkColumns and collects the result.
+func (s *Storage) queryLinks(ctx context.Context, q querier, query string, args ...any) ([]models.Link, error) {
+	cursor, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close() }()
+
+	links := make([]models.Link, 0)
+	for cursor.Next() {
+		link, err := scanLink(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// isUniqueViolation reports a duplicate key, text primary keys report their
+// own constraint.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
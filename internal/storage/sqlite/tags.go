@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+)
+
+func (s *Storage) TagLink(ctx context.Context, username, topic, alias, tag string) error {
+	const op = "sqlite.TagLink"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, linkId, err := s.findLink(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		var tagId uint32
+		if err := tx.QueryRowContext(ctx, upsertTagQuery, userId, tag).Scan(&tagId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, insertLinkTagQuery, linkId, tagId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) UntagLink(ctx context.Context, username, topic, alias, tag string) error {
+	const op = "sqlite.UntagLink"
+
+	return s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, linkId, err := s.findLink(ctx, tx, username, topic, alias)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, deleteLinkTagQuery, linkId, userId, tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		affectedRowsCount, _ := res.RowsAffected()
+		if affectedRowsCount == 0 {
+			return storage.ErrTagNotFound
+		}
+
+		return nil
+	})
+}
+
+func (s *Storage) ListLinksByTag(ctx context.Context, username, tag string) ([]models.Link, error) {
+	const op = "sqlite.ListLinksByTag"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return emptyLinks, storage.ErrUserNotFound
+		}
+
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	links, err := s.queryLinks(ctx, s.db, listLinksByTagQuery, userId, tag)
+	if err != nil {
+		return emptyLinks, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// findLink resolves a link addressed by username, topic and alias to the ids
+// of its owner and itself.
+func (s *Storage) findLink(ctx context.Context, q querier, username, topic, alias string) (uint32, uint32, error) {
+	const op = "sqlite.FindLink"
+
+	userId, topicId, err := s.findUserTopic(ctx, q, username, topic)
+	if err != nil {
+		return zeroUserId, zeroLinkId, err
+	}
+
+	var linkId uint32
+	if err := q.QueryRowContext(ctx, selectLinkIdQuery, userId, topicId, alias).Scan(&linkId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return zeroUserId, zeroLinkId, storage.ErrAliasNotFound
+		}
+
+		return zeroUserId, zeroLinkId, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, linkId, nil
+}
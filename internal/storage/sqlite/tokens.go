@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"time"
+)
+
+func (s *Storage) CreateToken(ctx context.Context, username, name, tokenHash string) (models.APIToken, error) {
+	const op = "sqlite.CreateToken"
+
+	token := models.APIToken{Name: name, CreatedAt: time.Now().UTC()}
+
+	err := s.WithTx(ctx, func(tx *sql.Tx) error {
+		userId, err := s.upsertUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, insertTokenQuery, userId, name, tokenHash, token.CreatedAt).Scan(&token.ID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return models.APIToken{}, err
+	}
+
+	return token, nil
+}
+
+func (s *Storage) ListTokens(ctx context.Context, username string) ([]models.APIToken, error) {
+	const op = "sqlite.ListTokens"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	cursor, err := s.db.QueryContext(ctx, listTokensQuery, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = cursor.Close() }()
+
+	tokens := make([]models.APIToken, 0)
+	for cursor.Next() {
+		var token models.APIToken
+		if err := cursor.Scan(&token.ID, &token.Name, &token.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+func (s *Storage) RevokeToken(ctx context.Context, username string, tokenID uint32) error {
+	const op = "sqlite.RevokeToken"
+
+	userId, err := s.findUser(ctx, s.db, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrUserNotFound
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := s.db.ExecContext(ctx, deleteTokenQuery, userId, tokenID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if deleted == 0 {
+		return storage.ErrTokenNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) TokenUser(ctx context.Context, tokenHash string) (string, error) {
+	const op = "sqlite.TokenUser"
+
+	var username string
+	if err := s.db.QueryRowContext(ctx, selectTokenUserQuery, tokenHash).Scan(&username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrTokenNotFound
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return username, nil
+}
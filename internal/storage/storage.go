@@ -3,29 +3,128 @@ package storage
 import (
 	"context"
 	"errors"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage/migrate"
+	"time"
 )
 
 type Storage interface {
 	PostTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
 	DeleteTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
-	ListTopics(ctx context.Context, username string) (topics []string, err error)
+	ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) (topics []string, next string, err error)
+	RenameTopic(ctx context.Context, username, topic, newTopic string) (err error)
 
-	PostLink(ctx context.Context, username, topic, link, alias string) (err error)
-	PickLink(ctx context.Context, username, topic, alias string) (link string, err error)
+	PostLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	PickLink(ctx context.Context, username, topic, alias string) (link models.Link, err error)
+	PickLinkByCode(ctx context.Context, username, code string) (link models.Link, err error)
 	DeleteLink(ctx context.Context, username, topic, alias string) (err error)
-	ListLinks(ctx context.Context, username, topic string) (links []string, aliases []string, err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
+	UpdateLink(ctx context.Context, username, topic, alias string, update models.LinkUpdate) (link models.Link, err error)
+	MoveLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
+	CopyLink(ctx context.Context, username, topic, alias, toTopic string) (newAlias string, err error)
+
+	TagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	UntagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	ListLinksByTag(ctx context.Context, username, tag string) (links []models.Link, err error)
+
+	SearchLinks(ctx context.Context, username, query string, limit int) (links []models.Link, err error)
+
+	// Deleted topics and links are kept in the trash until they are restored
+	// or purged. Restore brings back the latest deleted item with the path.
+	ListTrash(ctx context.Context, username string) (items []models.TrashItem, err error)
+	RestoreTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
+	RestoreLink(ctx context.Context, username, topic, alias string) (err error)
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (purged int64, err error)
+
+	// Short codes of the built-in URL shortener, shared by all users.
+	SaveShortURL(ctx context.Context, code, url string) (err error)
+	ShortURL(ctx context.Context, code string) (url string, err error)
+	DeleteShortURL(ctx context.Context, code string) (err error)
+
+	// PostShortenedLink and DeleteShortenedLink queue the shortening of the
+	// link or the deletion of its short URL in the same transaction as the
+	// link change, PostLink and DeleteLink leave the shortener alone. A link
+	// whose URL UpdateLink changes and the links of a deleted topic lose
+	// their short URLs the same way. The sync worker claims due tasks, leasing them for lease,
+	// and either completes or reschedules them.
+	PostShortenedLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	DeleteShortenedLink(ctx context.Context, username, topic, alias string) (err error)
+	ClaimShortenerTasks(ctx context.Context, limit int, lease time.Duration) (tasks []models.ShortenerTask, err error)
+	CompleteShortenerTask(ctx context.Context, task models.ShortenerTask, shortURL, code string) (err error)
+	RetryShortenerTask(ctx context.Context, taskID uint32, retryAt time.Time, lastErr string) (err error)
+	DiscardShortenerTask(ctx context.Context, taskID uint32) (err error)
+
+	// API tokens are stored as hashes. Creating a token creates its user if
+	// needed, TokenUser finds the owner of a token by its hash.
+	CreateToken(ctx context.Context, username, name, tokenHash string) (token models.APIToken, err error)
+	ListTokens(ctx context.Context, username string) (tokens []models.APIToken, err error)
+	RevokeToken(ctx context.Context, username string, tokenID uint32) (err error)
+	TokenUser(ctx context.Context, tokenHash string) (username string, err error)
+
+	// Telegram accounts are bound to users by their numeric ids. TelegramUser
+	// binds an account seen for the first time to the existing user username
+	// if no other account or API token claims it, to TelegramUsername
+	// otherwise. Link codes are stored as hashes, UseLinkCode spends an
+	// unexpired code and rebinds the account to the user of the code.
+	TelegramUser(ctx context.Context, telegramID int64, username string) (boundUsername string, err error)
+	CreateLinkCode(ctx context.Context, username, codeHash string, expiresAt time.Time) (err error)
+	UseLinkCode(ctx context.Context, telegramID int64, codeHash string) (username string, err error)
+
+	// Topics are shared with other users together with their subtopics. A
+	// shared topic is addressed as @<owner>/<topic>: PostLink, PickLink,
+	// ListLinks, UpdateLink and DeleteLink accept such paths as far as the role
+	// allows, the other methods work with own topics only and fail with
+	// ErrPermissionDenied. Sharing a topic again changes the role.
+	ShareTopic(ctx context.Context, owner, topic, username string, role models.ShareRole) (err error)
+	UnshareTopic(ctx context.Context, owner, topic, username string) (err error)
+	ListTopicShares(ctx context.Context, owner, topic string) (shares []models.TopicShare, err error)
+	ListSharedTopics(ctx context.Context, username string) (topics []models.SharedTopic, err error)
+
+	// Published topics are readable by anyone who knows the slug. Publishing a
+	// published topic keeps its slug, which is returned, PublishedTopic finds
+	// the topic and its owner by the slug.
+	PublishTopic(ctx context.Context, username, topic, slug string) (publishedSlug string, err error)
+	UnpublishTopic(ctx context.Context, username, topic string) (err error)
+	ListPublishedTopics(ctx context.Context, username string) (topics []models.PublishedTopic, err error)
+	PublishedTopic(ctx context.Context, slug string) (topic models.PublishedTopic, err error)
 
 	Close(ctx context.Context) error
 }
 
+// Migratable is implemented by backends with a versioned SQL schema.
+type Migratable interface {
+	Migrator() *migrate.Migrator
+}
+
 var (
 	ErrTopicAlreadyExists = errors.New("topic already exists")
 	ErrTopicNotFound      = errors.New("topic not found")
+	ErrInvalidTopicPath   = errors.New("invalid topic path")
 
 	ErrUserNotFound = errors.New("username not found")
 
 	ErrAliasNotFound      = errors.New("alias not found")
 	ErrAliasAlreadyExists = errors.New("alias already exists")
 
+	ErrTagNotFound = errors.New("tag not found")
+
+	ErrInvalidPage = errors.New("invalid page request")
+
+	ErrNotInTrash = errors.New("not found in trash")
+
+	ErrShortCodeExists   = errors.New("short code already exists")
+	ErrShortCodeNotFound = errors.New("short code not found")
+
+	ErrTokenNotFound = errors.New("token not found")
+
+	ErrLinkCodeNotFound = errors.New("link code not found or expired")
+
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrShareNotFound    = errors.New("topic is not shared with the user")
+	ErrInvalidShareRole = errors.New("invalid share role")
+	ErrShareWithOwner   = errors.New("topic can't be shared with its owner")
+
+	ErrTopicNotPublished = errors.New("topic is not published")
+
 	ErrRecordNotFound = errors.New("alias not found")
 )
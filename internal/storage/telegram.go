@@ -0,0 +1,9 @@
+package storage
+
+import "fmt"
+
+// TelegramUsername is the name of the user a Telegram account is bound to
+// when no existing user can be given to it.
+func TelegramUsername(telegramID int64) string {
+	return fmt.Sprintf("tg%d", telegramID)
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"strings"
+)
+
+// TopicSeparator separates the segments of a topic path such as
+// work/backend/postgres. A topic is the parent of the topics whose paths
+// continue its own.
+const TopicSeparator = "/"
+
+// CleanTopicPath normalizes a topic path given by a user: separators around
+// the path and spaces around segments are dropped. Paths with empty segments
+// are rejected, as are paths looking like shared ones.
+func CleanTopicPath(path string) (string, error) {
+	path = strings.Trim(strings.TrimSpace(path), TopicSeparator)
+	if path == "" || strings.HasPrefix(path, SharedTopicPrefix) {
+		return "", ErrInvalidTopicPath
+	}
+
+	segments := strings.Split(path, TopicSeparator)
+	for idx, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			return "", ErrInvalidTopicPath
+		}
+
+		segments[idx] = segment
+	}
+
+	return strings.Join(segments, TopicSeparator), nil
+}
+
+// CleanTopicParent is CleanTopicPath allowing an empty path, which stands for
+// the root of the hierarchy.
+func CleanTopicParent(path string) (string, error) {
+	if strings.Trim(strings.TrimSpace(path), TopicSeparator) == "" {
+		return "", nil
+	}
+
+	return CleanTopicPath(path)
+}
+
+// TopicAncestors returns the paths of the ancestors of path, outermost first.
+func TopicAncestors(path string) []string {
+	ancestors := make([]string, 0)
+	for idx := range path {
+		if strings.HasPrefix(path[idx:], TopicSeparator) {
+			ancestors = append(ancestors, path[:idx])
+		}
+	}
+
+	return ancestors
+}
+
+// SubtreePrefix returns the prefix shared by the paths of all descendants of
+// path, the empty path is the root and prefixes everything.
+func SubtreePrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return path + TopicSeparator
+}
+
+// InSubtree tells whether path is root or one of its descendants.
+func InSubtree(path, root string) bool {
+	return path == root || strings.HasPrefix(path, SubtreePrefix(root))
+}
+
+// TopicBase returns the last segment of path.
+func TopicBase(path string) string {
+	return path[strings.LastIndex(path, TopicSeparator)+1:]
+}
+
+// MoveTopicPath returns the path topic gets when it's moved under parent, the
+// empty parent moves it to the root.
+func MoveTopicPath(topic, parent string) string {
+	return SubtreePrefix(parent) + TopicBase(topic)
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"encoding/json"
+	"github.com/Sleeps17/linker/internal/models"
+	"time"
+)
+
+// TrashSnapshot is what the trash keeps of a deleted item to restore it: the
+// paths of a deleted topic and its subtopics and the links of these topics.
+// A deleted link is a snapshot with the link alone.
+type TrashSnapshot struct {
+	Topics []string      `json:"topics,omitempty"`
+	Links  []models.Link `json:"links"`
+}
+
+func (s TrashSnapshot) Encode() (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func DecodeTrashSnapshot(data string) (TrashSnapshot, error) {
+	var snapshot TrashSnapshot
+	err := json.Unmarshal([]byte(data), &snapshot)
+
+	return snapshot, err
+}
+
+// CheckPaths returns ErrInvalidTopicPath when topic or a path in the snapshot
+// isn't clean, such as a path of a shared topic left from before own topics
+// were renamed. Restoring it would create an own topic under such a path.
+func (s TrashSnapshot) CheckPaths(topic string) error {
+	paths := append([]string{topic}, s.Topics...)
+	for _, l := range s.Links {
+		paths = append(paths, l.Topic)
+	}
+
+	for _, path := range paths {
+		if clean, err := CleanTopicPath(path); err != nil || clean != path {
+			return ErrInvalidTopicPath
+		}
+	}
+
+	return nil
+}
+
+// TrashItem describes the snapshot of an item of the trash.
+func (s TrashSnapshot) TrashItem(id uint32, topic, alias string, deletedAt time.Time) models.TrashItem {
+	item := models.TrashItem{
+		ID:        id,
+		Topic:     topic,
+		Alias:     alias,
+		Links:     len(s.Links),
+		DeletedAt: deletedAt,
+	}
+
+	if len(s.Topics) > 0 {
+		item.Subtopics = len(s.Topics) - 1
+	}
+
+	return item
+}
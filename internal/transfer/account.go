@@ -0,0 +1,307 @@
+package transfer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format is the file format of an account export.
+type Format string
+
+const (
+	// FormatJSON keeps everything an account has, it is the format to move
+	// accounts between instances with.
+	FormatJSON Format = "json"
+	// FormatCSV is a flat table of links for spreadsheets, a row without a
+	// URL keeps an empty topic.
+	FormatCSV Format = "csv"
+)
+
+// Policy tells an import what to do with a link whose alias is already taken
+// in its topic by a link with another URL.
+type Policy string
+
+const (
+	// PolicySkip keeps the saved link.
+	PolicySkip Policy = "skip"
+	// PolicyOverwrite replaces the saved link with the imported one.
+	PolicyOverwrite Policy = "overwrite"
+	// PolicyRename saves the imported link under a numbered alias.
+	PolicyRename Policy = "rename"
+)
+
+func (p Policy) valid() bool {
+	return p == PolicySkip || p == PolicyOverwrite || p == PolicyRename
+}
+
+// AccountVersion is the version of the JSON export format. Imports accept
+// files of this version and older.
+const AccountVersion = 1
+
+var (
+	ErrUnsupportedFormat  = errors.New("unsupported export format")
+	ErrUnsupportedPolicy  = errors.New("unsupported conflict policy")
+	ErrUnsupportedVersion = errors.New("unsupported export version")
+	ErrInvalidArchive     = errors.New("invalid export file")
+)
+
+// accountFile is the JSON export of an account.
+type accountFile struct {
+	Version    int           `json:"version"`
+	Username   string        `json:"username"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Topics     []string      `json:"topics"`
+	Links      []accountLink `json:"links"`
+}
+
+// accountLink is a link of an export. The timestamps are informational, the
+// imported links are created anew.
+type accountLink struct {
+	Topic       string    `json:"topic"`
+	Alias       string    `json:"alias"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var csvHeader = []string{"topic", "alias", "url", "title", "description", "note", "tags", "created_at", "updated_at"}
+
+// ExportAccount writes all topics and links of username in format, JSON by
+// default.
+func (s *Service) ExportAccount(ctx context.Context, username string, format Format, w io.Writer) error {
+	if format == "" {
+		format = FormatJSON
+	}
+
+	if format != FormatJSON && format != FormatCSV {
+		return ErrUnsupportedFormat
+	}
+
+	archive, err := s.Export(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	return WriteAccount(w, format, username, archive)
+}
+
+// ImportAccount reads an export in format and merges it into the topics and
+// links of username. The format defaults to JSON and the policy to skipping
+// conflicting links.
+func (s *Service) ImportAccount(ctx context.Context, username string, format Format, policy Policy, r io.Reader) (models.ImportResult, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+
+	if policy == "" {
+		policy = PolicySkip
+	}
+
+	if !policy.valid() {
+		return models.ImportResult{}, ErrUnsupportedPolicy
+	}
+
+	archive, err := ReadAccount(r, format)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	return s.Import(ctx, username, archive, policy)
+}
+
+// WriteAccount writes archive, the export of username, in format.
+func WriteAccount(w io.Writer, format Format, username string, archive Archive) error {
+	switch format {
+	case FormatJSON:
+		file := accountFile{
+			Version:    AccountVersion,
+			Username:   username,
+			ExportedAt: time.Now().UTC(),
+			Topics:     archive.Topics,
+			Links:      make([]accountLink, 0, len(archive.Links)),
+		}
+		if file.Topics == nil {
+			file.Topics = []string{}
+		}
+
+		for _, link := range archive.Links {
+			file.Links = append(file.Links, accountLink{
+				Topic:       link.Topic,
+				Alias:       link.Alias,
+				URL:         link.URL,
+				Title:       link.Title,
+				Description: link.Description,
+				Note:        link.Note,
+				Tags:        link.Tags,
+				CreatedAt:   link.CreatedAt.UTC(),
+				UpdatedAt:   link.UpdatedAt.UTC(),
+			})
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(file)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(csvHeader)
+
+		withLinks := make(map[string]bool)
+		for _, link := range archive.Links {
+			withLinks[link.Topic] = true
+		}
+
+		for _, topic := range archive.Topics {
+			if !withLinks[topic] {
+				_ = cw.Write([]string{topic, "", "", "", "", "", "", "", ""})
+			}
+		}
+
+		for _, link := range archive.Links {
+			_ = cw.Write([]string{
+				link.Topic,
+				link.Alias,
+				link.URL,
+				link.Title,
+				link.Description,
+				link.Note,
+				strings.Join(link.Tags, " "),
+				link.CreatedAt.UTC().Format(time.RFC3339),
+				link.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		cw.Flush()
+		return cw.Error()
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// ReadAccount reads an export in format, checking its topic paths and links.
+func ReadAccount(r io.Reader, format Format) (Archive, error) {
+	var (
+		archive Archive
+		err     error
+	)
+
+	switch format {
+	case FormatJSON:
+		archive, err = readAccountJSON(r)
+	case FormatCSV:
+		archive, err = readAccountCSV(r)
+	default:
+		return Archive{}, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return Archive{}, err
+	}
+
+	for idx, topic := range archive.Topics {
+		if archive.Topics[idx], err = storage.CleanTopicPath(topic); err != nil {
+			return Archive{}, fmt.Errorf("%w: topic %q", ErrInvalidArchive, topic)
+		}
+	}
+
+	for idx, link := range archive.Links {
+		if archive.Links[idx].Topic, err = storage.CleanTopicPath(link.Topic); err != nil {
+			return Archive{}, fmt.Errorf("%w: topic %q", ErrInvalidArchive, link.Topic)
+		}
+
+		if link.URL == "" {
+			return Archive{}, fmt.Errorf("%w: link %q without url", ErrInvalidArchive, link.Alias)
+		}
+	}
+
+	return archive, nil
+}
+
+func readAccountJSON(r io.Reader) (Archive, error) {
+	var file accountFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return Archive{}, fmt.Errorf("%w: %s", ErrInvalidArchive, err.Error())
+	}
+
+	if file.Version < 1 || file.Version > AccountVersion {
+		return Archive{}, ErrUnsupportedVersion
+	}
+
+	archive := Archive{Topics: file.Topics}
+	for _, link := range file.Links {
+		archive.Links = append(archive.Links, models.Link{
+			URL:         link.URL,
+			Alias:       link.Alias,
+			Topic:       link.Topic,
+			Title:       link.Title,
+			Description: link.Description,
+			Note:        link.Note,
+			Tags:        link.Tags,
+			CreatedAt:   link.CreatedAt,
+			UpdatedAt:   link.UpdatedAt,
+		})
+	}
+
+	return archive, nil
+}
+
+func readAccountCSV(r io.Reader) (Archive, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	header, err := cr.Read()
+	if err != nil || !slices.Equal(header, csvHeader) {
+		return Archive{}, fmt.Errorf("%w: the header must be %s", ErrInvalidArchive, strings.Join(csvHeader, ","))
+	}
+
+	var archive Archive
+	seen := make(map[string]bool)
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return archive, nil
+		}
+		if err != nil {
+			return Archive{}, fmt.Errorf("%w: %s", ErrInvalidArchive, err.Error())
+		}
+
+		topic := record[0]
+		if !seen[topic] {
+			seen[topic] = true
+			archive.Topics = append(archive.Topics, topic)
+		}
+
+		if record[1] == "" && record[2] == "" {
+			continue
+		}
+
+		// Timestamps are informational, unreadable ones are left out.
+		createdAt, _ := time.Parse(time.RFC3339, record[7])
+		updatedAt, _ := time.Parse(time.RFC3339, record[8])
+
+		archive.Links = append(archive.Links, models.Link{
+			Topic:       topic,
+			Alias:       record[1],
+			URL:         record[2],
+			Title:       record[3],
+			Description: record[4],
+			Note:        record[5],
+			Tags:        strings.Fields(record[6]),
+			CreatedAt:   createdAt,
+			UpdatedAt:   updatedAt,
+		})
+	}
+}
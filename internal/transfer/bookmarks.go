@@ -0,0 +1,355 @@
+package transfer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Bookmarks outside of any folder are imported to this topic when no parent
+// topic is given.
+const defaultBookmarksTopic = "bookmarks"
+
+const maxSlugLength = 32
+
+var ErrNoBookmarks = errors.New("no bookmarks found")
+
+// ParseBookmarks reads a bookmark file in the Netscape format browsers export
+// to. Folders become topics under parent, bookmarks become links: the keyword
+// of a bookmark is its alias, the name is its title and the alias too when it
+// has no keyword. Folder names and aliases are reduced to lowercase words
+// joined by dashes to be usable as command arguments.
+func ParseBookmarks(r io.Reader, parent string) (Archive, error) {
+	p := &bookmarksParser{
+		parent: parent,
+		topics: make(map[string]bool),
+		last:   -1,
+	}
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if !errors.Is(z.Err(), io.EOF) {
+				return Archive{}, z.Err()
+			}
+
+			p.endDescription()
+			if len(p.archive.Links) == 0 && len(p.archive.Topics) == 0 {
+				return Archive{}, ErrNoBookmarks
+			}
+
+			return p.archive, nil
+		case html.StartTagToken:
+			p.start(z.Token())
+		case html.EndTagToken:
+			p.end(z.Token())
+		case html.TextToken:
+			if p.inside != 0 {
+				p.text.Write(z.Text())
+			}
+		}
+	}
+}
+
+type bookmarksParser struct {
+	parent  string
+	archive Archive
+	topics  map[string]bool
+
+	// folders are the names of the folders around the current one, opened
+	// tells which of the nested lists started a folder.
+	folders []string
+	opened  []bool
+	// heading is the name of the folder whose list comes next.
+	heading *string
+
+	// last is the index of the last link, a description may follow it.
+	last    int
+	current models.Link
+
+	// inside is the element whose text is collected.
+	inside atom.Atom
+	text   strings.Builder
+}
+
+func (p *bookmarksParser) start(token html.Token) {
+	switch token.DataAtom {
+	case atom.H3:
+		p.endDescription()
+		p.collect(atom.H3)
+		p.last = -1
+	case atom.A:
+		p.endDescription()
+		p.collect(atom.A)
+		p.current = bookmark(token)
+	case atom.Dd:
+		if p.last >= 0 {
+			p.collect(atom.Dd)
+		}
+	case atom.Dt:
+		p.endDescription()
+	case atom.Dl:
+		p.endDescription()
+		p.opened = append(p.opened, p.heading != nil)
+		if p.heading != nil {
+			p.folders = append(p.folders, *p.heading)
+			p.heading = nil
+			p.addTopic(p.topic())
+		}
+	}
+}
+
+func (p *bookmarksParser) end(token html.Token) {
+	switch token.DataAtom {
+	case atom.H3:
+		if p.inside == atom.H3 {
+			name := slug(p.text.String())
+			if name == "" {
+				name = "untitled"
+			}
+
+			p.heading = &name
+			p.inside = 0
+		}
+	case atom.A:
+		if p.inside == atom.A {
+			p.addLink()
+			p.inside = 0
+		}
+	case atom.Dl:
+		p.endDescription()
+		if len(p.opened) == 0 {
+			return
+		}
+
+		if p.opened[len(p.opened)-1] {
+			p.folders = p.folders[:len(p.folders)-1]
+		}
+		p.opened = p.opened[:len(p.opened)-1]
+	}
+}
+
+func (p *bookmarksParser) collect(element atom.Atom) {
+	p.inside = element
+	p.text.Reset()
+}
+
+// endDescription ends the description of the last link, it has no closing tag.
+func (p *bookmarksParser) endDescription() {
+	if p.inside != atom.Dd {
+		return
+	}
+
+	p.archive.Links[p.last].Description = strings.TrimSpace(p.text.String())
+	p.inside = 0
+}
+
+func (p *bookmarksParser) addLink() {
+	link := p.current
+
+	// Only web pages can be opened from a link, browsers also keep
+	// javascript: bookmarklets and their own place: queries.
+	if u, err := url.Parse(link.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		p.last = -1
+		return
+	}
+
+	title := strings.TrimSpace(p.text.String())
+	if link.Alias == "" {
+		link.Alias = slug(title)
+	}
+	if title != link.Alias {
+		link.Title = title
+	}
+
+	link.Topic = p.topic()
+	p.addTopic(link.Topic)
+
+	p.archive.Links = append(p.archive.Links, link)
+	p.last = len(p.archive.Links) - 1
+}
+
+func (p *bookmarksParser) addTopic(topic string) {
+	if !p.topics[topic] {
+		p.topics[topic] = true
+		p.archive.Topics = append(p.archive.Topics, topic)
+	}
+}
+
+// topic returns the path of the current folder.
+func (p *bookmarksParser) topic() string {
+	path := strings.Join(p.folders, storage.TopicSeparator)
+
+	switch {
+	case path == "" && p.parent == "":
+		return defaultBookmarksTopic
+	case path == "":
+		return p.parent
+	default:
+		return storage.SubtreePrefix(p.parent) + path
+	}
+}
+
+// bookmark reads the attributes of a bookmark anchor.
+func bookmark(token html.Token) models.Link {
+	var link models.Link
+
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "href":
+			link.URL = strings.TrimSpace(attr.Val)
+		case "shortcuturl":
+			link.Alias = slug(attr.Val)
+		case "tags":
+			for _, tag := range strings.Split(attr.Val, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" && !strings.ContainsFunc(tag, unicode.IsSpace) {
+					link.Tags = append(link.Tags, tag)
+				}
+			}
+		case "add_date":
+			link.CreatedAt = unixTime(attr.Val)
+		case "last_modified":
+			link.UpdatedAt = unixTime(attr.Val)
+		}
+	}
+
+	return link
+}
+
+func unixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0).UTC()
+}
+
+// slug turns a name into lowercase words joined by dashes.
+func slug(name string) string {
+	var b strings.Builder
+
+	dash := false
+	length := 0
+	for _, r := range strings.ToLower(name) {
+		if length == maxSlugLength {
+			break
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteRune('-')
+				length++
+			}
+
+			b.WriteRune(r)
+			length++
+			dash = false
+			continue
+		}
+
+		dash = true
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// WriteBookmarks writes archive in the Netscape bookmark format, topics become
+// nested folders and aliases become bookmark keywords.
+func WriteBookmarks(w io.Writer, archive Archive) error {
+	root := &folder{}
+	folders := map[string]*folder{"": root}
+
+	var find func(path string) *folder
+	find = func(path string) *folder {
+		if f, ok := folders[path]; ok {
+			return f
+		}
+
+		parentPath := ""
+		if idx := strings.LastIndex(path, storage.TopicSeparator); idx >= 0 {
+			parentPath = path[:idx]
+		}
+
+		parent := find(parentPath)
+		f := &folder{name: storage.TopicBase(path)}
+		parent.folders = append(parent.folders, f)
+		folders[path] = f
+
+		return f
+	}
+
+	for _, topic := range archive.Topics {
+		find(topic)
+	}
+
+	for _, link := range archive.Links {
+		f := find(link.Topic)
+		f.links = append(f.links, link)
+	}
+
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprint(bw, bookmarksHeader)
+	root.write(bw, 0)
+
+	return bw.Flush()
+}
+
+const bookmarksHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+`
+
+type folder struct {
+	name    string
+	folders []*folder
+	links   []models.Link
+}
+
+func (f *folder) write(w *bufio.Writer, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	_, _ = fmt.Fprintf(w, "%s<DL><p>\n", indent)
+
+	for _, sub := range f.folders {
+		_, _ = fmt.Fprintf(w, "%s    <DT><H3>%s</H3>\n", indent, html.EscapeString(sub.name))
+		sub.write(w, depth+1)
+	}
+
+	for _, link := range f.links {
+		title := link.Title
+		if title == "" {
+			title = link.Alias
+		}
+
+		_, _ = fmt.Fprintf(
+			w, `%s    <DT><A HREF="%s" ADD_DATE="%d" LAST_MODIFIED="%d" SHORTCUTURL="%s"`,
+			indent, html.EscapeString(link.URL), link.CreatedAt.Unix(), link.UpdatedAt.Unix(), html.EscapeString(link.Alias),
+		)
+		if len(link.Tags) > 0 {
+			_, _ = fmt.Fprintf(w, ` TAGS="%s"`, html.EscapeString(strings.Join(link.Tags, ",")))
+		}
+		_, _ = fmt.Fprintf(w, ">%s</A>\n", html.EscapeString(title))
+
+		if link.Description != "" {
+			_, _ = fmt.Fprintf(w, "%s    <DD>%s\n", indent, html.EscapeString(link.Description))
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "%s</DL><p>\n", indent)
+}
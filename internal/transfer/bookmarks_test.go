@@ -0,0 +1,172 @@
+package transfer_test
+
+import (
+	"bytes"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/transfer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBookmarksRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+
+	archive := transfer.Archive{
+		Topics: []string{"dev", "dev/go", "news"},
+		Links: []models.Link{
+			{
+				URL:         "https://go.dev/doc/",
+				Alias:       "go-docs",
+				Topic:       "dev/go",
+				Title:       "Go <docs> & \"more\"",
+				Description: "The official documentation",
+				Tags:        []string{"go", "docs"},
+				CreatedAt:   createdAt,
+				UpdatedAt:   updatedAt,
+			},
+			{
+				URL:       "https://example.com/?a=1&b=2",
+				Alias:     "example",
+				Topic:     "dev",
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			},
+			{
+				URL:       "https://news.ycombinator.com/",
+				Alias:     "hn",
+				Topic:     "news",
+				Title:     "Hacker News",
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, transfer.WriteBookmarks(&buf, archive))
+
+	parsed, err := transfer.ParseBookmarks(&buf, "")
+	require.NoError(t, err)
+	assert.Equal(t, archive.Topics, parsed.Topics)
+	assert.ElementsMatch(t, archive.Links, parsed.Links)
+}
+
+func TestBookmarksRoundTripUnderParent(t *testing.T) {
+	archive := transfer.Archive{
+		Topics: []string{"dev"},
+		Links: []models.Link{
+			{URL: "https://go.dev/", Alias: "go", Topic: "dev", CreatedAt: time.Unix(0, 0).UTC(), UpdatedAt: time.Unix(0, 0).UTC()},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, transfer.WriteBookmarks(&buf, archive))
+
+	parsed, err := transfer.ParseBookmarks(&buf, "imported")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"imported/dev"}, parsed.Topics)
+	require.Len(t, parsed.Links, 1)
+	assert.Equal(t, "imported/dev", parsed.Links[0].Topic)
+}
+
+func TestParseBookmarks(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		topics []string
+		links  []models.Link
+	}{
+		{
+			name:   "bookmarks outside of folders",
+			input:  `<DL><p><DT><A HREF="https://go.dev/">The Go Site</A></DL>`,
+			topics: []string{"bookmarks"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "the-go-site", Title: "The Go Site", Topic: "bookmarks"},
+			},
+		},
+		{
+			name: "folder names and keywords become slugs",
+			input: `<DL><p><DT><H3>My Folder!</H3><DL><p>
+				<DT><A HREF="https://go.dev/" SHORTCUTURL="Go Home">Go</A>
+			</DL></DL>`,
+			topics: []string{"my-folder"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "go-home", Title: "Go", Topic: "my-folder"},
+			},
+		},
+		{
+			name: "only web pages are imported",
+			input: `<DL><p>
+				<DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+				<DD>Dropped with its bookmarklet
+				<DT><A HREF="place:sort=8">Recent</A>
+				<DT><A HREF="https://go.dev/">go</A>
+			</DL>`,
+			topics: []string{"bookmarks"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "go", Topic: "bookmarks"},
+			},
+		},
+		{
+			name:   "tags with spaces and bad dates are skipped",
+			input:  `<DL><p><DT><A HREF="https://go.dev/" TAGS="go, two words,,lang" ADD_DATE="yesterday">go</A></DL>`,
+			topics: []string{"bookmarks"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "go", Topic: "bookmarks", Tags: []string{"go", "lang"}},
+			},
+		},
+		{
+			name:   "unclosed anchor",
+			input:  `<DL><p><DT><H3>Dev</H3><DL><p><DT><A HREF="https://go.dev/">Go`,
+			topics: []string{"dev"},
+		},
+		{
+			name: "unbalanced lists",
+			input: `</DL></DL><DL><p><DT><H3>Dev</H3><DL><p>
+				<DT><A HREF="https://go.dev/">go</A>
+			</DL></DL></DL>
+			<DT><A HREF="https://example.com/">example</A>`,
+			topics: []string{"dev", "bookmarks"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "go", Topic: "dev"},
+				{URL: "https://example.com/", Alias: "example", Topic: "bookmarks"},
+			},
+		},
+		{
+			name: "description without a bookmark",
+			input: `<DL><p><DD>Nothing to describe
+				<DT><A HREF="https://go.dev/">go</A><DD> Go site </DL>`,
+			topics: []string{"bookmarks"},
+			links: []models.Link{
+				{URL: "https://go.dev/", Alias: "go", Topic: "bookmarks", Description: "Go site"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := transfer.ParseBookmarks(strings.NewReader(tt.input), "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.topics, archive.Topics)
+			assert.Equal(t, tt.links, archive.Links)
+		})
+	}
+}
+
+func TestParseBookmarksRejectsFilesWithoutBookmarks(t *testing.T) {
+	inputs := []string{
+		"",
+		"not a bookmark file",
+		`<html><body><p>Hello</p></body></html>`,
+		`<DL><p><DT><A HREF="javascript:alert(1)">Bookmarklet</A></DL>`,
+	}
+
+	for _, input := range inputs {
+		_, err := transfer.ParseBookmarks(strings.NewReader(input), "")
+		assert.ErrorIs(t, err, transfer.ErrNoBookmarks, "input %q", input)
+	}
+}
@@ -0,0 +1,280 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/pkg/random"
+	"io"
+	"slices"
+)
+
+// Store is the part of the storage imports and exports go through.
+type Store interface {
+	PostTopic(ctx context.Context, username, topic string) (topicID uint32, err error)
+	ListTopics(ctx context.Context, username string, page models.Page, filter models.TopicsFilter) (topics []string, next string, err error)
+	PostShortenedLink(ctx context.Context, username, topic string, link models.Link) (err error)
+	ListLinks(ctx context.Context, username, topic string, page models.Page, filter models.LinksFilter) (links []models.Link, next string, err error)
+	UpdateLink(ctx context.Context, username, topic, alias string, update models.LinkUpdate) (link models.Link, err error)
+	TagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+	UntagLink(ctx context.Context, username, topic, alias, tag string) (err error)
+}
+
+// Archive is a copy of topics and links independent of the file format. Links
+// refer to their topics by path.
+type Archive struct {
+	Topics []string
+	Links  []models.Link
+}
+
+// Service moves topics and links of users in and out of the storage in bulk.
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Export collects all topics of username with their links.
+func (s *Service) Export(ctx context.Context, username string) (Archive, error) {
+	const op = "transfer.Export"
+
+	// Sorted by path, so parents precede their subtopics.
+	topics, _, err := s.store.ListTopics(ctx, username, models.Page{SortBy: models.SortByName}, models.TopicsFilter{})
+	if err != nil {
+		return Archive{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	archive := Archive{Topics: topics}
+	for _, topic := range topics {
+		links, _, err := s.store.ListLinks(ctx, username, topic, models.Page{}, models.LinksFilter{})
+		if err != nil {
+			return Archive{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		archive.Links = append(archive.Links, links...)
+	}
+
+	return archive, nil
+}
+
+// Import adds the topics and links of archive to the ones of username. A link
+// whose alias is taken in its topic is handled according to policy. Links
+// already saved in their topics are skipped, so importing the same archive
+// again changes nothing.
+func (s *Service) Import(ctx context.Context, username string, archive Archive, policy Policy) (models.ImportResult, error) {
+	const op = "transfer.Import"
+
+	var result models.ImportResult
+
+	if !policy.valid() {
+		return result, ErrUnsupportedPolicy
+	}
+
+	for _, topic := range archive.Topics {
+		if _, err := s.store.PostTopic(ctx, username, topic); err != nil {
+			if errors.Is(err, storage.ErrTopicAlreadyExists) {
+				continue
+			}
+
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		result.Topics++
+	}
+
+	saved := make(map[string]*topicLinks)
+	for _, link := range archive.Links {
+		known, ok := saved[link.Topic]
+		if !ok {
+			var err error
+			if known, err = s.topicLinks(ctx, username, link.Topic, &result); err != nil {
+				return result, fmt.Errorf("%s: %w", op, err)
+			}
+
+			saved[link.Topic] = known
+		}
+
+		existing, taken := known.aliases[link.Alias]
+
+		switch {
+		case taken && existing.URL == link.URL && policy != PolicyOverwrite,
+			!taken && known.urls[link.URL] && (link.Alias == "" || policy == PolicyRename),
+			taken && policy == PolicySkip,
+			taken && policy == PolicyRename && known.urls[link.URL]:
+			result.Skipped++
+			continue
+		case taken && policy == PolicyOverwrite:
+			updated, err := s.overwrite(ctx, username, existing, link)
+			if err != nil {
+				return result, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if !updated {
+				result.Skipped++
+				continue
+			}
+
+			known.add(link)
+			result.Updated++
+			continue
+		}
+
+		link.Alias = known.freeAlias(link.Alias)
+		if err := s.store.PostShortenedLink(ctx, username, link.Topic, link); err != nil {
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, tag := range link.Tags {
+			if err := s.store.TagLink(ctx, username, link.Topic, link.Alias, tag); err != nil {
+				return result, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		known.add(link)
+		result.Links++
+	}
+
+	return result, nil
+}
+
+// overwrite replaces the saved link existing with link, updated is false when
+// they are already the same.
+func (s *Service) overwrite(ctx context.Context, username string, existing, link models.Link) (updated bool, err error) {
+	if sameLink(existing, link) {
+		return false, nil
+	}
+
+	_, err = s.store.UpdateLink(ctx, username, link.Topic, link.Alias, models.LinkUpdate{
+		URL:         &link.URL,
+		Title:       &link.Title,
+		Description: &link.Description,
+		Note:        &link.Note,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, tag := range existing.Tags {
+		if !slices.Contains(link.Tags, tag) {
+			if err := s.store.UntagLink(ctx, username, link.Topic, link.Alias, tag); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	for _, tag := range link.Tags {
+		if !slices.Contains(existing.Tags, tag) {
+			if err := s.store.TagLink(ctx, username, link.Topic, link.Alias, tag); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func sameLink(a, b models.Link) bool {
+	if a.URL != b.URL || a.Title != b.Title || a.Description != b.Description || a.Note != b.Note {
+		return false
+	}
+
+	if len(a.Tags) != len(b.Tags) {
+		return false
+	}
+
+	for _, tag := range a.Tags {
+		if !slices.Contains(b.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ImportBookmarks imports a Netscape bookmark file under the topic parent, the
+// empty parent imports to the top level.
+func (s *Service) ImportBookmarks(ctx context.Context, username, parent string, r io.Reader) (models.ImportResult, error) {
+	parent, err := storage.CleanTopicParent(parent)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	archive, err := ParseBookmarks(r, parent)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	// Bookmarks have no aliases of their own, so a taken one is numbered.
+	return s.Import(ctx, username, archive, PolicyRename)
+}
+
+// ExportBookmarks writes all topics and links of username as a Netscape
+// bookmark file.
+func (s *Service) ExportBookmarks(ctx context.Context, username string, w io.Writer) error {
+	archive, err := s.Export(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	return WriteBookmarks(w, archive)
+}
+
+// topicLinks are the URLs and aliases taken in a topic.
+type topicLinks struct {
+	urls    map[string]bool
+	aliases map[string]models.Link
+}
+
+// topicLinks loads the links saved in topic, creating the topic if the archive
+// doesn't list it.
+func (s *Service) topicLinks(ctx context.Context, username, topic string, result *models.ImportResult) (*topicLinks, error) {
+	known := &topicLinks{
+		urls:    make(map[string]bool),
+		aliases: make(map[string]models.Link),
+	}
+
+	links, _, err := s.store.ListLinks(ctx, username, topic, models.Page{}, models.LinksFilter{})
+	if err != nil {
+		if !errors.Is(err, storage.ErrTopicNotFound) && !errors.Is(err, storage.ErrUserNotFound) {
+			return nil, err
+		}
+
+		if _, err := s.store.PostTopic(ctx, username, topic); err != nil {
+			return nil, err
+		}
+		result.Topics++
+	}
+
+	for _, link := range links {
+		known.add(link)
+	}
+
+	return known, nil
+}
+
+func (t *topicLinks) add(link models.Link) {
+	t.urls[link.URL] = true
+	t.aliases[link.Alias] = link
+}
+
+func (t *topicLinks) taken(alias string) bool {
+	_, ok := t.aliases[alias]
+	return ok
+}
+
+func (t *topicLinks) freeAlias(alias string) string {
+	if alias == "" {
+		alias = random.Alias()
+	}
+
+	candidate := alias
+	for idx := 2; t.taken(candidate); idx++ {
+		candidate = fmt.Sprintf("%s-%d", alias, idx)
+	}
+
+	return candidate
+}
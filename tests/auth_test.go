@@ -0,0 +1,323 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/grpc/linker"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/tests/suite"
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestRequiresToken(t *testing.T) {
+	_, st := suite.New(t)
+
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodGet, "/topics", "", nil, nil)
+		return err == nil && status == http.StatusUnauthorized
+	}, syncTimeout, syncTick)
+
+	status, err := restRequest(st, http.MethodGet, "/topics", "lnk_unknown", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestRestMetricsRequireToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	status, err := restRequest(st, http.MethodGet, "/debug/vars", "", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	token, _, err := st.Tokens.IssueToken(ctx, generateUsername(), "test")
+	require.NoError(t, err)
+
+	var vars map[string]any
+	status, err = restRequest(st, http.MethodGet, "/debug/vars", token, nil, &vars)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, vars, "memstats")
+}
+
+func TestRestActsAsTokenUser(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	topic := gofakeit.Word()
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	// A username in the body is ignored, the token tells whose topic it is.
+	body := map[string]string{"username": generateUsername(), "topic": topic}
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodPost, "/topics", token, body, nil)
+		return err == nil && status == http.StatusOK
+	}, syncTimeout, syncTick)
+
+	resp, err := st.LinkerClient.ListTopics(ctx, &linkerV2.ListTopicsRequest{Username: username})
+	require.NoError(t, err)
+	assert.Equal(t, []string{topic}, resp.GetTopics())
+
+	var topics models.ListTopicsResponse
+	status, err := restRequest(st, http.MethodGet, "/topics", token, nil, &topics)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{topic}, topics.Topics)
+}
+
+func TestRestRevokesToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+
+	token, info, err := st.Tokens.IssueToken(ctx, username, "cli")
+	require.NoError(t, err)
+
+	var created models.CreateTokenResponse
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodPost, "/tokens", token, models.CreateTokenRequest{Name: "laptop"}, &created)
+		return err == nil && status == http.StatusOK
+	}, syncTimeout, syncTick)
+	assert.NotEqual(t, token, created.Token)
+	assert.Equal(t, "laptop", created.Info.Name)
+
+	var list models.ListTokensResponse
+	status, err := restRequest(st, http.MethodGet, "/tokens", created.Token, nil, &list)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list.Tokens, 2)
+	assert.Equal(t, info.ID, list.Tokens[0].ID)
+	assert.Equal(t, created.Info.ID, list.Tokens[1].ID)
+
+	status, err = restRequest(st, http.MethodDelete, fmt.Sprintf("/tokens/%d", info.ID), created.Token, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	status, err = restRequest(st, http.MethodGet, "/tokens", token, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, err = restRequest(st, http.MethodDelete, fmt.Sprintf("/tokens/%d", info.ID), created.Token, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRestLinksTelegramAccount(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	telegramID := gofakeit.Int64()
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	// The account talked to the bot before it was linked. A user with tokens
+	// is never given to it by username.
+	bound, err := st.Tokens.TelegramUser(ctx, telegramID, username)
+	require.NoError(t, err)
+	assert.Equal(t, storage.TelegramUsername(telegramID), bound)
+
+	var code models.LinkCodeResponse
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodPost, "/telegram/code", token, nil, &code)
+		return err == nil && status == http.StatusOK
+	}, syncTimeout, syncTick)
+	require.NotEmpty(t, code.Code)
+	assert.True(t, code.ExpiresAt.After(time.Now()))
+
+	linked, err := st.Tokens.LinkTelegram(ctx, telegramID, strings.ToLower(code.Code))
+	require.NoError(t, err)
+	assert.Equal(t, username, linked)
+
+	bound, err = st.Tokens.TelegramUser(ctx, telegramID, username)
+	require.NoError(t, err)
+	assert.Equal(t, username, bound)
+
+	// Codes are one-time.
+	_, err = st.Tokens.LinkTelegram(ctx, gofakeit.Int64(), code.Code)
+	require.ErrorIs(t, err, storage.ErrLinkCodeNotFound)
+}
+
+func TestTelegramUserRefusesClaimedUsername(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	// A link code creates the user without tokens or bound accounts, like the
+	// users of the bot before accounts were bound.
+	username := generateUsername()
+	_, _, err := st.Tokens.IssueLinkCode(ctx, username)
+	require.NoError(t, err)
+
+	first := gofakeit.Int64()
+	bound, err := st.Tokens.TelegramUser(ctx, first, username)
+	require.NoError(t, err)
+	assert.Equal(t, username, bound)
+
+	second := gofakeit.Int64()
+	bound, err = st.Tokens.TelegramUser(ctx, second, username)
+	require.NoError(t, err)
+	assert.Equal(t, storage.TelegramUsername(second), bound)
+
+	bound, err = st.Tokens.TelegramUser(ctx, first, "")
+	require.NoError(t, err)
+	assert.Equal(t, username, bound)
+}
+
+func TestLinkerRejectsInvalidToken(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", suite.Bearer("lnk_unknown"))
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+		Username: generateUsername(),
+		Topic:    gofakeit.Word(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestLinkerRejectsOtherUsersRequests(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	owner := generateUsername()
+	topic := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+		Username: owner,
+		Topic:    topic,
+	})
+	require.NoError(t, err)
+
+	token, _, err := st.Tokens.IssueToken(ctx, generateUsername(), "test")
+	require.NoError(t, err)
+
+	// The token of another user can't act for the owner.
+	intruderCtx := metadata.AppendToOutgoingContext(ctx, "authorization", suite.Bearer(token))
+
+	_, err = st.LinkerClient.ListTopics(intruderCtx, &linkerV2.ListTopicsRequest{Username: owner})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.LinkerClient.DeleteTopic(intruderCtx, &linkerV2.DeleteTopicRequest{
+		Username: owner,
+		Topic:    topic,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.TransferClient.ExportAccount(intruderCtx, wrapperspb.String(owner))
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.TrashClient.ListTrash(intruderCtx, wrapperspb.String(owner))
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.TransferClient.ImportAccount(
+		metadata.AppendToOutgoingContext(intruderCtx, "username", owner),
+		wrapperspb.Bytes([]byte(`{"version": 1}`)),
+	)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := st.LinkerClient.ListTopics(ctx, &linkerV2.ListTopicsRequest{Username: owner})
+	require.NoError(t, err)
+	assert.Equal(t, []string{topic}, resp.GetTopics())
+}
+
+func TestLinkerRefusesMethodsWithoutUsername(t *testing.T) {
+	// Unknown services never reach the interceptors of a server, so they are
+	// called directly with a method none of the services has.
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", suite.Bearer("lnk_test")))
+	authenticator := staticAuthenticator(generateUsername())
+	called := false
+
+	_, err := linker.AuthUnaryInterceptor(authenticator)(ctx, wrapperspb.String(string(authenticator)),
+		&grpc.UnaryServerInfo{FullMethod: "/linker.Hidden/Call"},
+		func(context.Context, any) (any, error) {
+			called = true
+			return nil, nil
+		})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	err = linker.AuthStreamInterceptor(authenticator)(nil, &incomingStream{ctx: ctx},
+		&grpc.StreamServerInfo{FullMethod: "/linker.Hidden/Stream"},
+		func(any, grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	assert.False(t, called)
+}
+
+// staticAuthenticator accepts every token as the one of its user.
+type staticAuthenticator string
+
+func (a staticAuthenticator) Authenticate(context.Context, string) (string, error) {
+	return string(a), nil
+}
+
+// incomingStream is a server stream with the incoming metadata of ctx.
+type incomingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *incomingStream) Context() context.Context {
+	return s.ctx
+}
+
+// restRequest calls the REST server with token, sending body and decoding the
+// response into out when they are set.
+func restRequest(st *suite.Suite, method, path, token string, body, out any) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, st.RestURL+path, &buf)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", suite.Bearer(token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, err
+		}
+	}
+
+	return resp.StatusCode, nil
+}
@@ -1,11 +1,19 @@
 package tests
 
 import (
+	"context"
+	"fmt"
 	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
 	"github.com/Sleeps17/linker/tests/suite"
 	"github.com/brianvoe/gofakeit"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sync"
 	"testing"
 )
 
@@ -171,6 +179,282 @@ func TestLinkerPickLink(t *testing.T) {
 	}
 }
 
+func TestLinkerConcurrentPostTopicForNewUser(t *testing.T) {
+	// The memory storage locks a mutex, the race of user upserts is only
+	// seen by the SQL backends.
+	for _, driver := range []string{"memory", "sqlite"} {
+		t.Run(driver, func(t *testing.T) {
+			ctx, st := suite.NewWithDriver(t, driver)
+
+			const topicsCount = 10
+
+			username := generateUsername()
+
+			var wg sync.WaitGroup
+			errs := make(chan error, topicsCount)
+
+			for i := 0; i < topicsCount; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+						Username: username,
+						Topic:    fmt.Sprintf("topic-%d", i),
+					})
+					errs <- err
+				}(i)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				require.NoError(t, err)
+			}
+
+			resp, err := st.LinkerClient.ListTopics(ctx, &linkerV2.ListTopicsRequest{Username: username})
+			require.NoError(t, err)
+			assert.Len(t, resp.GetTopics(), topicsCount)
+		})
+	}
+}
+
+func TestLinkerListLinksPages(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	const linksCount = 5
+
+	username := generateUsername()
+	topic := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: topic})
+	require.NoError(t, err)
+
+	for i := 0; i < linksCount; i++ {
+		_, err := st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+			Username: username,
+			Topic:    topic,
+			Link:     fmt.Sprintf("https://example.com/%d", i),
+			Alias:    fmt.Sprintf("alias-%d", linksCount-i),
+		})
+		require.NoError(t, err)
+	}
+
+	var (
+		aliases []string
+		cursor  string
+	)
+	for {
+		reqCtx := metadata.AppendToOutgoingContext(ctx, "page-size", "2", "sort-by", "alias")
+		if cursor != "" {
+			reqCtx = metadata.AppendToOutgoingContext(reqCtx, "page-cursor", cursor)
+		}
+
+		var header metadata.MD
+		resp, err := st.LinkerClient.ListLinks(reqCtx, &linkerV2.ListLinksRequest{
+			Username: username,
+			Topic:    topic,
+		}, grpc.Header(&header))
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(resp.GetAliases()), 2)
+
+		aliases = append(aliases, resp.GetAliases()...)
+
+		next := header.Get("next-cursor")
+		if len(next) == 0 {
+			break
+		}
+		cursor = next[0]
+	}
+
+	assert.Equal(t, []string{"alias-1", "alias-2", "alias-3", "alias-4", "alias-5"}, aliases)
+}
+
+func TestLinkerNestedTopics(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	root := gofakeit.Word()
+	topic := root + "/backend/postgres"
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: topic})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Link:     "https://www.postgresql.org/docs/",
+		Alias:    "docs",
+	})
+	require.NoError(t, err)
+
+	reqCtx := metadata.AppendToOutgoingContext(ctx, "sort-by", "name")
+	topics, err := st.LinkerClient.ListTopics(reqCtx, &linkerV2.ListTopicsRequest{Username: username})
+	require.NoError(t, err)
+	assert.Equal(t, []string{root, root + "/backend", topic}, topics.GetTopics())
+
+	reqCtx = metadata.AppendToOutgoingContext(ctx, "topic-parent", root, "topic-direct", "true")
+	topics, err = st.LinkerClient.ListTopics(reqCtx, &linkerV2.ListTopicsRequest{Username: username})
+	require.NoError(t, err)
+	assert.Equal(t, []string{root + "/backend"}, topics.GetTopics())
+
+	reqCtx = metadata.AppendToOutgoingContext(ctx, "recursive", "true")
+	links, err := st.LinkerClient.ListLinks(reqCtx, &linkerV2.ListLinksRequest{Username: username, Topic: root})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs"}, links.GetAliases())
+
+	_, err = st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: root + "//x"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.LinkerClient.DeleteTopic(ctx, &linkerV2.DeleteTopicRequest{Username: username, Topic: root})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.ListLinks(ctx, &linkerV2.ListLinksRequest{Username: username, Topic: topic})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLinkerTrashAndRestore(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic := fillTrash(ctx, t, st)
+
+	_, err := st.TrashClient.RestoreLink(ctx, &linkerV2.DeleteLinkRequest{Username: username, Topic: topic + "/sub", Alias: "missing"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.TrashClient.RestoreTopic(ctx, &linkerV2.DeleteTopicRequest{Username: username, Topic: topic})
+	require.NoError(t, err)
+
+	_, err = st.TrashClient.RestoreLink(ctx, &linkerV2.DeleteLinkRequest{Username: username, Topic: topic + "/sub", Alias: "first"})
+	require.NoError(t, err)
+
+	links, err := st.LinkerClient.ListLinks(ctx, &linkerV2.ListLinksRequest{Username: username, Topic: topic + "/sub"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"first", "second"}, links.GetAliases())
+
+	_, err = st.TrashClient.RestoreTopic(ctx, &linkerV2.DeleteTopicRequest{Username: username, Topic: topic})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLinkerListsTrash(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic := fillTrash(ctx, t, st)
+
+	resp, err := st.TrashClient.ListTrash(ctx, wrapperspb.String(username))
+	require.NoError(t, err)
+
+	items := resp.GetFields()["items"].GetListValue().GetValues()
+	require.Len(t, items, 2)
+
+	byTopic := make(map[string]map[string]any)
+	for _, item := range items {
+		fields := item.GetStructValue().AsMap()
+		byTopic[fields["topic"].(string)] = fields
+	}
+
+	require.Contains(t, byTopic, topic)
+	assert.Equal(t, "", byTopic[topic]["alias"])
+	assert.Equal(t, float64(1), byTopic[topic]["subtopics"])
+	assert.Equal(t, float64(1), byTopic[topic]["links"])
+
+	require.Contains(t, byTopic, topic+"/sub")
+	assert.Equal(t, "first", byTopic[topic+"/sub"]["alias"])
+}
+
+func TestLinkerExportAndImportAccount(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	source := generateUsername()
+	target := generateUsername()
+	for target == source {
+		target = generateUsername()
+	}
+
+	topic := gofakeit.Word() + "/sub"
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: source, Topic: topic})
+	require.NoError(t, err)
+
+	for _, alias := range []string{"first", "second"} {
+		_, err := st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+			Username: source,
+			Topic:    topic,
+			Link:     "https://example.com/" + alias,
+			Alias:    alias,
+		})
+		require.NoError(t, err)
+	}
+
+	for _, format := range []string{"json", "csv"} {
+		exported, err := st.TransferClient.ExportAccount(
+			metadata.AppendToOutgoingContext(ctx, "export-format", format),
+			wrapperspb.String(source),
+		)
+		require.NoError(t, err)
+
+		importCtx := metadata.AppendToOutgoingContext(ctx, "username", target, "export-format", format)
+
+		result, err := st.TransferClient.ImportAccount(importCtx, wrapperspb.Bytes(exported.GetValue()))
+		require.NoError(t, err)
+
+		links, err := st.LinkerClient.ListLinks(ctx, &linkerV2.ListLinksRequest{Username: target, Topic: topic})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"first", "second"}, links.GetAliases())
+
+		// The links imported from the first file are already there when
+		// the second one is imported, as they are on any repeated import.
+		imported := result.GetFields()["links"].GetNumberValue() + result.GetFields()["skipped"].GetNumberValue()
+		assert.Equal(t, float64(2), imported)
+
+		result, err = st.TransferClient.ImportAccount(importCtx, wrapperspb.Bytes(exported.GetValue()))
+		require.NoError(t, err)
+		assert.Equal(t, float64(0), result.GetFields()["links"].GetNumberValue())
+		assert.Equal(t, float64(2), result.GetFields()["skipped"].GetNumberValue())
+	}
+
+	_, err = st.TransferClient.ImportAccount(
+		metadata.AppendToOutgoingContext(ctx, "username", target, "conflict-policy", "merge"),
+		wrapperspb.Bytes([]byte(`{"version":1}`)),
+	)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// fillTrash posts topic/sub with the links first and second, then deletes the
+// link first and the whole topic, so the trash holds both of them.
+func fillTrash(ctx context.Context, t *testing.T, st *suite.Suite) (username, topic string) {
+	t.Helper()
+
+	username = generateUsername()
+	topic = gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: topic + "/sub"})
+	require.NoError(t, err)
+
+	for _, alias := range []string{"first", "second"} {
+		_, err := st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+			Username: username,
+			Topic:    topic + "/sub",
+			Link:     "https://example.com/" + alias,
+			Alias:    alias,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = st.LinkerClient.DeleteLink(ctx, &linkerV2.DeleteLinkRequest{Username: username, Topic: topic + "/sub", Alias: "first"})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.DeleteTopic(ctx, &linkerV2.DeleteTopicRequest{Username: username, Topic: topic})
+	require.NoError(t, err)
+
+	return username, topic
+}
+
 func generateUsername() string {
 	var username string
 
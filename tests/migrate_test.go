@@ -0,0 +1,288 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/internal/storage/migrate"
+	"github.com/Sleeps17/linker/internal/storage/sqlite"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// testMigrations creates three tables, the last one cannot be rolled back.
+var testMigrations = fstest.MapFS{
+	"0001_notes.up.sql":     {Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY);`)},
+	"0001_notes.down.sql":   {Data: []byte(`DROP TABLE notes;`)},
+	"0002_labels.up.sql":    {Data: []byte(`CREATE TABLE labels (id INTEGER PRIMARY KEY);`)},
+	"0002_labels.down.sql":  {Data: []byte(`DROP TABLE labels;`)},
+	"0003_comments.up.sql":  {Data: []byte(`CREATE TABLE comments (id INTEGER PRIMARY KEY);`)},
+	"0003_comments.up.json": {Data: []byte(`{}`)},
+}
+
+func TestMigrateLoadsMigrations(t *testing.T) {
+	db := openMigrateDB(t, filepath.Join(t.TempDir(), "migrate.db"))
+
+	migrator, err := migrate.New(db, migrate.SQLite, testMigrations)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), migrator.Latest())
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name:  "unknown suffix",
+			files: fstest.MapFS{"0001_notes.sql": {Data: []byte(`SELECT 1;`)}},
+		},
+		{
+			name:  "invalid version",
+			files: fstest.MapFS{"notes.up.sql": {Data: []byte(`SELECT 1;`)}},
+		},
+		{
+			name:  "zero version",
+			files: fstest.MapFS{"0000_notes.up.sql": {Data: []byte(`SELECT 1;`)}},
+		},
+		{
+			name:  "no up migration",
+			files: fstest.MapFS{"0001_notes.down.sql": {Data: []byte(`SELECT 1;`)}},
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"0001_notes.up.sql":  {Data: []byte(`SELECT 1;`)},
+				"0001_labels.up.sql": {Data: []byte(`SELECT 1;`)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrate.New(db, migrate.SQLite, tt.files)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrateDB(t, filepath.Join(t.TempDir(), "migrate.db"))
+
+	migrator, err := migrate.New(db, migrate.SQLite, testMigrations)
+	require.NoError(t, err)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+	assert.Equal(t, []string{"comments", "labels", "notes"}, migrateTables(t, db))
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	// The last migration has no down script, so it stops the rollback.
+	rolledBack, err := migrator.Down(ctx, 1)
+	require.ErrorIs(t, err, migrate.ErrNoDownMigration)
+	assert.Equal(t, 0, rolledBack)
+
+	_, err = db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = 3;`)
+	require.NoError(t, err)
+
+	rolledBack, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rolledBack)
+
+	version, err = migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+	assert.Equal(t, []string{"comments", "notes"}, migrateTables(t, db))
+}
+
+func TestMigrateRejectsUnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrateDB(t, filepath.Join(t.TempDir(), "migrate.db"))
+
+	migrator, err := migrate.New(db, migrate.SQLite, testMigrations)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	// An older build knows only the first migration.
+	older, err := migrate.New(db, migrate.SQLite, fstest.MapFS{
+		"0001_notes.up.sql": testMigrations["0001_notes.up.sql"],
+	})
+	require.NoError(t, err)
+
+	_, err = older.Up(ctx)
+	require.ErrorIs(t, err, migrate.ErrUnknownVersion)
+
+	_, err = older.Version(ctx)
+	require.ErrorIs(t, err, migrate.ErrUnknownVersion)
+}
+
+func TestMigrateConcurrentUp(t *testing.T) {
+	const (
+		instancesCount  = 8
+		migrationsCount = 30
+	)
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "migrate.db")
+
+	migrations := make(fstest.MapFS, migrationsCount)
+	for i := 1; i <= migrationsCount; i++ {
+		migrations[fmt.Sprintf("%04d_table.up.sql", i)] = &fstest.MapFile{
+			Data: []byte(fmt.Sprintf(`CREATE TABLE table_%d (id INTEGER PRIMARY KEY);`, i)),
+		}
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make(chan int, instancesCount)
+	errs := make(chan error, instancesCount)
+
+	for i := 0; i < instancesCount; i++ {
+		migrator, err := migrate.New(openMigrateDB(t, path), migrate.SQLite, migrations)
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			<-start
+			applied, err := migrator.Up(ctx)
+			results <- applied
+			errs <- err
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	total := 0
+	for applied := range results {
+		total += applied
+	}
+	assert.Equal(t, migrationsCount, total)
+}
+
+func TestMigrateRenamesTopicsLikeShared(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "linker.db")
+
+	s := sqlite.MustNew(ctx, path)
+	t.Cleanup(func() { _ = s.Close(ctx) })
+
+	migrator := s.(storage.Migratable).Migrator()
+	_, err := migrator.Up(ctx)
+	require.NoError(t, err)
+
+	// Topics starting with "@" could be created before the last migration.
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+
+	db := openMigrateDB(t, path)
+	_, err = db.ExecContext(ctx, `INSERT INTO users (id, username) VALUES (1, 'bob'), (2, 'ann'), (3, 'eve');`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO topics (user_id, topic) VALUES
+		(1, '@work'), (1, '@work/backend'), (1, 'at-work'), (1, '@home'),
+		(2, '@work'), (2, '@work/backend'), (2, 'plain'),
+		(3, '@work'), (3, 'at-work'), (3, 'at-work-2'), (3, '@work-3');`)
+	require.NoError(t, err)
+
+	snapshot, err := storage.TrashSnapshot{
+		Topics: []string{"@work/old", "@work/old/go"},
+		Links:  []models.Link{{URL: "https://go.dev/", Alias: "go", Topic: "@work/old/go"}},
+	}.Encode()
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO trash (user_id, topic, alias, snapshot, deleted_at) VALUES
+		(1, '@work/old', '', ?, CURRENT_TIMESTAMP), (2, '@gone', '', '{"links":null}', CURRENT_TIMESTAMP);`, snapshot)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"1:at-home", "1:at-work", "1:at-work-2", "1:at-work-2/backend",
+		"2:at-work", "2:at-work/backend", "2:plain",
+		"3:at-work", "3:at-work-2", "3:at-work-3", "3:at-work-4",
+	}, migrateStrings(t, db, `SELECT user_id || ':' || topic FROM topics ORDER BY user_id, topic;`))
+
+	assert.Equal(t, []string{"1:at-work-2/old", "2:at-gone"},
+		migrateStrings(t, db, `SELECT user_id || ':' || topic FROM trash ORDER BY user_id;`))
+
+	snapshots := migrateStrings(t, db, `SELECT snapshot FROM trash ORDER BY user_id;`)
+	restored, err := storage.DecodeTrashSnapshot(snapshots[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"at-work-2/old", "at-work-2/old/go"}, restored.Topics)
+	require.Len(t, restored.Links, 1)
+	assert.Equal(t, "at-work-2/old/go", restored.Links[0].Topic)
+	assert.Equal(t, "go", restored.Links[0].Alias)
+
+	_, err = s.RestoreTopic(ctx, "bob", "at-work-2/old")
+	require.NoError(t, err)
+
+	// Paths of shared topics are never restored as own topics.
+	_, err = db.ExecContext(ctx, `INSERT INTO trash (user_id, topic, alias, snapshot, deleted_at)
+		VALUES (1, '@stale', '', '{"topics":["@stale"],"links":null}', CURRENT_TIMESTAMP);`)
+	require.NoError(t, err)
+	_, err = s.RestoreTopic(ctx, "bob", "@stale")
+	require.ErrorIs(t, err, storage.ErrInvalidTopicPath)
+}
+
+// openMigrateDB opens the sqlite database at path the way the sqlite storage
+// does, every call gets a connection of its own.
+func openMigrateDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000", path))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func migrateTables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	return migrateStrings(t, db, `SELECT name FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations' ORDER BY name;`)
+}
+
+// migrateStrings returns the single text column of the rows of query.
+func migrateStrings(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		require.NoError(t, rows.Scan(&value))
+		values = append(values, value)
+	}
+	require.NoError(t, rows.Err())
+
+	return values
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"encoding/xml"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/http/linker/handlers"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/tests/suite"
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestRestPublishesTopic(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	topic := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: topic + "/sub"})
+	require.NoError(t, err)
+
+	for _, alias := range []string{"first", "second"} {
+		_, err := st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+			Username: username,
+			Topic:    topic + "/sub",
+			Link:     "https://example.com/" + alias,
+			Alias:    alias,
+		})
+		require.NoError(t, err)
+	}
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	var published models.PublishTopicResponse
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodPost, "/topics/publish", token, models.PublishTopicRequest{Topic: topic}, &published)
+		return err == nil && status == http.StatusOK
+	}, syncTimeout, syncTick)
+	require.GreaterOrEqual(t, len(published.Slug), 22)
+
+	// Publishing again keeps the slug.
+	var again models.PublishTopicResponse
+	status, err := restRequest(st, http.MethodPost, "/topics/publish", token, models.PublishTopicRequest{Topic: topic}, &again)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, published.Slug, again.Slug)
+
+	// The page and the feeds need no token and include the subtopics.
+	status, page := getPublic(t, published.URL)
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, page, "https://example.com/first")
+	assert.Contains(t, page, "https://example.com/second")
+
+	status, body := getPublic(t, published.URL+"/atom")
+	require.Equal(t, http.StatusOK, status)
+
+	var atom struct {
+		Entries []struct {
+			Link struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"entry"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(body), &atom))
+	require.Len(t, atom.Entries, 2)
+	assert.Equal(t, "https://example.com/second", atom.Entries[0].Link.Href)
+	assert.Equal(t, "https://example.com/first", atom.Entries[1].Link.Href)
+
+	status, body = getPublic(t, published.URL+"/rss")
+	require.Equal(t, http.StatusOK, status)
+
+	var rss struct {
+		Items []struct {
+			Link string `xml:"link"`
+		} `xml:"channel>item"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(body), &rss))
+	require.Len(t, rss.Items, 2)
+	assert.Equal(t, "https://example.com/second", rss.Items[0].Link)
+
+	var list models.ListPublishedTopicsResponse
+	status, err = restRequest(st, http.MethodGet, "/topics/published", token, nil, &list)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, list.Topics, 1)
+	assert.Equal(t, topic, list.Topics[0].Topic)
+
+	status, err = restRequest(st, http.MethodDelete, "/topics/publish", token, models.UnpublishTopicRequest{Topic: topic}, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	status, _ = getPublic(t, published.URL)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = getPublic(t, published.URL+"/atom")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestRestPublicURLsIgnoreRequestHost(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	topic := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: username, Topic: topic})
+	require.NoError(t, err)
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	var published models.PublishTopicResponse
+	status, err := restRequest(st, http.MethodPost, "/topics/publish", token, models.PublishTopicRequest{Topic: topic}, &published)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	pageURL := st.Cfg.Publish.BaseURL + handlers.PublicTopicPath + published.Slug
+	assert.Equal(t, pageURL, published.URL)
+
+	// The feed links back to the page at the configured address whatever
+	// the request says it came to.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, published.URL+"/atom", nil)
+	require.NoError(t, err)
+	req.Host = "attacker.example"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), pageURL)
+	assert.NotContains(t, string(body), "attacker.example")
+}
+
+// getPublic fetches url without a token and returns the status and the body.
+func getPublic(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
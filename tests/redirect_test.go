@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"fmt"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRestRedirectsByLinkCode(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, link, alias := postShortenerTopic(ctx, t, st)
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	query := url.Values{"topic": {topic}, "alias": {alias}}
+	var picked models.PickLinkResponse
+	status, err := restRequest(st, http.MethodGet, "/links?"+query.Encode(), token, nil, &picked)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.NotEmpty(t, picked.Code)
+
+	status, location := getRedirect(t, fmt.Sprintf("%s/s/%s/%s", st.RestURL, username, picked.Code))
+	assert.Equal(t, http.StatusFound, status)
+	assert.Equal(t, link, location)
+
+	// Codes are random, not the ids of the links.
+	for id := 1; id <= 3; id++ {
+		status, _ = getRedirect(t, fmt.Sprintf("%s/s/%s/%d", st.RestURL, username, id))
+		assert.Equal(t, http.StatusNotFound, status)
+	}
+}
+
+func TestRestRedirectHidesForeignAndMalformedPaths(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	owner, topic, _, alias := postShortenerTopic(ctx, t, st)
+	username, _, _, _ := postShortenerTopic(ctx, t, st)
+
+	paths := []string{
+		// The topic isn't shared with the user.
+		fmt.Sprintf("@%s/%s/%s", owner, topic, alias),
+		// Shared topics can't be nested in shared paths.
+		fmt.Sprintf("@%s/@%s/%s/%s", owner, owner, topic, alias),
+		fmt.Sprintf("@%s/@%s/%s", username, topic, alias),
+	}
+
+	for _, path := range paths {
+		status, _ := getRedirect(t, fmt.Sprintf("%s/r/%s/%s", st.RestURL, username, path))
+		assert.Equal(t, http.StatusNotFound, status, path)
+	}
+}
+
+// getRedirect requests url without following redirects and returns the status
+// and the location redirected to.
+func getRedirect(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode, resp.Header.Get("Location")
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/internal/storage"
+	"github.com/Sleeps17/linker/tests/suite"
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"testing"
+)
+
+func TestLinkerSharesTopic(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	owner := generateUsername()
+	user := generateUsername()
+	topic := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: owner, Topic: topic + "/sub"})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+		Username: owner,
+		Topic:    topic + "/sub",
+		Link:     "https://example.com/first",
+		Alias:    "first",
+	})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{Username: user, Topic: gofakeit.Word()})
+	require.NoError(t, err)
+
+	shared := storage.SharedTopicPath(owner, topic+"/sub")
+
+	// Nothing is visible before the topic is shared.
+	_, err = st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{Username: user, Topic: shared, Alias: "first"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// A share of the topic covers its subtopics.
+	shareTopic(ctx, t, st, owner, topic, user, models.ShareViewer)
+
+	topics, err := st.SharingClient.ListSharedTopics(ctx, wrapperspb.String(user))
+	require.NoError(t, err)
+	require.Len(t, topics.GetFields()["topics"].GetListValue().GetValues(), 1)
+
+	picked, err := st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{Username: user, Topic: shared, Alias: "first"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/first", picked.GetLink())
+
+	links, err := st.LinkerClient.ListLinks(ctx, &linkerV2.ListLinksRequest{Username: user, Topic: shared})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, links.GetAliases())
+
+	postSecond := &linkerV2.PostLinkRequest{
+		Username: user,
+		Topic:    shared,
+		Link:     "https://example.com/second",
+		Alias:    "second",
+	}
+
+	_, err = st.LinkerClient.PostLink(ctx, postSecond)
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Sharing again changes the role.
+	shareTopic(ctx, t, st, owner, topic, user, models.ShareEditor)
+
+	_, err = st.LinkerClient.PostLink(ctx, postSecond)
+	require.NoError(t, err)
+
+	links, err = st.LinkerClient.ListLinks(ctx, &linkerV2.ListLinksRequest{Username: owner, Topic: topic + "/sub"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"first", "second"}, links.GetAliases())
+
+	// Editors work with the links, the topics stay with the owner.
+	_, err = st.LinkerClient.DeleteTopic(ctx, &linkerV2.DeleteTopicRequest{Username: user, Topic: shared})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.SharingClient.UnshareTopic(ctx, &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"username": structpb.NewStringValue(owner),
+			"topic":    structpb.NewStringValue(topic),
+			"user":     structpb.NewStringValue(user),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{Username: user, Topic: shared, Alias: "first"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRestSharesTopic(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	owner := generateUsername()
+	user := generateUsername()
+	topic := gofakeit.Word()
+
+	ownerToken, _, err := st.Tokens.IssueToken(ctx, owner, "test")
+	require.NoError(t, err)
+
+	userToken, _, err := st.Tokens.IssueToken(ctx, user, "test")
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		status, err := restRequest(st, http.MethodPost, "/topics", ownerToken, models.PostTopicRequest{Topic: topic}, nil)
+		return err == nil && status == http.StatusOK
+	}, syncTimeout, syncTick)
+
+	share := models.ShareTopicRequest{Topic: topic, Username: user, Role: "owner"}
+	status, err := restRequest(st, http.MethodPost, "/topics/shares", ownerToken, share, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	share.Role = models.ShareViewer
+	status, err = restRequest(st, http.MethodPost, "/topics/shares", ownerToken, share, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	var shares models.ListTopicSharesResponse
+	status, err = restRequest(st, http.MethodGet, "/topics/shares?topic="+topic, ownerToken, nil, &shares)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []models.TopicShare{{Username: user, Role: models.ShareViewer}}, shares.Shares)
+
+	var shared models.ListSharedTopicsResponse
+	status, err = restRequest(st, http.MethodGet, "/topics/shared", userToken, nil, &shared)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, shared.Topics, 1)
+	assert.Equal(t, storage.SharedTopicPath(owner, topic), shared.Topics[0].Path)
+
+	post := models.PostLinkRequest{Topic: shared.Topics[0].Path, Link: "https://example.com", Alias: "example"}
+	status, err = restRequest(st, http.MethodPost, "/links", userToken, post, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	unshare := models.UnshareTopicRequest{Topic: topic, Username: user}
+	status, err = restRequest(st, http.MethodDelete, "/topics/shares", ownerToken, unshare, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	status, err = restRequest(st, http.MethodDelete, "/topics/shares", ownerToken, unshare, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func shareTopic(ctx context.Context, t *testing.T, st *suite.Suite, owner, topic, user string, role models.ShareRole) {
+	t.Helper()
+
+	_, err := st.SharingClient.ShareTopic(ctx, &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"username": structpb.NewStringValue(owner),
+			"topic":    structpb.NewStringValue(topic),
+			"user":     structpb.NewStringValue(user),
+			"role":     structpb.NewStringValue(string(role)),
+		},
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,341 @@
+package tests
+
+import (
+	"context"
+	linkerV2 "github.com/Sleeps17/linker-protos/gen/go/linker"
+	"github.com/Sleeps17/linker/internal/models"
+	"github.com/Sleeps17/linker/tests/suite"
+	"github.com/brianvoe/gofakeit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	syncTimeout = 5 * time.Second
+	syncTick    = 20 * time.Millisecond
+)
+
+func TestLinkerShortensAndDeletesLink(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, link, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		saved, ok := st.Shortener.Saved(alias)
+		return ok && saved == link
+	}, syncTimeout, syncTick)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	resp, err := st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, link, resp.GetLink())
+	assert.Equal(t, st.Shortener.URL+"/"+alias, pickShortURL(ctx, t, st, username, topic, alias))
+
+	_, err = st.LinkerClient.DeleteLink(ctx, &linkerV2.DeleteLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return !ok
+	}, syncTimeout, syncTick)
+}
+
+func TestLinkerShortensLinkAfterShortenerFailures(t *testing.T) {
+	faults := []struct {
+		name  string
+		fault suite.Fault
+	}{
+		{name: "server error", fault: suite.Fault{Status: http.StatusInternalServerError}},
+		{name: "malformed response", fault: suite.Fault{Malformed: true}},
+		{name: "timeout", fault: suite.Fault{Latency: time.Second}},
+	}
+
+	for _, tt := range faults {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, st := suite.New(t)
+
+			st.Shortener.SetFault(tt.fault)
+
+			username, topic, link, alias := postShortenerTopic(ctx, t, st)
+
+			// The link is saved as is while the shortener fails.
+			require.Eventually(t, func() bool {
+				return st.Shortener.Calls(http.MethodPost) >= 2
+			}, syncTimeout, syncTick)
+
+			resp, err := st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{
+				Username: username,
+				Topic:    topic,
+				Alias:    alias,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, link, resp.GetLink())
+			assert.Empty(t, pickShortURL(ctx, t, st, username, topic, alias))
+
+			st.Shortener.SetFault(suite.Fault{})
+
+			require.Eventually(t, func() bool {
+				return pickShortURL(ctx, t, st, username, topic, alias) == st.Shortener.URL+"/"+alias
+			}, syncTimeout, syncTick)
+
+			saved, ok := st.Shortener.Saved(alias)
+			require.True(t, ok)
+			assert.Equal(t, link, saved)
+		})
+	}
+}
+
+func TestLinkerDeletesShortURLAfterShortenerFailures(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, _, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	st.Shortener.SetFault(suite.Fault{Status: http.StatusServiceUnavailable})
+
+	_, err := st.LinkerClient.DeleteLink(ctx, &linkerV2.DeleteLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return st.Shortener.Calls(http.MethodDelete) >= 2
+	}, syncTimeout, syncTick)
+
+	_, ok := st.Shortener.Saved(alias)
+	require.True(t, ok)
+
+	st.Shortener.SetFault(suite.Fault{})
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return !ok
+	}, syncTimeout, syncTick)
+}
+
+func TestLinkerDropsRejectedShortenerTask(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, _, _, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return ok
+	}, syncTimeout, syncTick)
+
+	// The shortener refuses the alias taken by the first link.
+	username := generateUsername()
+	topic := gofakeit.Word()
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+		Username: username,
+		Topic:    topic,
+	})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Link:     gofakeit.URL(),
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return st.Shortener.Calls(http.MethodPost) == 2
+	}, syncTimeout, syncTick)
+
+	// Retries would come after the backoff.
+	time.Sleep(4 * st.Cfg.ShortenerSync.RetryBackoff)
+	assert.Equal(t, 2, st.Shortener.Calls(http.MethodPost))
+	assert.Empty(t, pickShortURL(ctx, t, st, username, topic, alias))
+}
+
+func TestRestShortensAndDeletesLink(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username := generateUsername()
+	topic := gofakeit.Word()
+	link := gofakeit.URL()
+	alias := gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+		Username: username,
+		Topic:    topic,
+	})
+	require.NoError(t, err)
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	status, err := restRequest(st, http.MethodPost, "/links", token, models.PostLinkRequest{
+		Topic: topic,
+		Link:  link,
+		Alias: alias,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	status, err = restRequest(st, http.MethodDelete, "/links", token, models.DeleteLinkRequest{
+		Topic: topic,
+		Alias: alias,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return !ok
+	}, syncTimeout, syncTick)
+}
+
+func TestLinkerDeletesShortURLsWithTopic(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, _, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	_, err := st.LinkerClient.DeleteTopic(ctx, &linkerV2.DeleteTopicRequest{
+		Username: username,
+		Topic:    topic,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return !ok
+	}, syncTimeout, syncTick)
+}
+
+func TestLinkerReshortensRestoredLink(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, link, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	_, err := st.LinkerClient.DeleteLink(ctx, &linkerV2.DeleteLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, ok := st.Shortener.Saved(alias)
+		return !ok
+	}, syncTimeout, syncTick)
+
+	_, err = st.TrashClient.RestoreLink(ctx, &linkerV2.DeleteLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		saved, ok := st.Shortener.Saved(alias)
+		return ok && saved == link && pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+}
+
+func TestRestReshortensUpdatedLink(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	username, topic, _, alias := postShortenerTopic(ctx, t, st)
+
+	require.Eventually(t, func() bool {
+		return pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+
+	token, _, err := st.Tokens.IssueToken(ctx, username, "test")
+	require.NoError(t, err)
+
+	updated := gofakeit.URL()
+	status, err := restRequest(st, http.MethodPatch, "/links", token, models.UpdateLinkRequest{
+		Topic: topic,
+		Alias: alias,
+		Link:  &updated,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Eventually(t, func() bool {
+		saved, ok := st.Shortener.Saved(alias)
+		return ok && saved == updated && pickShortURL(ctx, t, st, username, topic, alias) != ""
+	}, syncTimeout, syncTick)
+}
+
+// postShortenerTopic posts a link to a new topic of a new user.
+func postShortenerTopic(ctx context.Context, t *testing.T, st *suite.Suite) (username, topic, link, alias string) {
+	t.Helper()
+
+	username = generateUsername()
+	topic = gofakeit.Word()
+	link = gofakeit.URL()
+	alias = gofakeit.Word()
+
+	_, err := st.LinkerClient.PostTopic(ctx, &linkerV2.PostTopicRequest{
+		Username: username,
+		Topic:    topic,
+	})
+	require.NoError(t, err)
+
+	_, err = st.LinkerClient.PostLink(ctx, &linkerV2.PostLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Link:     link,
+		Alias:    alias,
+	})
+	require.NoError(t, err)
+
+	return username, topic, link, alias
+}
+
+// pickShortURL returns the short URL PickLink sends in its header.
+func pickShortURL(ctx context.Context, t *testing.T, st *suite.Suite, username, topic, alias string) string {
+	t.Helper()
+
+	var header metadata.MD
+	_, err := st.LinkerClient.PickLink(ctx, &linkerV2.PickLinkRequest{
+		Username: username,
+		Topic:    topic,
+		Alias:    alias,
+	}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	if values := header.Get("short-url"); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,97 @@
+package suite
+
+import (
+	"context"
+	"github.com/Sleeps17/linker/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"sync"
+)
+
+const authorizationKey = "authorization"
+
+// tokenIssuer signs every gRPC call as the user it is made for, so tests name
+// users in requests the way they did before the server required tokens. Calls
+// that carry authorization already are sent as they are.
+type tokenIssuer struct {
+	tokens *auth.Service
+
+	mu     sync.Mutex
+	issued map[string]string
+}
+
+func newTokenIssuer(tokens *auth.Service) *tokenIssuer {
+	return &tokenIssuer{
+		tokens: tokens,
+		issued: make(map[string]string),
+	}
+}
+
+func (i *tokenIssuer) intercept(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(authorizationKey)) > 0 {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	token, err := i.token(ctx, requestUsername(ctx, req))
+	if err != nil {
+		return err
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, authorizationKey, Bearer(token))
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func (i *tokenIssuer) token(ctx context.Context, username string) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if token, ok := i.issued[username]; ok {
+		return token, nil
+	}
+
+	token, _, err := i.tokens.IssueToken(ctx, username, "tests")
+	if err != nil {
+		return "", err
+	}
+
+	i.issued[username] = token
+
+	return token, nil
+}
+
+// requestUsername finds the user a request is made for: a username field,
+// the username of an account export or of a sharing request, or the username
+// metadata of an import.
+func requestUsername(ctx context.Context, req any) string {
+	switch r := req.(type) {
+	case interface{ GetUsername() string }:
+		return r.GetUsername()
+	case *wrapperspb.StringValue:
+		return r.GetValue()
+	case *structpb.Struct:
+		return r.GetFields()["username"].GetStringValue()
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get("username"); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// Bearer makes the authorization value of token.
+func Bearer(token string) string {
+	return "Bearer " + token
+}
@@ -0,0 +1,160 @@
+package suite
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Fault is what the fake shortener does wrong while it is set.
+type Fault struct {
+	// Latency delays every response.
+	Latency time.Duration
+	// Status answers with the status code and no body.
+	Status int
+	// Malformed answers 200 with a body that isn't JSON.
+	Malformed bool
+}
+
+// FakeShortener stands in for the url-shortener service. It speaks the same
+// protocol: JSON requests with basic auth, POST / saves an alias and DELETE /
+// deletes it, responses carry status, error and alias.
+type FakeShortener struct {
+	*httptest.Server
+
+	username string
+	password string
+
+	mu    sync.Mutex
+	urls  map[string]string
+	fault Fault
+	calls map[string]int
+}
+
+func NewFakeShortener(username, password string) *FakeShortener {
+	f := &FakeShortener{
+		username: username,
+		password: password,
+		urls:     make(map[string]string),
+		calls:    make(map[string]int),
+	}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+
+	return f
+}
+
+// SetFault makes the following calls fail, the zero Fault heals the fake.
+func (f *FakeShortener) SetFault(fault Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fault = fault
+}
+
+// Saved returns the URL saved under alias.
+func (f *FakeShortener) Saved(alias string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	url, ok := f.urls[alias]
+
+	return url, ok
+}
+
+// Calls counts the calls made with method, failed ones included.
+func (f *FakeShortener) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls[method]
+}
+
+func (f *FakeShortener) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.calls[r.Method]++
+	fault := f.fault
+	f.mu.Unlock()
+
+	// The request is read first: the server notices that the client has gone
+	// only after the body is consumed, and a late request must not save.
+	var req struct {
+		Url   string `json:"url"`
+		Alias string `json:"alias"`
+	}
+	decodeErr := json.NewDecoder(r.Body).Decode(&req)
+
+	if fault.Latency > 0 {
+		select {
+		case <-time.After(fault.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fault.Status != 0 {
+		w.WriteHeader(fault.Status)
+		return
+	}
+
+	if fault.Malformed {
+		_, _ = w.Write([]byte(`{"status": "OK", "alias": `))
+		return
+	}
+
+	if username, password, ok := r.BasicAuth(); !ok || username != f.username || password != f.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if decodeErr != nil || r.URL.Path != "/" {
+		respond(w, "failed to decode request", "")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		if req.Url == "" || req.Alias == "" {
+			respond(w, "url and alias are required", "")
+			return
+		}
+
+		if _, ok := f.urls[req.Alias]; ok {
+			respond(w, "alias already exists", "")
+			return
+		}
+
+		f.urls[req.Alias] = req.Url
+		respond(w, "", req.Alias)
+	case http.MethodDelete:
+		if _, ok := f.urls[req.Alias]; !ok {
+			respond(w, "alias not found", "")
+			return
+		}
+
+		delete(f.urls, req.Alias)
+		respond(w, "", "")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func respond(w http.ResponseWriter, errMsg, alias string) {
+	resp := struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+		Alias  string `json:"alias,omitempty"`
+	}{Status: "OK", Error: errMsg, Alias: alias}
+
+	if errMsg != "" {
+		resp.Status = "Error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
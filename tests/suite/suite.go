@@ -2,85 +2,147 @@ package suite
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	linkerV1 "github.com/Sleeps17/linker-protos/gen/go/linker"
 	"github.com/Sleeps17/linker/internal/app"
-	mockUrlShortener "github.com/Sleeps17/linker/internal/clients/url-shortener/mock"
+	"github.com/Sleeps17/linker/internal/auth"
+	urlShortenerClient "github.com/Sleeps17/linker/internal/clients/url-shortener/url-shortener-client"
 	"github.com/Sleeps17/linker/internal/config"
+	server "github.com/Sleeps17/linker/internal/grpc/linker"
 	"github.com/Sleeps17/linker/internal/logger"
-	"github.com/Sleeps17/linker/internal/storage/postgresql"
-	"github.com/golang/mock/gomock"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"net"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 const (
 	serverHost = "localhost"
+
+	sqliteDriver = "sqlite"
+
+	startTimeout = 5 * time.Second
+	startTick    = 10 * time.Millisecond
 )
 
 type Suite struct {
 	*testing.T
-	Cfg          *config.Config
-	LinkerClient linkerV1.LinkerClient
+	Cfg            *config.Config
+	LinkerClient   linkerV1.LinkerClient
+	TrashClient    *server.TrashClient
+	TransferClient *server.TransferClient
+	SharingClient  *server.SharingClient
+	Shortener      *FakeShortener
+	// Tokens issues API tokens for the REST server at RestURL. The gRPC
+	// clients get them on their own.
+	Tokens  *auth.Service
+	RestURL string
 }
 
 func New(t *testing.T) (context.Context, *Suite) {
 	t.Helper()
 
+	return NewWithDriver(t, "")
+}
+
+// NewWithDriver is New with the storage backend set to driver, the driver of
+// the test config is used when it is empty. A sqlite database lives in a
+// temporary directory of the test.
+func NewWithDriver(t *testing.T, driver string) (context.Context, *Suite) {
+	t.Helper()
+
 	cfg := config.MustLoadByPath("../config/test.yaml")
+	if driver != "" {
+		cfg.DataBase.Driver = driver
+	}
+	if cfg.DataBase.Driver == sqliteDriver {
+		cfg.DataBase.Path = filepath.Join(t.TempDir(), "linker.db")
+	}
 
 	log := logger.Setup(cfg.Env)
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.DataBase.Timeout)
 	defer cancel()
-	storage := postgresql.MustNew(ctx, createPostgresConnString(cfg))
+	storage := app.MustNewStorage(ctx, log, &cfg.DataBase)
 
-	ctrl := gomock.NewController(t)
-	mockedShortener := mockUrlShortener.NewMockUrlShortener(ctrl)
-	mockedShortener.EXPECT().SaveURL(gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("some err")).AnyTimes()
-	mockedShortener.EXPECT().DeleteURL(gomock.Any(), gomock.Any()).Return(errors.New("some err")).AnyTimes()
+	// The app talks to a fake url-shortener through the real client.
+	shortener := NewFakeShortener(cfg.UrlShortenerClient.Username, cfg.UrlShortenerClient.Password)
+	cfg.UrlShortenerClient.Host, cfg.UrlShortenerClient.Port, _ = net.SplitHostPort(shortener.Listener.Addr().String())
+	cfg.UrlShortenerClient.BaseURL = shortener.URL
 
-	application := app.New(log, int(cfg.Server.Port), storage, storage, mockedShortener)
+	application := app.New(log, cfg, storage, urlShortenerClient.New(&cfg.UrlShortenerClient, log))
 	log.Info("application configured successfully")
 
-	// TODO: Start server
-	go application.MustRun()
+	application.MustStart()
+
+	// Stopping a server before it serves panics, so tests that never reach
+	// the servers wait for them as well.
+	for _, address := range []string{serverAddress(cfg), restAddress(cfg)} {
+		if err := waitForServer(address); err != nil {
+			t.Fatalf("server is not started: %v", err)
+		}
+	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), cfg.Server.Timeout)
+	ctx, cancel = context.WithTimeout(context.Background(), cfg.Grpc.Timeout)
 
 	t.Cleanup(func() {
 		t.Helper()
-		ctrl.Finish()
 		cancel()
 
 		application.Stop()
+		shortener.Close()
 	})
 
-	cc, err := grpc.NewClient(serverAddress(cfg), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	tokens := auth.New(storage)
+
+	cc, err := grpc.NewClient(
+		serverAddress(cfg),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(newTokenIssuer(tokens).intercept),
+	)
 	if err != nil {
 		t.Fatalf("grpc server connection failed: %v", err)
 	}
 
 	return ctx, &Suite{
-		Cfg:          cfg,
-		LinkerClient: linkerV1.NewLinkerClient(cc),
+		Cfg:            cfg,
+		LinkerClient:   linkerV1.NewLinkerClient(cc),
+		TrashClient:    server.NewTrashClient(cc),
+		TransferClient: server.NewTransferClient(cc),
+		SharingClient:  server.NewSharingClient(cc),
+		Shortener:      shortener,
+		Tokens:         tokens,
+		RestURL:        restURL(cfg),
+	}
+}
+
+func waitForServer(address string) error {
+	deadline := time.Now().Add(startTimeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, startTick)
+		if err == nil {
+			return conn.Close()
+		}
+
+		if time.Now().After(deadline) {
+			return err
+		}
+
+		time.Sleep(startTick)
 	}
 }
 
 func serverAddress(cfg *config.Config) string {
-	return net.JoinHostPort(serverHost, fmt.Sprint(cfg.Server.Port))
+	_, port, _ := net.SplitHostPort(cfg.Grpc.Port)
+	return net.JoinHostPort(serverHost, port)
 }
 
-func createPostgresConnString(cfg *config.Config) string {
-	return fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
-		cfg.DataBase.Host,
-		cfg.DataBase.Port,
-		cfg.DataBase.Username,
-		cfg.DataBase.Name,
-		cfg.DataBase.Password,
-	)
+func restAddress(cfg *config.Config) string {
+	_, port, _ := net.SplitHostPort(cfg.Rest.Port)
+	return net.JoinHostPort(serverHost, port)
+}
+
+func restURL(cfg *config.Config) string {
+	return "http://" + restAddress(cfg)
 }